@@ -6,10 +6,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +18,7 @@ import (
 
 	cfgpkg "github.com/FBakkensen/bc-insights-tui/config"
 	"github.com/FBakkensen/bc-insights-tui/debugdump"
+	"github.com/FBakkensen/bc-insights-tui/internal/kql"
 	"github.com/FBakkensen/bc-insights-tui/internal/util"
 	"github.com/FBakkensen/bc-insights-tui/logging"
 )
@@ -50,16 +51,28 @@ type Client struct {
 	rawPath     string
 	rawMaxBytes int
 	rawKeepN    int
+	retry       RetryPolicy
 }
 
 // QueryRequest represents a KQL query request
 type QueryRequest struct {
-	Query string `json:"query"`
+	Query    string `json:"query"`
+	Timespan string `json:"timespan,omitempty"`
 }
 
 // QueryResponse represents the response from Application Insights API
 type QueryResponse struct {
-	Tables []Table `json:"tables"`
+	Tables     []Table            `json:"tables"`
+	FetchLimit FetchLimitDecision `json:"-"` // whether ExecuteQuery added "| take N"
+}
+
+// FetchLimitDecision records whether the configured fetch size was appended to
+// the query as "| take N" and why.
+type FetchLimitDecision struct {
+	Applied    bool
+	Limit      int
+	Reason     string // applied_no_user_limit, user_explicit, fetch_zero or parse_error
+	Statements []int  // 1-based positions, among the query statements, of those capped
 }
 
 // Table represents a result table from the API
@@ -91,6 +104,7 @@ func NewClient(token *oauth2.Token, appID string) *Client {
 		rawPath:     resolvedPath,
 		rawMaxBytes: maxBytes,
 		rawKeepN:    cfg.DebugAppInsightsRawKeepN,
+		retry:       retryPolicyFrom(cfg),
 	}
 }
 
@@ -112,13 +126,18 @@ func NewClientWithAuthenticator(authenticator ApplicationInsightsAuthenticator,
 		rawPath:     resolvedPath,
 		rawMaxBytes: maxBytes,
 		rawKeepN:    cfg.DebugAppInsightsRawKeepN,
+		retry:       retryPolicyFrom(cfg),
 	}
 }
 
-// ExecuteQuery executes a KQL query against Application Insights
-func (c *Client) ExecuteQuery(ctx context.Context, query string) (*QueryResponse, error) {
-	// Apply configured fetch limit to simple top-level table queries when not explicitly set
-	query = c.maybeApplyFetchLimit(query)
+// ExecuteQuery executes a KQL query against Application Insights.
+// A non-zero timespan is sent as the API's "timespan" field and limits the rows
+// the service considers, in addition to any filters in the query itself.
+// Throttled (429) and transient (5xx, transport) failures are retried per the
+// configured RetryPolicy without outliving ctx; see WithRetryNotifier.
+func (c *Client) ExecuteQuery(ctx context.Context, query string, timespan Timespan) (*QueryResponse, error) {
+	// Apply configured fetch limit to query statements that do not limit their rows
+	query, fetchLimit := c.maybeApplyFetchLimit(query)
 
 	// Get a valid token, either from stored token or via authenticator
 	token, err := c.getValidToken(ctx)
@@ -128,7 +147,7 @@ func (c *Client) ExecuteQuery(ctx context.Context, query string) (*QueryResponse
 	}
 
 	// Prepare the request
-	reqBody := QueryRequest{Query: query}
+	reqBody := QueryRequest{Query: query, Timespan: timespan.String()}
 	bodyJSON, err := json.Marshal(reqBody)
 	if err != nil {
 		logging.Error("KQL request marshal failed", "error", err.Error())
@@ -144,60 +163,102 @@ func (c *Client) ExecuteQuery(ctx context.Context, query string) (*QueryResponse
 		"url", queryURL,
 		"appId_len", fmt.Sprintf("%d", len(strings.TrimSpace(c.appID))),
 		"body_bytes", fmt.Sprintf("%d", len(bodyJSON)),
+		"timespan", util.FirstNonEmpty(reqBody.Timespan, "none"),
+		"max_attempts", fmt.Sprintf("%d", c.retry.MaxAttempts),
 		"timeout_set", timeoutSet,
 		"deadline", deadlineStr,
 	)
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", queryURL, bytes.NewReader(bodyJSON))
-	if err != nil {
-		logging.Error("KQL request creation failed", "error", err.Error())
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
-
 	// Prepare optional raw debug capture (using snapshot)
 	rawEnabled := c.rawEnabled
 	resolvedPath := c.rawPath
 	maxBytes := c.rawMaxBytes
 	checkAndLogRawConfigChange(rawEnabled, resolvedPath, maxBytes)
-
-	// If enabled, write request-only capture first
+	startedAt := ""
 	if rawEnabled {
-		startedAt := debugdump.Now()
-		writeRawRequestCapture(req, bodyJSON, maxBytes, resolvedPath, startedAt)
-		// Store startedAt in request context for later completion capture
-		ctx = context.WithValue(ctx, ctxKeyStartedAt{}, startedAt)
+		startedAt = debugdump.Now()
 	}
 
-	// Execute request
-	start := time.Now()
-	logging.Debug("KQL request sending")
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		// Capture whether this is a context deadline / timeout
-		sel := ""
-		if ctx.Err() != nil {
-			sel = ctx.Err().Error()
+	// Execute request, retrying throttled and transient failures per c.retry
+	var (
+		req      *http.Request
+		resp     *http.Response
+		body     []byte
+		start    time.Time
+		attempts []debugdump.AIRawAttempt
+	)
+	for attempt := 1; ; attempt++ {
+		req, err = newQueryRequest(ctx, queryURL, bodyJSON, token)
+		if err != nil {
+			logging.Error("KQL request creation failed", "error", err.Error())
+			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
-		logging.Error("KQL request failed", "error", err.Error(), "ctxErr", sel)
-		// Write error-only capture if enabled
-		if rawEnabled {
-			startedAt, _ := ctx.Value(ctxKeyStartedAt{}).(string)
-			writeRawTransportError(req, bodyJSON, maxBytes, resolvedPath, err, time.Since(start), startedAt, c.rawKeepN)
+		// If enabled, write request-only capture first
+		if rawEnabled && attempt == 1 {
+			writeRawRequestCapture(req, bodyJSON, maxBytes, resolvedPath, startedAt)
+		}
+
+		start = time.Now()
+		rec := debugdump.AIRawAttempt{Attempt: attempt, StartedAt: debugdump.Now()}
+		logging.Debug("KQL request sending", "attempt", fmt.Sprintf("%d", attempt))
+		resp, err = c.httpClient.Do(req)
+		rec.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			rec.Error = err.Error()
+			wait, ok := c.nextRetry(ctx, attempt, nil, &rec)
+			attempts = append(attempts, rec)
+			if ok && c.waitForRetry(ctx, attempt, 0, err.Error(), wait, false) == nil {
+				continue
+			}
+			// Capture whether this is a context deadline / timeout
+			sel := ""
+			if ctx.Err() != nil {
+				sel = ctx.Err().Error()
+			}
+			if canceledByCaller(ctx) {
+				logging.Info("KQL request canceled", "attempts", fmt.Sprintf("%d", attempt), "duration_ms", fmt.Sprintf("%d", time.Since(start).Milliseconds()))
+			} else {
+				logging.Error("KQL request failed", "error", err.Error(), "ctxErr", sel, "attempts", fmt.Sprintf("%d", attempt))
+			}
+			// Write error-only capture if enabled
+			if rawEnabled {
+				writeRawTransportError(req, bodyJSON, maxBytes, resolvedPath, err, time.Since(start), startedAt, c.rawKeepN, retriedAttempts(attempts))
+			}
+			return nil, fmt.Errorf("failed to execute request%s: %w", attemptsSuffix(attempt), err)
+		}
+
+		// Read response body
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			logging.Error("KQL read response failed", "error", err.Error())
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		rec.Status = resp.StatusCode
+		if resp.StatusCode == http.StatusOK || !isRetryableStatus(resp.StatusCode) {
+			if len(attempts) > 0 {
+				attempts = append(attempts, rec)
+			}
+			break
+		}
+		wait, ok := c.nextRetry(ctx, attempt, resp, &rec)
+		attempts = append(attempts, rec)
+		if !ok {
+			break
+		}
+		if c.waitForRetry(ctx, attempt, resp.StatusCode, "", wait, rec.RetryAfter != "") != nil {
+			break
 		}
-		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
-	defer resp.Body.Close()
+	attemptCount := len(attempts)
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logging.Error("KQL read response failed", "error", err.Error())
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	// A cancel while waiting to retry ends the request with the last (retryable) response
+	if resp.StatusCode != http.StatusOK && canceledByCaller(ctx) {
+		logging.Info("KQL request canceled", "attempts", fmt.Sprintf("%d", attemptCount), "last_status", fmt.Sprintf("%d", resp.StatusCode))
+		if rawEnabled {
+			writeRawHTTPResult(req, bodyJSON, resp, body, maxBytes, resolvedPath, time.Since(start), fmt.Sprintf("canceled while waiting to retry after status %d", resp.StatusCode), startedAt, c.rawKeepN, retriedAttempts(attempts), true)
+		}
+		return nil, fmt.Errorf("request canceled while waiting to retry after status %d: %w", resp.StatusCode, ctx.Err())
 	}
 
 	// Check for HTTP errors
@@ -212,13 +273,20 @@ func (c *Client) ExecuteQuery(ctx context.Context, query string) (*QueryResponse
 			"x-ms-request-id", rid,
 			"x-ms-correlation-request-id", cid,
 			"resp_bytes", fmt.Sprintf("%d", len(body)),
+			"attempts", fmt.Sprintf("%d", max(attemptCount, 1)),
 		)
 		// Write full capture if enabled
 		if rawEnabled {
-			startedAt, _ := ctx.Value(ctxKeyStartedAt{}).(string)
-			writeRawHTTPResult(req, bodyJSON, resp, body, maxBytes, resolvedPath, dur, fmt.Sprintf("API request failed with status %d", resp.StatusCode), startedAt, c.rawKeepN)
+			writeRawHTTPResult(req, bodyJSON, resp, body, maxBytes, resolvedPath, dur, fmt.Sprintf("API request failed with status %d", resp.StatusCode), startedAt, c.rawKeepN, retriedAttempts(attempts), false)
 		}
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		qe := newQueryError(resp, body, attemptCount)
+		logging.Debug("KQL API error details",
+			"code", qe.Code,
+			"inner_code", qe.InnerCode,
+			"line", fmt.Sprintf("%d", qe.Line),
+			"column", fmt.Sprintf("%d", qe.Column),
+		)
+		return nil, qe
 	}
 
 	// Parse response
@@ -227,6 +295,7 @@ func (c *Client) ExecuteQuery(ctx context.Context, query string) (*QueryResponse
 		logging.Error("KQL response parse failed", "error", err.Error(), "resp_bytes", fmt.Sprintf("%d", len(body)))
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	queryResp.FetchLimit = fetchLimit
 
 	dur := time.Since(start)
 	rowCount := 0
@@ -246,98 +315,121 @@ func (c *Client) ExecuteQuery(ctx context.Context, query string) (*QueryResponse
 		"table", util.FirstNonEmpty(tableName, "PrimaryResult"),
 		"x-ms-request-id", rid,
 		"x-ms-correlation-request-id", cid,
+		"attempts", fmt.Sprintf("%d", max(len(attempts), 1)),
 	)
 
 	// Write success capture if enabled
 	if rawEnabled {
-		startedAt, _ := ctx.Value(ctxKeyStartedAt{}).(string)
-		writeRawHTTPResult(req, bodyJSON, resp, body, maxBytes, resolvedPath, dur, "", startedAt, c.rawKeepN)
+		writeRawHTTPResult(req, bodyJSON, resp, body, maxBytes, resolvedPath, dur, "", startedAt, c.rawKeepN, retriedAttempts(attempts), false)
 	}
 
 	return &queryResp, nil
 }
 
-// applyFetchLimitIfNeeded appends "| take <fetch>" to the first statement when:
-// - fetch > 0
-// - the first statement starts with a known top-level table
-// - the first statement does NOT already contain a top-level take/limit operator
-// Returns: possibly-mutated query, whether applied, and a short reason code.
-func applyFetchLimitIfNeeded(query string, fetch int) (string, bool, string) {
+// newQueryRequest builds a fresh POST for one attempt; the body reader is not reusable across retries.
+func newQueryRequest(ctx context.Context, queryURL string, bodyJSON []byte, token *oauth2.Token) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", queryURL, bytes.NewReader(bodyJSON))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return req, nil
+}
+
+// applyFetchLimitIfNeeded appends "| take <fetch>" to every query statement that
+// produces an output table (after any let/set/declare preamble) and has no
+// row-limiting operator of its own: take, limit, top, sample, summarize, count,
+// distinct and friends, as reported by kql.Statement.RowLimit. Operators inside
+// subqueries do not count. A statement whose result a later statement reads
+// through "| as Name" is intermediate and is not limited, nor are let bodies.
+// A trailing "| render" stays last. Queries the parser rejects are left unchanged.
+// Returns: possibly-mutated query, the 1-based positions among the query
+// statements of those capped, and a short reason code.
+func applyFetchLimitIfNeeded(query string, fetch int) (string, []int, string) {
 	if fetch <= 0 {
-		return query, false, "fetch_zero"
-	}
-	// Work on the first statement only (before the first ';')
-	parts := strings.SplitN(query, ";", 2)
-	firstOrig := parts[0]
-	first := strings.TrimSpace(firstOrig)
-	if first == "" {
-		return query, false, "empty_stmt"
-	}
-	// Must start with a known table name on the first non-empty line
-	if !startsWithKnownTable(first) {
-		return query, false, "not_table_first"
-	}
-	// Detect explicit user limit/take in the first statement (case-insensitive)
-	// We consider appearances either right after a pipeline '|' or at statement start
-	// (rare but supported), with word boundary to avoid matching column names.
-	re := regexp.MustCompile(`(?i)(^|\|)\s*(take|limit)\b`)
-	if re.MatchString(first) {
-		return query, false, "user_explicit"
-	}
-	// Append take to first statement and preserve any original whitespace
-	mutatedFirst := firstOrig + fmt.Sprintf(" | take %d", fetch)
-	if len(parts) == 2 {
-		// Preserve original ';' and remainder exactly
-		return mutatedFirst + ";" + parts[1], true, "applied_table_no_user_limit"
-	}
-	return mutatedFirst, true, "applied_table_no_user_limit"
+		return query, nil, "fetch_zero"
+	}
+	script, err := kql.Parse(query)
+	if err != nil {
+		return query, nil, "parse_error"
+	}
+	queries := script.Queries()
+	mutated := query
+	var capped []int
+	// Insert from the end so earlier offsets stay valid
+	for i := len(queries) - 1; i >= 0; i-- {
+		if queries[i].RowLimit() != "" || feedsLaterStatement(script, queries[i]) {
+			continue
+		}
+		at, take := fetchLimitInsertion(queries[i], fetch)
+		mutated = mutated[:at] + take + mutated[at:]
+		capped = append([]int{i + 1}, capped...)
+	}
+	if len(capped) == 0 {
+		return query, nil, "user_explicit"
+	}
+	return mutated, capped, "applied_no_user_limit"
 }
 
-// startsWithKnownTable checks if the provided statement's first token is a known AI table
-func startsWithKnownTable(stmt string) bool {
-	// Consider only the first line to mirror ValidateQuery behavior
-	lines := strings.Split(stmt, "\n")
-	if len(lines) == 0 {
+// feedsLaterStatement reports whether stmt names its result with a final
+// "| as Name" that a later statement of the script refers to.
+func feedsLaterStatement(script *kql.Script, stmt *kql.Statement) bool {
+	stages := stmt.Stages()
+	last := stages[len(stages)-1]
+	if len(stages) < 2 || len(last) < 2 || !last[0].IsWord("as") {
 		return false
 	}
-	firstLine := strings.TrimSpace(lines[0])
-	if firstLine == "" {
-		return false
+	name := last[len(last)-1].Text
+	after := false
+	for i := range script.Statements {
+		if &script.Statements[i] == stmt {
+			after = true
+			continue
+		}
+		if !after {
+			continue
+		}
+		for _, t := range script.Statements[i].Tokens {
+			if t.IsWord(name) {
+				return true
+			}
+		}
 	}
-	toks := strings.Fields(firstLine)
-	if len(toks) == 0 {
-		return false
+	return false
+}
+
+// fetchLimitInsertion returns the offset and text of the take for stmt: after its
+// last token, or before a final top-level "| render".
+func fetchLimitInsertion(stmt *kql.Statement, fetch int) (int, string) {
+	stages := stmt.Stages()
+	if last := stages[len(stages)-1]; len(stages) > 1 && len(last) > 0 && last[0].IsWord("render") {
+		for i := len(stmt.Tokens) - 1; i > 0; i-- {
+			if stmt.Tokens[i].Pos.Offset == last[0].Pos.Offset {
+				return stmt.Tokens[i-1].Pos.Offset, fmt.Sprintf("| take %d ", fetch)
+			}
+		}
 	}
-	table := strings.ToLower(toks[0])
-	knownTables := map[string]struct{}{
-		"traces":              {},
-		"requests":            {},
-		"dependencies":        {},
-		"exceptions":          {},
-		"pageviews":           {},
-		"browsertimings":      {},
-		"customevents":        {},
-		"custommetrics":       {},
-		"performancecounters": {},
-		"availabilityresults": {},
-	}
-	_, ok := knownTables[table]
-	return ok
+	return stmt.Tokens[len(stmt.Tokens)-1].End.Offset, fmt.Sprintf(" | take %d", fetch)
 }
 
-// maybeApplyFetchLimit loads config and applies fetch limit if appropriate. It logs a concise decision.
-func (c *Client) maybeApplyFetchLimit(query string) string {
+// maybeApplyFetchLimit applies the configured fetch limit if appropriate. It logs and returns a concise decision.
+func (c *Client) maybeApplyFetchLimit(query string) (string, FetchLimitDecision) {
+	decision := FetchLimitDecision{Limit: c.fetchLimit}
 	if c.fetchLimit <= 0 {
-		logging.Debug("KQL fetch limit not applied", "reason", "fetch_zero", "limit", fmt.Sprintf("%d", c.fetchLimit))
-		return query
+		decision.Reason = "fetch_zero"
+		logging.Debug("KQL fetch limit not applied", "reason", decision.Reason, "limit", fmt.Sprintf("%d", c.fetchLimit))
+		return query, decision
 	}
-	mutated, applied, reason := applyFetchLimitIfNeeded(query, c.fetchLimit)
-	if applied {
-		logging.Debug("KQL fetch limit applied", "limit", fmt.Sprintf("%d", c.fetchLimit), "reason", reason)
-		return mutated
+	mutated, capped, reason := applyFetchLimitIfNeeded(query, c.fetchLimit)
+	decision.Applied, decision.Reason, decision.Statements = len(capped) > 0, reason, capped
+	if decision.Applied {
+		logging.Debug("KQL fetch limit applied", "limit", fmt.Sprintf("%d", c.fetchLimit), "reason", reason,
+			"statements", fmt.Sprintf("%v", capped))
+		return mutated, decision
 	}
 	logging.Debug("KQL fetch limit not applied", "reason", reason, "limit", fmt.Sprintf("%d", c.fetchLimit))
-	return query
+	return query, decision
 }
 
 // computeDeadlineFields returns (timeout_set, deadline) strings for logging.
@@ -348,6 +440,11 @@ func computeDeadlineFields(ctx context.Context) (string, string) {
 	return "false", ""
 }
 
+// canceledByCaller reports whether ctx was canceled explicitly rather than timing out.
+func canceledByCaller(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
 // getRawCaptureConfigFrom resolves raw capture config from a given config snapshot.
 func getRawCaptureConfigFrom(cfg cfgpkg.Config) (bool, string, int) {
 	if !cfg.DebugAppInsightsRawEnable {
@@ -433,7 +530,7 @@ func writeRawRequestCapture(req *http.Request, bodyJSON []byte, maxBytes int, pa
 }
 
 // writeRawTransportError writes a full capture with request details and error message.
-func writeRawTransportError(req *http.Request, bodyJSON []byte, maxBytes int, path string, err error, dur time.Duration, startedAt string, keepN int) {
+func writeRawTransportError(req *http.Request, bodyJSON []byte, maxBytes int, path string, err error, dur time.Duration, startedAt string, keepN int, attempts []debugdump.AIRawAttempt) {
 	reqHdr := debugdump.RedactHeaders(map[string]string{
 		"content-type":  req.Header.Get("Content-Type"),
 		"authorization": req.Header.Get("Authorization"),
@@ -452,7 +549,8 @@ func writeRawTransportError(req *http.Request, bodyJSON []byte, maxBytes int, pa
 			Truncated: reqTrunc,
 		},
 		Response: nil,
-		Error:    &debugdump.AIRawError{Message: err.Error()},
+		Error:    &debugdump.AIRawError{Message: err.Error(), Canceled: errors.Is(err, context.Canceled)},
+		Attempts: attempts,
 	}
 	if keepN > 0 {
 		_ = debugdump.WriteAIRawFullRotating(path, keepN, cap)
@@ -465,12 +563,15 @@ func writeRawTransportError(req *http.Request, bodyJSON []byte, maxBytes int, pa
 }
 
 // writeRawHTTPResult writes a full capture for HTTP responses, optionally including an error message.
-func writeRawHTTPResult(req *http.Request, bodyJSON []byte, resp *http.Response, respBody []byte, maxBytes int, path string, dur time.Duration, errMsg, startedAt string, keepN int) {
+func writeRawHTTPResult(req *http.Request, bodyJSON []byte, resp *http.Response, respBody []byte, maxBytes int, path string, dur time.Duration, errMsg, startedAt string, keepN int, attempts []debugdump.AIRawAttempt, canceled bool) {
 	rh := map[string]string{
 		"x-ms-request-id":             resp.Header.Get("x-ms-request-id"),
 		"x-ms-correlation-request-id": resp.Header.Get("x-ms-correlation-request-id"),
 		"content-type":                resp.Header.Get("Content-Type"),
 	}
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		rh["retry-after"] = ra
+	}
 	redh := debugdump.RedactHeaders(rh)
 	// Pretty print response body JSON when possible
 	bodyStr, bodyLen, truncated := debugdump.FormatBodyPrettyJSON(respBody, maxBytes)
@@ -497,9 +598,10 @@ func writeRawHTTPResult(req *http.Request, bodyJSON []byte, resp *http.Response,
 			BodyBytes:   bodyLen,
 			Truncated:   truncated,
 		},
+		Attempts: attempts,
 	}
 	if strings.TrimSpace(errMsg) != "" {
-		full.Error = &debugdump.AIRawError{Message: errMsg}
+		full.Error = &debugdump.AIRawError{Message: errMsg, Canceled: canceled}
 	}
 	if keepN > 0 {
 		_ = debugdump.WriteAIRawFullRotating(path, keepN, full)
@@ -511,9 +613,6 @@ func writeRawHTTPResult(req *http.Request, bodyJSON []byte, resp *http.Response,
 	logging.Debug("ai_raw_dump_written", "path", path, "status", fmt.Sprintf("%d", resp.StatusCode), "duration_ms", fmt.Sprintf("%d", dur.Milliseconds()), "resp_bytes", fmt.Sprintf("%d", bodyLen))
 }
 
-// ctxKeyStartedAt is an unexported key type for context values
-type ctxKeyStartedAt struct{}
-
 // getValidToken returns a valid token, acquiring one via authenticator if needed
 func (c *Client) getValidToken(ctx context.Context) (*oauth2.Token, error) {
 	c.mu.Lock()
@@ -545,20 +644,15 @@ func (c *Client) getValidToken(ctx context.Context) (*oauth2.Token, error) {
 	return nil, fmt.Errorf("no valid authentication token available and no authenticator provided")
 }
 
-// ValidateQuery performs basic KQL syntax validation
+// ValidateQuery checks KQL syntax with the kql parser. Syntax errors are returned
+// as *kql.SyntaxError with the line and column of the problem; table and column
+// names are left to the service.
 func (c *Client) ValidateQuery(query string) error {
-	query = strings.TrimSpace(query)
-	if query == "" {
+	if strings.TrimSpace(query) == "" {
 		return fmt.Errorf("query cannot be empty")
 	}
-
-	// Basic syntax checks
-	if !c.containsValidTable(query) {
-		return fmt.Errorf("query must start with a valid table name (traces, requests, dependencies, exceptions, etc.)")
-	}
-
-	// Check for balanced brackets
-	if err := c.checkBalancedBrackets(query); err != nil {
+	script, err := kql.Parse(query)
+	if err != nil {
 		return err
 	}
 
@@ -568,71 +662,7 @@ func (c *Client) ValidateQuery(query string) error {
 	if len(parts) > 0 {
 		firstToken = parts[0]
 	}
-	logging.Debug("KQL validated", "first_token", firstToken, "length", fmt.Sprintf("%d", len(query)))
-
-	return nil
-}
-
-// firstNonEmpty returns v if not blank; otherwise fallback.
-// firstNonEmpty was moved to internal/util. Use util.FirstNonEmpty instead.
-
-// containsValidTable checks if the query starts with a known table
-func (c *Client) containsValidTable(query string) bool {
-	lines := strings.Split(query, "\n")
-	if len(lines) == 0 {
-		return false
-	}
-
-	firstLine := strings.TrimSpace(lines[0])
-	firstWord := strings.Fields(firstLine)
-	if len(firstWord) == 0 {
-		return false
-	}
-
-	knownTables := []string{
-		"traces", "requests", "dependencies", "exceptions",
-		"pageviews", "browsertimings", "customevents", "custommetrics",
-		"performancecounters", "availabilityresults",
-	}
-
-	tableName := strings.ToLower(firstWord[0])
-	for _, known := range knownTables {
-		if tableName == known {
-			return true
-		}
-	}
-
-	return false
-}
-
-// checkBalancedBrackets validates bracket matching
-func (c *Client) checkBalancedBrackets(query string) error {
-	stack := make([]rune, 0)
-	brackets := map[rune]rune{
-		')': '(',
-		']': '[',
-		'}': '{',
-	}
-
-	for _, char := range query {
-		switch char {
-		case '(', '[', '{':
-			stack = append(stack, char)
-		case ')', ']', '}':
-			if len(stack) == 0 {
-				return fmt.Errorf("unmatched closing bracket: %c", char)
-			}
-			expected := brackets[char]
-			if stack[len(stack)-1] != expected {
-				return fmt.Errorf("mismatched brackets: expected %c but found %c", expected, char)
-			}
-			stack = stack[:len(stack)-1]
-		}
-	}
-
-	if len(stack) > 0 {
-		return fmt.Errorf("unmatched opening bracket: %c", stack[len(stack)-1])
-	}
+	logging.Debug("KQL validated", "first_token", firstToken, "length", fmt.Sprintf("%d", len(query)), "statements", fmt.Sprintf("%d", len(script.Statements)))
 
 	return nil
 }
@@ -2,11 +2,15 @@ package appinsights
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	"golang.org/x/oauth2"
+
+	"github.com/FBakkensen/bc-insights-tui/internal/kql"
 )
 
 const testAppID = "test-app-id"
@@ -180,13 +184,13 @@ func TestValidateQuery_ValidTables(t *testing.T) {
 	}
 }
 
-func TestValidateQuery_InvalidTables(t *testing.T) {
+func TestValidateQuery_InvalidStart(t *testing.T) {
 	client := NewClient(nil, "test-app")
 
 	invalidQueries := []string{
-		"invalidtable | limit 10",
-		"notatable | where something = true",
 		"123invalid | project name",
+		"| where something == true",
+		"'traces' | take 1",
 	}
 
 	for _, query := range invalidQueries {
@@ -197,6 +201,35 @@ func TestValidateQuery_InvalidTables(t *testing.T) {
 	}
 }
 
+func TestValidateQuery_AcceptsStatementsAndOtherSources(t *testing.T) {
+	client := NewClient(nil, "test-app")
+
+	validQueries := []string{
+		"customtable_CL | limit 10",
+		"let since = ago(1h);\ntraces | where timestamp > since",
+		"union traces, exceptions | take 5",
+		"app('other-app').traces | take 1",
+		"datatable(a:int) [1, 2] | where a > 1",
+		"// latest errors\ntraces | where severityLevel >= 3",
+		"print now()",
+	}
+
+	for _, query := range validQueries {
+		if err := client.ValidateQuery(query); err != nil {
+			t.Errorf("Expected no error for %q, got %v", query, err)
+		}
+	}
+}
+
+func TestValidateQuery_ReportsPosition(t *testing.T) {
+	client := NewClient(nil, "test-app")
+	err := client.ValidateQuery("traces\n| where message has ')'\n| where (severityLevel > 2")
+	var se *kql.SyntaxError
+	if !errors.As(err, &se) || se.Pos.Line != 3 || se.Pos.Column != 9 {
+		t.Fatalf("expected syntax error at line 3, column 9, got %v", err)
+	}
+}
+
 func TestValidateQuery_BracketMatching(t *testing.T) {
 	client := NewClient(nil, "test-app")
 
@@ -288,31 +321,42 @@ func TestQueryStructures(t *testing.T) {
 
 func TestApplyFetchLimitIfNeeded(t *testing.T) {
 	tests := []struct {
-		name    string
-		query   string
-		fetch   int
-		applied bool
-		want    string
-		reason  string
+		name   string
+		query  string
+		fetch  int
+		capped []int
+		want   string
+		reason string
 	}{
-		{name: "simple traces adds take", query: "traces", fetch: 50, applied: true, want: "traces | take 50", reason: "applied_table_no_user_limit"},
-		{name: "simple requests adds take", query: "requests | where timestamp > ago(1h)", fetch: 25, applied: true, want: "requests | where timestamp > ago(1h) | take 25", reason: "applied_table_no_user_limit"},
-		{name: "existing take not overridden", query: "traces | take 10", fetch: 100, applied: false, want: "traces | take 10", reason: "user_explicit"},
-		{name: "existing limit not overridden", query: "traces | limit 5", fetch: 100, applied: false, want: "traces | limit 5", reason: "user_explicit"},
-		{name: "case insensitive TAKE not overridden", query: "traces | TAKE 7", fetch: 9, applied: false, want: "traces | TAKE 7", reason: "user_explicit"},
-		{name: "semicolon preserves remainder", query: "traces | where something; requests", fetch: 15, applied: true, want: "traces | where something | take 15; requests", reason: "applied_table_no_user_limit"},
-		{name: "multiline table first", query: "traces\n| where timestamp > ago(1h)", fetch: 30, applied: true, want: "traces\n| where timestamp > ago(1h) | take 30", reason: "applied_table_no_user_limit"},
-		{name: "not a table first token", query: "let x = traces; x | take 5", fetch: 20, applied: false, want: "let x = traces; x | take 5", reason: "not_table_first"},
-		{name: "empty first statement", query: "  ; traces", fetch: 20, applied: false, want: "  ; traces", reason: "empty_stmt"},
-		{name: "fetch zero no-op", query: "traces", fetch: 0, applied: false, want: "traces", reason: "fetch_zero"},
-		{name: "whitespace preserved", query: "  traces  \t", fetch: 3, applied: true, want: "  traces  \t | take 3", reason: "applied_table_no_user_limit"},
+		{name: "simple traces adds take", query: "traces", fetch: 50, capped: []int{1}, want: "traces | take 50", reason: "applied_no_user_limit"},
+		{name: "simple requests adds take", query: "requests | where timestamp > ago(1h)", fetch: 25, capped: []int{1}, want: "requests | where timestamp > ago(1h) | take 25", reason: "applied_no_user_limit"},
+		{name: "existing take not overridden", query: "traces | take 10", fetch: 100, want: "traces | take 10", reason: "user_explicit"},
+		{name: "existing limit not overridden", query: "traces | limit 5", fetch: 100, want: "traces | limit 5", reason: "user_explicit"},
+		{name: "case insensitive TAKE not overridden", query: "traces | TAKE 7", fetch: 9, want: "traces | TAKE 7", reason: "user_explicit"},
+		{name: "summarize not limited", query: "traces | summarize count() by severityLevel", fetch: 9, want: "traces | summarize count() by severityLevel", reason: "user_explicit"},
+		{name: "top and sample not limited", query: "traces | top 5 by timestamp; requests | sample 3", fetch: 9, want: "traces | top 5 by timestamp; requests | sample 3", reason: "user_explicit"},
+		{name: "every query statement", query: "traces | where something; requests", fetch: 15, capped: []int{1, 2}, want: "traces | where something | take 15; requests | take 15", reason: "applied_no_user_limit"},
+		{name: "only the output statements of a let script", query: "let since = ago(1h);\ntraces | where timestamp > since | as T;\nrequests | take 5;\nT | join (requests) on operation_Id", fetch: 10, capped: []int{3}, want: "let since = ago(1h);\ntraces | where timestamp > since | as T;\nrequests | take 5;\nT | join (requests) on operation_Id | take 10", reason: "applied_no_user_limit"},
+		{name: "unreferenced as is output", query: "traces | as T; requests", fetch: 10, capped: []int{1, 2}, want: "traces | as T | take 10; requests | take 10", reason: "applied_no_user_limit"},
+		{name: "multiline table first", query: "traces\n| where timestamp > ago(1h)", fetch: 30, capped: []int{1}, want: "traces\n| where timestamp > ago(1h) | take 30", reason: "applied_no_user_limit"},
+		{name: "let preamble limited", query: "let x = traces; x | take 5", fetch: 20, want: "let x = traces; x | take 5", reason: "user_explicit"},
+		{name: "let preamble final query", query: "let since = ago(1h);\nlet t = traces | take 1000;\nt | where timestamp > since", fetch: 20, capped: []int{1}, want: "let since = ago(1h);\nlet t = traces | take 1000;\nt | where timestamp > since | take 20", reason: "applied_no_user_limit"},
+		{name: "take inside subquery ignored", query: "traces | join (requests | take 5) on operation_Id", fetch: 20, capped: []int{1}, want: "traces | join (requests | take 5) on operation_Id | take 20", reason: "applied_no_user_limit"},
+		{name: "take in string ignored", query: "traces | where message has '| take 5'", fetch: 20, capped: []int{1}, want: "traces | where message has '| take 5' | take 20", reason: "applied_no_user_limit"},
+		{name: "union source", query: "union traces, exceptions", fetch: 20, capped: []int{1}, want: "union traces, exceptions | take 20", reason: "applied_no_user_limit"},
+		{name: "render stays last", query: "traces | project timestamp, duration | render timechart", fetch: 20, capped: []int{1}, want: "traces | project timestamp, duration | take 20 | render timechart", reason: "applied_no_user_limit"},
+		{name: "trailing comment kept", query: "traces // all\n", fetch: 20, capped: []int{1}, want: "traces | take 20 // all", reason: "applied_no_user_limit"},
+		{name: "empty first statement", query: "  ; traces", fetch: 20, capped: []int{1}, want: "  ; traces | take 20", reason: "applied_no_user_limit"},
+		{name: "parse error unchanged", query: "traces | where (x", fetch: 20, want: "traces | where (x", reason: "parse_error"},
+		{name: "fetch zero no-op", query: "traces", fetch: 0, want: "traces", reason: "fetch_zero"},
+		{name: "whitespace preserved", query: "  traces  \t", fetch: 3, capped: []int{1}, want: "  traces | take 3  \t", reason: "applied_no_user_limit"},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			got, applied, reason := applyFetchLimitIfNeeded(tc.query, tc.fetch)
-			if applied != tc.applied {
-				t.Fatalf("applied mismatch: got %v want %v (reason=%s)", applied, tc.applied, reason)
+			got, capped, reason := applyFetchLimitIfNeeded(tc.query, tc.fetch)
+			if fmt.Sprint(capped) != fmt.Sprint(tc.capped) {
+				t.Fatalf("capped statements mismatch: got %v want %v (reason=%s)", capped, tc.capped, reason)
 			}
 			if strings.TrimSpace(got) != strings.TrimSpace(tc.want) {
 				t.Fatalf("query mutated wrong.\n got: %q\nwant: %q", got, tc.want)
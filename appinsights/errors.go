@@ -0,0 +1,212 @@
+package appinsights
+
+// Typed API errors: non-200 responses are parsed from the App Insights error
+// envelope ({"error": {"code", "message", "innererror": {...}}}) into QueryError.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/FBakkensen/bc-insights-tui/internal/util"
+)
+
+// QueryErrorKind classifies a QueryError for user-facing hints.
+type QueryErrorKind int
+
+const (
+	QueryErrorOther        QueryErrorKind = iota
+	QueryErrorSyntax                      // 400, the query could not be parsed
+	QueryErrorSemantic                    // 400, unknown table/column/function or type mismatch
+	QueryErrorBadRequest                  // 400 without a more specific classification
+	QueryErrorUnauthorized                // 401
+	QueryErrorForbidden                   // 403, usually missing RBAC
+	QueryErrorNotFound                    // 404, usually a wrong application ID
+	QueryErrorThrottled                   // 429
+	QueryErrorLimits                      // query or result limits exceeded
+	QueryErrorServer                      // 5xx
+)
+
+// maxQueryErrorBody caps the raw body kept on a QueryError.
+const maxQueryErrorBody = 4096
+
+// QueryError is a non-200 response from the query API.
+// Line and Column are 1-based positions in the submitted query text (0 when unknown).
+type QueryError struct {
+	StatusCode    int
+	Code          string // top-level error.code, e.g. "BadArgumentError"
+	Message       string // top-level error.message
+	InnerCode     string // most specific innererror code, e.g. "SEM0100" or "SyntaxError"
+	InnerMessage  string // most specific innererror message
+	Line          int
+	Column        int
+	Token         string
+	CorrelationID string
+	RequestID     string
+	Attempts      int
+	Body          string // raw response body, truncated to maxQueryErrorBody
+}
+
+// Error keeps the historical "API request failed with status N" prefix so callers
+// matching on the status text keep working.
+func (e *QueryError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "API request failed with status %d%s", e.StatusCode, attemptsSuffix(e.Attempts))
+	detail := e.Detail()
+	if detail == "" {
+		detail = strings.TrimSpace(e.Body)
+	}
+	if detail != "" {
+		b.WriteString(": " + detail)
+	}
+	if e.HasPosition() {
+		fmt.Fprintf(&b, " (line %d, column %d)", e.Line, e.Column)
+	}
+	return b.String()
+}
+
+// Detail returns the most useful message from the envelope, or "" when there is none.
+func (e *QueryError) Detail() string {
+	switch {
+	case e.InnerMessage != "" && e.Message != "" && e.InnerMessage != e.Message:
+		return e.Message + ": " + e.InnerMessage
+	case e.InnerMessage != "":
+		return e.InnerMessage
+	default:
+		return e.Message
+	}
+}
+
+// HasPosition reports whether the error points at a location in the query.
+func (e *QueryError) HasPosition() bool { return e.Line > 0 }
+
+// Kind classifies the error by status and error codes.
+func (e *QueryError) Kind() QueryErrorKind {
+	text := strings.ToLower(strings.Join([]string{e.Code, e.InnerCode, e.Message, e.InnerMessage}, " "))
+	switch {
+	case e.StatusCode == http.StatusRequestEntityTooLarge || isLimitsText(text):
+		return QueryErrorLimits
+	case e.StatusCode == http.StatusUnauthorized:
+		return QueryErrorUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return QueryErrorForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return QueryErrorNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return QueryErrorThrottled
+	case e.StatusCode >= 500:
+		return QueryErrorServer
+	case e.StatusCode == http.StatusBadRequest:
+		inner := strings.ToLower(e.InnerCode)
+		switch {
+		case strings.Contains(text, "syntaxerror") || strings.HasPrefix(inner, "syn"):
+			return QueryErrorSyntax
+		case strings.Contains(text, "semanticerror") || strings.HasPrefix(inner, "sem"):
+			return QueryErrorSemantic
+		}
+		return QueryErrorBadRequest
+	}
+	return QueryErrorOther
+}
+
+func isLimitsText(text string) bool {
+	return strings.Contains(text, "limitsexceeded") ||
+		strings.Contains(text, "result_set_too_large") ||
+		strings.Contains(text, "e_low_memory") ||
+		strings.Contains(text, "querylimit") ||
+		(strings.Contains(text, "limit") && strings.Contains(text, "exceed"))
+}
+
+// apiErrorDetail mirrors one level of the API error envelope.
+type apiErrorDetail struct {
+	Code          string           `json:"code"`
+	Message       string           `json:"message"`
+	CorrelationID string           `json:"correlationId"`
+	Line          int              `json:"line"`
+	Pos           int              `json:"pos"`
+	Token         string           `json:"token"`
+	InnerError    *apiErrorDetail  `json:"innererror"`
+	Details       []apiErrorDetail `json:"details"`
+}
+
+// Kusto messages carry positions as "on line [2,15]" or "[line:position]=[2:15]".
+var (
+	reLinePosBracket = regexp.MustCompile(`(?i)line\s*\[(\d+)\s*,\s*(\d+)\]`)
+	reLinePosLabel   = regexp.MustCompile(`(?i)\[line:position\]\s*=\s*\[(\d+)\s*:\s*(\d+)\]`)
+)
+
+// newQueryError builds a QueryError from a non-200 response.
+func newQueryError(resp *http.Response, body []byte, attempts int) *QueryError {
+	qe := ParseQueryError(resp.StatusCode, body)
+	qe.RequestID = resp.Header.Get("x-ms-request-id")
+	qe.Attempts = attempts
+	return qe
+}
+
+// ParseQueryError parses an API error body for the given status. It never fails;
+// bodies that are not an error envelope are kept verbatim in Body.
+func ParseQueryError(status int, body []byte) *QueryError {
+	qe := &QueryError{StatusCode: status, Body: truncateBody(string(body))}
+	qe.parseEnvelope(body)
+	return qe
+}
+
+func (e *QueryError) parseEnvelope(body []byte) {
+	var env struct {
+		Error *apiErrorDetail `json:"error"`
+	}
+	if err := json.Unmarshal(body, &env); err != nil || env.Error == nil {
+		return
+	}
+	top := env.Error
+	e.Code = top.Code
+	e.Message = top.Message
+	e.CorrelationID = top.CorrelationID
+	// Walk innererror chains (and details) to the most specific entry
+	var walk func(d *apiErrorDetail)
+	walk = func(d *apiErrorDetail) {
+		if d == nil {
+			return
+		}
+		if d != top && (d.Code != "" || d.Message != "") {
+			e.InnerCode = util.FirstNonEmpty(d.Code, e.InnerCode)
+			e.InnerMessage = util.FirstNonEmpty(d.Message, e.InnerMessage)
+		}
+		if d.Line > 0 && e.Line == 0 {
+			e.Line, e.Column, e.Token = d.Line, d.Pos, d.Token
+		}
+		for i := range d.Details {
+			walk(&d.Details[i])
+		}
+		walk(d.InnerError)
+	}
+	walk(top)
+	if e.Line == 0 {
+		e.Line, e.Column = positionFromMessage(e.InnerMessage + " " + e.Message)
+	}
+	if e.Line > 0 && e.Column <= 0 {
+		e.Column = 1
+	}
+}
+
+// positionFromMessage extracts a line/column pair embedded in a Kusto error message.
+func positionFromMessage(msg string) (int, int) {
+	for _, re := range []*regexp.Regexp{reLinePosLabel, reLinePosBracket} {
+		if m := re.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			col, _ := strconv.Atoi(m[2])
+			return line, col
+		}
+	}
+	return 0, 0
+}
+
+func truncateBody(s string) string {
+	if len(s) <= maxQueryErrorBody {
+		return s
+	}
+	return s[:maxQueryErrorBody] + "…"
+}
@@ -0,0 +1,75 @@
+package appinsights
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const syntaxErrorBody = `{"error":{"message":"The request had some invalid properties","code":"BadArgumentError","correlationId":"corr-1",
+"innererror":{"code":"SyntaxError","message":"A recognition error occurred in the query.",
+"innererror":{"code":"SYN0002","message":"Query could not be parsed at 'wher' on line [2,3]","line":2,"pos":3,"token":"wher"}}}}`
+
+func TestParseQueryError_SyntaxEnvelope(t *testing.T) {
+	qe := ParseQueryError(400, []byte(syntaxErrorBody))
+	if qe.Code != "BadArgumentError" || qe.InnerCode != "SYN0002" || qe.CorrelationID != "corr-1" {
+		t.Fatalf("unexpected codes: %+v", qe)
+	}
+	if qe.Line != 2 || qe.Column != 3 || qe.Token != "wher" {
+		t.Fatalf("expected line 2 col 3 token wher, got %d:%d %q", qe.Line, qe.Column, qe.Token)
+	}
+	if qe.Kind() != QueryErrorSyntax {
+		t.Fatalf("expected syntax kind, got %v", qe.Kind())
+	}
+	if !strings.HasPrefix(qe.Error(), "API request failed with status 400: ") || !strings.Contains(qe.Error(), "(line 2, column 3)") {
+		t.Fatalf("unexpected message: %q", qe.Error())
+	}
+}
+
+func TestParseQueryError_PositionFromMessageAndKinds(t *testing.T) {
+	sem := `{"error":{"code":"BadArgumentError","message":"bad","innererror":{"code":"SemanticError","message":"'where' operator: Failed to resolve column named 'foo' [line:position]=[3:9]"}}}`
+	qe := ParseQueryError(400, []byte(sem))
+	if qe.Kind() != QueryErrorSemantic || qe.Line != 3 || qe.Column != 9 {
+		t.Fatalf("expected semantic error at 3:9, got kind=%v %d:%d", qe.Kind(), qe.Line, qe.Column)
+	}
+	tests := []struct {
+		status int
+		body   string
+		want   QueryErrorKind
+	}{
+		{403, `{"error":{"code":"InsufficientAccessError","message":"The provided credentials have insufficient access"}}`, QueryErrorForbidden},
+		{404, `{"error":{"code":"PathNotFoundError","message":"The requested path does not exist"}}`, QueryErrorNotFound},
+		{400, `{"error":{"code":"BadArgumentError","message":"x","innererror":{"code":"LimitsExceeded","message":"Query result set has exceeded the internal data size limit"}}}`, QueryErrorLimits},
+		{400, `{"error":{"code":"BadArgumentError","message":"x"}}`, QueryErrorBadRequest},
+		{503, `busy`, QueryErrorServer},
+	}
+	for _, tt := range tests {
+		if got := ParseQueryError(tt.status, []byte(tt.body)).Kind(); got != tt.want {
+			t.Fatalf("status %d body %s: kind %v, want %v", tt.status, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryError_NonEnvelopeBodyKeptVerbatim(t *testing.T) {
+	qe := ParseQueryError(502, []byte("gateway down"))
+	if qe.Error() != "API request failed with status 502: gateway down" {
+		t.Fatalf("unexpected message: %q", qe.Error())
+	}
+}
+
+func TestExecuteQuery_ReturnsTypedQueryError(t *testing.T) {
+	c, _ := newRetryTestClient(t, RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		statusResp(http.StatusBadRequest, http.Header{"X-Ms-Request-Id": {"rid-1"}}, syntaxErrorBody),
+	)
+	_, err := c.ExecuteQuery(context.Background(), "traces\n| wher x", Timespan{})
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Fatalf("expected *QueryError, got %T %v", err, err)
+	}
+	if qe.StatusCode != 400 || qe.RequestID != "rid-1" || qe.Line != 2 {
+		t.Fatalf("unexpected query error: %+v", qe)
+	}
+}
@@ -53,7 +53,7 @@ func TestAIRawCapture_Success(t *testing.T) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
-	if _, err := c.ExecuteQuery(ctx, "traces | limit 1"); err != nil {
+	if _, err := c.ExecuteQuery(ctx, "traces | limit 1", Timespan{}); err != nil {
 		t.Fatalf("ExecuteQuery: %v", err)
 	}
 
@@ -100,7 +100,7 @@ func TestAIRawCapture_TransportError(t *testing.T) {
 	c := NewClient(tok, "appId")
 	installFakeTransport(c, &fakeRoundTripper{resp: nil, err: fmt.Errorf("boom")})
 
-	if _, err := c.ExecuteQuery(context.Background(), "traces | limit 1"); err == nil {
+	if _, err := c.ExecuteQuery(context.Background(), "traces | limit 1", Timespan{}); err == nil {
 		t.Fatalf("expected error")
 	}
 	m := readYAMLMap(t, out)
@@ -124,13 +124,13 @@ func TestAIRawCapture_Truncation(t *testing.T) {
 	resp := &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(long))}
 	installFakeTransport(c, &fakeRoundTripper{resp: resp})
 
-	if _, err := c.ExecuteQuery(context.Background(), "traces | limit 1"); err == nil {
+	if _, err := c.ExecuteQuery(context.Background(), "traces | limit 1", Timespan{}); err == nil {
 		t.Fatalf("expected parse error for invalid JSON body")
 	}
 	// Use non-200 to still write capture with body present
 	resp = &http.Response{StatusCode: 400, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(long))}
 	installFakeTransport(c, &fakeRoundTripper{resp: resp})
-	_, _ = c.ExecuteQuery(context.Background(), "traces | limit 1")
+	_, _ = c.ExecuteQuery(context.Background(), "traces | limit 1", Timespan{})
 
 	m := readYAMLMap(t, out)
 	respm := m["response"].(map[string]any)
@@ -152,7 +152,7 @@ func TestAIRawCapture_Disabled_NoFile(t *testing.T) {
 	body := `{"tables": [{"name": "PrimaryResult", "columns": [], "rows": []}]}`
 	resp := &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}
 	installFakeTransport(c, &fakeRoundTripper{resp: resp})
-	_, _ = c.ExecuteQuery(context.Background(), "traces | limit 1")
+	_, _ = c.ExecuteQuery(context.Background(), "traces | limit 1", Timespan{})
 
 	if _, err := os.Stat(out); !os.IsNotExist(err) {
 		t.Fatalf("expected no capture file when disabled, err=%v", err)
@@ -190,8 +190,8 @@ func TestAIRawCapture_Overwrite(t *testing.T) {
 	}}
 	installFakeTransport(c, srt)
 
-	_, _ = c.ExecuteQuery(context.Background(), "traces | limit 1")
-	_, _ = c.ExecuteQuery(context.Background(), "traces | limit 1")
+	_, _ = c.ExecuteQuery(context.Background(), "traces | limit 1", Timespan{})
+	_, _ = c.ExecuteQuery(context.Background(), "traces | limit 1", Timespan{})
 
 	// File should reflect the last capture (rows length 3 imputed by bytes length)
 	st, err := os.Stat(out)
@@ -0,0 +1,211 @@
+package appinsights
+
+// Retry policy for throttled (429) and transient (5xx, transport) query failures.
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cfgpkg "github.com/FBakkensen/bc-insights-tui/config"
+	"github.com/FBakkensen/bc-insights-tui/debugdump"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// RetryPolicy controls how ExecuteQuery retries failed attempts.
+// MaxAttempts counts the first attempt; 1 disables retries.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// RetryEvent describes a retry that is about to happen, after waiting Wait.
+// Status is the HTTP status that triggered it (0 for transport errors).
+type RetryEvent struct {
+	Attempt     int // the attempt about to start (2 for the first retry)
+	MaxAttempts int
+	Status      int
+	Err         string
+	Wait        time.Duration
+	RetryAfter  bool // Wait came from the server's Retry-After header
+}
+
+// ctxKeyRetryNotifier is an unexported key type for the retry notifier
+type ctxKeyRetryNotifier struct{}
+
+// WithRetryNotifier returns a context whose ExecuteQuery calls report each retry to fn
+// before waiting. fn is called on the querying goroutine and must not block.
+func WithRetryNotifier(ctx context.Context, fn func(RetryEvent)) context.Context {
+	return context.WithValue(ctx, ctxKeyRetryNotifier{}, fn)
+}
+
+func notifyRetry(ctx context.Context, ev RetryEvent) {
+	if fn, ok := ctx.Value(ctxKeyRetryNotifier{}).(func(RetryEvent)); ok && fn != nil {
+		fn(ev)
+	}
+}
+
+// retryPolicyFrom builds the policy from config, falling back to defaults for unset values.
+func retryPolicyFrom(cfg cfgpkg.Config) RetryPolicy {
+	def := cfgpkg.NewConfig()
+	p := RetryPolicy{
+		MaxAttempts: cfg.QueryRetryMaxAttempts,
+		BaseDelay:   time.Duration(cfg.QueryRetryBaseDelayMs) * time.Millisecond,
+		MaxDelay:    time.Duration(cfg.QueryRetryMaxDelayMs) * time.Millisecond,
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.QueryRetryMaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = time.Duration(def.QueryRetryBaseDelayMs) * time.Millisecond
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	return p
+}
+
+// isRetryableStatus reports whether an HTTP status is worth retrying.
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter reads a Retry-After value given as delay-seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// jitter returns a random value in [0, n); replaced in tests for deterministic delays.
+var jitter = func(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+	return rand.Int63n(n)
+}
+
+// backoff returns the jittered exponential delay before retry number retry (1-based):
+// half of the capped exponential delay plus a random share of the other half.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	return half + time.Duration(jitter(int64(d-half)+1))
+}
+
+// fitsDeadline reports whether waiting wait still leaves time before ctx's deadline.
+func fitsDeadline(ctx context.Context, wait time.Duration) bool {
+	dl, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+	return time.Until(dl) > wait
+}
+
+// sleepCtx waits for d or until ctx is done, returning ctx.Err() in the latter case.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// nextRetry decides whether the failed attempt may be retried and how long to wait first.
+// A Retry-After header on resp overrides the backoff. rec is annotated with the decision.
+func (c *Client) nextRetry(ctx context.Context, attempt int, resp *http.Response, rec *debugdump.AIRawAttempt) (time.Duration, bool) {
+	if attempt >= c.retry.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+	wait := c.retry.backoff(attempt)
+	if resp != nil {
+		if ra := resp.Header.Get("Retry-After"); ra != "" {
+			rec.RetryAfter = ra
+			if d, ok := parseRetryAfter(ra, time.Now()); ok {
+				wait = d
+			}
+		}
+	}
+	if !fitsDeadline(ctx, wait) {
+		rec.Note = "retry skipped: wait exceeds context deadline"
+		logging.Warn("KQL retry skipped", "reason", "deadline", "attempt", fmt.Sprintf("%d", attempt), "wait_ms", fmt.Sprintf("%d", wait.Milliseconds()))
+		return wait, false
+	}
+	rec.WaitMs = wait.Milliseconds()
+	return wait, true
+}
+
+// waitForRetry logs and reports the upcoming retry, then sleeps unless ctx ends first.
+func (c *Client) waitForRetry(ctx context.Context, attempt, status int, errMsg string, wait time.Duration, fromHeader bool) error {
+	logging.Warn("KQL request retrying",
+		"attempt", fmt.Sprintf("%d", attempt+1),
+		"max_attempts", fmt.Sprintf("%d", c.retry.MaxAttempts),
+		"status", fmt.Sprintf("%d", status),
+		"error", errMsg,
+		"wait_ms", fmt.Sprintf("%d", wait.Milliseconds()),
+		"retry_after", fmt.Sprintf("%t", fromHeader),
+	)
+	notifyRetry(ctx, RetryEvent{
+		Attempt:     attempt + 1,
+		MaxAttempts: c.retry.MaxAttempts,
+		Status:      status,
+		Err:         errMsg,
+		Wait:        wait,
+		RetryAfter:  fromHeader,
+	})
+	return sleepCtx(ctx, wait)
+}
+
+// retriedAttempts returns the attempt log for the raw capture, or nil when there was no retry.
+func retriedAttempts(attempts []debugdump.AIRawAttempt) []debugdump.AIRawAttempt {
+	if len(attempts) < 2 {
+		return nil
+	}
+	return attempts
+}
+
+// attemptsSuffix annotates error messages once a request was tried more than once.
+func attemptsSuffix(n int) string {
+	if n < 2 {
+		return ""
+	}
+	return fmt.Sprintf(" (after %d attempts)", n)
+}
@@ -0,0 +1,168 @@
+package appinsights
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func statusResp(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func newRetryTestClient(t *testing.T, policy RetryPolicy, resps ...*http.Response) (*Client, *seqRoundTripper) {
+	t.Helper()
+	t.Setenv("TEST_MODE", "1")
+	tok := &oauth2.Token{AccessToken: "t", Expiry: time.Now().Add(time.Hour)}
+	c := NewClient(tok, "appId")
+	c.retry = policy
+	srt := &seqRoundTripper{resps: resps}
+	installFakeTransport(c, srt)
+	return c, srt
+}
+
+const okBody = `{"tables": [{"name": "PrimaryResult", "columns": [], "rows": []}]}`
+
+func TestExecuteQuery_RetriesThrottlingHonoringRetryAfter(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "cap.yaml")
+	t.Setenv("BCINSIGHTS_AI_RAW_ENABLE", "true")
+	t.Setenv("BCINSIGHTS_AI_RAW_FILE", out)
+	c, srt := newRetryTestClient(t, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour},
+		statusResp(http.StatusTooManyRequests, http.Header{"Retry-After": {"0"}}, `{"error":"throttled"}`),
+		statusResp(http.StatusOK, nil, okBody),
+	)
+
+	var events []RetryEvent
+	ctx := WithRetryNotifier(context.Background(), func(ev RetryEvent) { events = append(events, ev) })
+	if _, err := c.ExecuteQuery(ctx, "traces | take 1", Timespan{}); err != nil {
+		t.Fatalf("expected success after retry, got %v", err)
+	}
+	if srt.idx != 2 {
+		t.Fatalf("expected 2 attempts, got %d", srt.idx)
+	}
+	if len(events) != 1 || events[0].Attempt != 2 || events[0].Status != 429 || !events[0].RetryAfter || events[0].Wait != 0 {
+		t.Fatalf("unexpected retry events: %+v", events)
+	}
+	m := readYAMLMap(t, out)
+	attempts, ok := m["attempts"].([]any)
+	if !ok || len(attempts) != 2 {
+		t.Fatalf("expected 2 attempts in raw capture, got %v", m["attempts"])
+	}
+	if first := attempts[0].(map[string]any); first["retryAfter"] != "0" {
+		t.Fatalf("expected retryAfter recorded on first attempt, got %v", first)
+	}
+}
+
+func TestExecuteQuery_GivesUpAfterMaxAttempts(t *testing.T) {
+	c, srt := newRetryTestClient(t, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		statusResp(http.StatusServiceUnavailable, nil, "busy"),
+		statusResp(http.StatusServiceUnavailable, nil, "still busy"),
+		statusResp(http.StatusOK, nil, okBody),
+	)
+	_, err := c.ExecuteQuery(context.Background(), "traces | take 1", Timespan{})
+	if err == nil || !strings.Contains(err.Error(), "status 503 (after 2 attempts)") {
+		t.Fatalf("expected 503 after 2 attempts, got %v", err)
+	}
+	if srt.idx != 2 {
+		t.Fatalf("expected exactly 2 attempts, got %d", srt.idx)
+	}
+}
+
+func TestExecuteQuery_DoesNotRetryClientErrors(t *testing.T) {
+	c, srt := newRetryTestClient(t, RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		statusResp(http.StatusBadRequest, nil, `{"error":"syntax"}`),
+		statusResp(http.StatusOK, nil, okBody),
+	)
+	if _, err := c.ExecuteQuery(context.Background(), "traces | take 1", Timespan{}); err == nil {
+		t.Fatalf("expected 400 error")
+	}
+	if srt.idx != 1 {
+		t.Fatalf("expected no retry for 400, got %d attempts", srt.idx)
+	}
+}
+
+func TestExecuteQuery_SkipsRetryBeyondDeadline(t *testing.T) {
+	c, srt := newRetryTestClient(t, RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		statusResp(http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}}, "throttled"),
+		statusResp(http.StatusOK, nil, okBody),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.ExecuteQuery(ctx, "traces | take 1", Timespan{}); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("expected 429 error, got %v", err)
+	}
+	if srt.idx != 1 || time.Since(start) > time.Second {
+		t.Fatalf("expected immediate failure without waiting past the deadline; attempts=%d", srt.idx)
+	}
+}
+
+func TestRetryPolicy_BackoffJitterAndCap(t *testing.T) {
+	orig := jitter
+	defer func() { jitter = orig }()
+	jitter = func(n int64) int64 { return n - 1 } // upper bound
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	if got := p.backoff(1); got != 100*time.Millisecond {
+		t.Fatalf("backoff(1) = %v", got)
+	}
+	if got := p.backoff(2); got != 200*time.Millisecond {
+		t.Fatalf("backoff(2) = %v", got)
+	}
+	if got := p.backoff(5); got != 300*time.Millisecond {
+		t.Fatalf("backoff(5) should be capped, got %v", got)
+	}
+	jitter = func(int64) int64 { return 0 } // lower bound
+	if got := p.backoff(2); got != 100*time.Millisecond {
+		t.Fatalf("backoff(2) lower bound = %v", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if d, ok := parseRetryAfter("7", now); !ok || d != 7*time.Second {
+		t.Fatalf("seconds: %v %v", d, ok)
+	}
+	if d, ok := parseRetryAfter(now.Add(3*time.Second).Format(http.TimeFormat), now); !ok || d != 3*time.Second {
+		t.Fatalf("http date: %v %v", d, ok)
+	}
+	if _, ok := parseRetryAfter("soon", now); ok {
+		t.Fatalf("expected invalid value to be ignored")
+	}
+}
+
+func TestExecuteQuery_CancelDuringRetryWaitRecordedInCapture(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "cap.yaml")
+	t.Setenv("BCINSIGHTS_AI_RAW_ENABLE", "true")
+	t.Setenv("BCINSIGHTS_AI_RAW_FILE", out)
+	c, srt := newRetryTestClient(t, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour},
+		statusResp(http.StatusServiceUnavailable, nil, "busy"),
+		statusResp(http.StatusOK, nil, okBody),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = WithRetryNotifier(ctx, func(RetryEvent) { cancel() })
+	_, err := c.ExecuteQuery(ctx, "traces | take 1", Timespan{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if srt.idx != 1 {
+		t.Fatalf("expected no further attempt after cancel, got %d", srt.idx)
+	}
+	m := readYAMLMap(t, out)
+	errm, ok := m["error"].(map[string]any)
+	if !ok || errm["canceled"] != true {
+		t.Fatalf("expected canceled error in capture, got %v", m["error"])
+	}
+}
@@ -11,6 +11,9 @@ import (
 func TestMain(m *testing.M) {
 	keyring.MockInit()                                     // in-memory provider
 	_ = os.Setenv("BCINSIGHTS_KEYRING_NAMESPACE", "tests") // extra isolation
+	// keep retried transport failures fast; retry tests set their own policy
+	_ = os.Setenv("BCINSIGHTS_QUERY_RETRY_BASE_DELAY_MS", "1")
+	_ = os.Setenv("BCINSIGHTS_QUERY_RETRY_MAX_DELAY_MS", "5")
 	code := m.Run()
 	os.Exit(code)
 }
@@ -0,0 +1,15 @@
+package appinsights
+
+// Query time window sent as the API's ISO 8601 "timespan" field
+
+import "github.com/FBakkensen/bc-insights-tui/internal/timespan"
+
+// Timespan restricts a query to a time window; see timespan.Timespan.
+type Timespan = timespan.Timespan
+
+// ParseTimespan accepts an ISO 8601 duration (PT1H, P1D, P1DT12H), a shorthand
+// (15m, 1h, 7d, 2w), or an absolute "start/end" pair of RFC 3339 timestamps or
+// dates (2006-01-02). Empty, "none", "off" and "all" yield the zero Timespan.
+func ParseTimespan(s string) (Timespan, error) {
+	return timespan.Parse(s)
+}
@@ -0,0 +1,84 @@
+package appinsights
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestParseTimespan(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "", want: ""},
+		{in: "none", want: ""},
+		{in: "PT1H", want: "PT1H"},
+		{in: "p1d", want: "P1D"},
+		{in: "P1DT12H", want: "P1DT12H"},
+		{in: "PT30.5S", want: "PT30.5S"},
+		{in: "15m", want: "PT15M"},
+		{in: "1h", want: "PT1H"},
+		{in: "7d", want: "P7D"},
+		{in: "2w", want: "P2W"},
+		{in: "2024-05-01T00:00:00Z/2024-05-02T06:00:00Z", want: "2024-05-01T00:00:00Z/2024-05-02T06:00:00Z"},
+		{in: "2024-05-01/2024-05-03", want: "2024-05-01T00:00:00Z/2024-05-03T00:00:00Z"},
+		{in: "2024-05-01T02:00:00+02:00/2024-05-01T03:00:00+02:00", want: "2024-05-01T00:00:00Z/2024-05-01T01:00:00Z"},
+		{in: "P", wantErr: true},
+		{in: "PT", wantErr: true},
+		{in: "P1DT", wantErr: true},
+		{in: "yesterday", wantErr: true},
+		{in: "2024-05-02/2024-05-01", wantErr: true},
+		{in: "2024-05-01/soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimespan(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseTimespan(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if !tt.wantErr && got.String() != tt.want {
+			t.Fatalf("ParseTimespan(%q) = %q; want %q", tt.in, got.String(), tt.want)
+		}
+	}
+}
+
+type capturingRoundTripper struct{ body []byte }
+
+func (c *capturingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.body, _ = io.ReadAll(r.Body)
+	body := `{"tables": [{"name": "PrimaryResult", "columns": [], "rows": []}]}`
+	return &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestExecuteQuery_SendsTimespan(t *testing.T) {
+	t.Setenv("TEST_MODE", "1")
+	tok := &oauth2.Token{AccessToken: "t", Expiry: time.Now().Add(time.Hour)}
+	c := NewClient(tok, "appId")
+	rt := &capturingRoundTripper{}
+	installFakeTransport(c, rt)
+
+	if _, err := c.ExecuteQuery(context.Background(), "traces | take 1", Timespan{Duration: "PT1H"}); err != nil {
+		t.Fatalf("ExecuteQuery: %v", err)
+	}
+	var req map[string]any
+	if err := json.Unmarshal(rt.body, &req); err != nil {
+		t.Fatalf("request body: %v", err)
+	}
+	if req["timespan"] != "PT1H" {
+		t.Fatalf("expected timespan PT1H in request body, got %v", req)
+	}
+
+	if _, err := c.ExecuteQuery(context.Background(), "traces | take 1", Timespan{}); err != nil {
+		t.Fatalf("ExecuteQuery: %v", err)
+	}
+	if strings.Contains(string(rt.body), "timespan") {
+		t.Fatalf("expected no timespan field for zero Timespan, got %s", rt.body)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/FBakkensen/bc-insights-tui/internal/timespan"
 	"github.com/FBakkensen/bc-insights-tui/logging"
 )
 
@@ -37,7 +38,14 @@ const (
 	settingQueryHistoryMaxEntries = "queryHistoryMaxEntries"
 	settingQueryTimeoutSeconds    = "queryTimeoutSeconds"
 	settingQueryHistoryFile       = "queryHistoryFile"
+	settingSavedQueriesFile       = "savedQueriesFile"
+	settingColumnLayoutsFile      = "columnLayoutsFile"
+	settingQueryTimespan          = SettingQueryTimespan
 	settingEditorPanelRatio       = "editorPanelRatio"
+	settingQueryRetryMaxAttempts  = "queryRetryMaxAttempts"
+	settingQueryRetryBaseDelayMs  = "queryRetryBaseDelayMs"
+	settingQueryRetryMaxDelayMs   = "queryRetryMaxDelayMs"
+	settingTailIntervalSeconds    = "tailIntervalSeconds"
 
 	// Common strings
 	notSetValue = "(not set)"
@@ -50,6 +58,18 @@ const (
 	configFileName = "config.json"
 )
 
+// SettingQueryTimespan is the name of the query time range setting, which the
+// TUI also sets through its 'time' command.
+const SettingQueryTimespan = "queryTimespan"
+
+// validateQueryTimespan checks a queryTimespan value with the parser used to send it.
+func validateQueryTimespan(value string) error {
+	if _, err := timespan.Parse(value); err != nil {
+		return fmt.Errorf("invalid queryTimespan: %w", err)
+	}
+	return nil
+}
+
 // OAuth2Config holds OAuth2 authentication settings
 type OAuth2Config struct {
 	TenantID string   `json:"tenant_id" yaml:"tenant_id"`
@@ -69,7 +89,16 @@ type Config struct {
 	QueryHistoryMaxEntries int           `json:"queryHistoryMaxEntries" yaml:"queryHistoryMaxEntries"`
 	QueryTimeoutSeconds    int           `json:"queryTimeoutSeconds" yaml:"queryTimeoutSeconds"`
 	QueryHistoryFile       string        `json:"queryHistoryFile" yaml:"queryHistoryFile"`
+	SavedQueriesFile       string        `json:"savedQueriesFile" yaml:"savedQueriesFile"`
+	ColumnLayoutsFile      string        `json:"columnLayoutsFile" yaml:"columnLayoutsFile"`
+	QueryTimespan          string        `json:"queryTimespan" yaml:"queryTimespan"`
 	EditorPanelRatio       float32       `json:"editorPanelRatio" yaml:"editorPanelRatio"`
+	// Retry policy for throttled (429) and transient (5xx, transport) query failures
+	QueryRetryMaxAttempts int `json:"queryRetryMaxAttempts" yaml:"queryRetryMaxAttempts"`
+	QueryRetryBaseDelayMs int `json:"queryRetryBaseDelayMs" yaml:"queryRetryBaseDelayMs"`
+	QueryRetryMaxDelayMs  int `json:"queryRetryMaxDelayMs" yaml:"queryRetryMaxDelayMs"`
+	// Poll interval of the 'tail' command
+	TailIntervalSeconds int `json:"tailIntervalSeconds" yaml:"tailIntervalSeconds"`
 	// Debugging - App Insights raw capture
 	DebugAppInsightsRawEnable   bool   `json:"debug.appInsightsRawEnable" yaml:"debug.appInsightsRawEnable"`
 	DebugAppInsightsRawFile     string `json:"debug.appInsightsRawFile" yaml:"debug.appInsightsRawFile"`
@@ -110,7 +139,13 @@ func NewConfig() Config {
 		QueryHistoryMaxEntries:      100,
 		QueryTimeoutSeconds:         30,
 		QueryHistoryFile:            ".bc-insights-query-history.json",
+		SavedQueriesFile:            ".bc-insights-saved-queries.yaml",
+		ColumnLayoutsFile:           ".bc-insights-column-layouts.yaml",
 		EditorPanelRatio:            0.4,
+		QueryRetryMaxAttempts:       4,
+		QueryRetryBaseDelayMs:       500,
+		QueryRetryMaxDelayMs:        8000,
+		TailIntervalSeconds:         5,
 		DebugAppInsightsRawEnable:   false,
 		DebugAppInsightsRawFile:     "logs/appinsights-raw.yaml",
 		DebugAppInsightsRawMaxBytes: 1048576,
@@ -217,11 +252,44 @@ func applyKQLEditorEnvVars(cfg *Config) {
 	if _, exists := os.LookupEnv("BCINSIGHTS_QUERY_HISTORY_FILE"); exists {
 		cfg.QueryHistoryFile = os.Getenv("BCINSIGHTS_QUERY_HISTORY_FILE")
 	}
+	if val := os.Getenv("BCINSIGHTS_SAVED_QUERIES_FILE"); val != "" {
+		cfg.SavedQueriesFile = val
+	}
+	if val := os.Getenv("BCINSIGHTS_COLUMN_LAYOUTS_FILE"); val != "" {
+		cfg.ColumnLayoutsFile = val
+	}
+	if val, exists := os.LookupEnv("BCINSIGHTS_QUERY_TIMESPAN"); exists {
+		if err := validateQueryTimespan(val); err != nil {
+			logging.Warn("Ignoring invalid BCINSIGHTS_QUERY_TIMESPAN", "value", val, "error", err.Error())
+		} else {
+			cfg.QueryTimespan = strings.TrimSpace(val)
+		}
+	}
 	if val := os.Getenv("BCINSIGHTS_EDITOR_PANEL_RATIO"); val != "" {
 		if parsed, err := strconv.ParseFloat(val, 32); err == nil && parsed > 0 && parsed < 1 {
 			cfg.EditorPanelRatio = float32(parsed)
 		}
 	}
+	if val := os.Getenv("BCINSIGHTS_QUERY_RETRY_MAX_ATTEMPTS"); val != "" {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(val)); err == nil && parsed > 0 {
+			cfg.QueryRetryMaxAttempts = parsed
+		}
+	}
+	if val := os.Getenv("BCINSIGHTS_QUERY_RETRY_BASE_DELAY_MS"); val != "" {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(val)); err == nil && parsed > 0 {
+			cfg.QueryRetryBaseDelayMs = parsed
+		}
+	}
+	if val := os.Getenv("BCINSIGHTS_QUERY_RETRY_MAX_DELAY_MS"); val != "" {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(val)); err == nil && parsed > 0 {
+			cfg.QueryRetryMaxDelayMs = parsed
+		}
+	}
+	if val := os.Getenv("BCINSIGHTS_TAIL_INTERVAL_SECONDS"); val != "" {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(val)); err == nil && parsed > 0 {
+			cfg.TailIntervalSeconds = parsed
+		}
+	}
 }
 
 // applyAIRawDebugEnvVars applies environment variables for App Insights raw debug capture
@@ -373,9 +441,34 @@ func mergeKQLEditor(base, file *Config) {
 	if file.QueryHistoryFile != "" {
 		base.QueryHistoryFile = file.QueryHistoryFile
 	}
+	if file.SavedQueriesFile != "" {
+		base.SavedQueriesFile = file.SavedQueriesFile
+	}
+	if file.ColumnLayoutsFile != "" {
+		base.ColumnLayoutsFile = file.ColumnLayoutsFile
+	}
+	if file.QueryTimespan != "" {
+		if err := validateQueryTimespan(file.QueryTimespan); err != nil {
+			logging.Warn("Ignoring invalid queryTimespan in config file", "value", file.QueryTimespan, "error", err.Error())
+		} else {
+			base.QueryTimespan = file.QueryTimespan
+		}
+	}
 	if file.EditorPanelRatio > 0 && file.EditorPanelRatio < 1 {
 		base.EditorPanelRatio = file.EditorPanelRatio
 	}
+	if file.QueryRetryMaxAttempts > 0 {
+		base.QueryRetryMaxAttempts = file.QueryRetryMaxAttempts
+	}
+	if file.QueryRetryBaseDelayMs > 0 {
+		base.QueryRetryBaseDelayMs = file.QueryRetryBaseDelayMs
+	}
+	if file.QueryRetryMaxDelayMs > 0 {
+		base.QueryRetryMaxDelayMs = file.QueryRetryMaxDelayMs
+	}
+	if file.TailIntervalSeconds > 0 {
+		base.TailIntervalSeconds = file.TailIntervalSeconds
+	}
 }
 
 func mergeAIRawDebug(base, file *Config) {
@@ -490,7 +583,8 @@ func (c *Config) isOAuth2Setting(name string) bool {
 // isKQLEditorSetting checks if the setting name is a KQL Editor configuration setting
 func (c *Config) isKQLEditorSetting(name string) bool {
 	switch name {
-	case settingQueryHistoryMaxEntries, settingQueryTimeoutSeconds, settingQueryHistoryFile, settingEditorPanelRatio:
+	case settingQueryHistoryMaxEntries, settingQueryTimeoutSeconds, settingQueryHistoryFile, settingSavedQueriesFile, settingColumnLayoutsFile, settingQueryTimespan, settingEditorPanelRatio,
+		settingQueryRetryMaxAttempts, settingQueryRetryBaseDelayMs, settingQueryRetryMaxDelayMs, settingTailIntervalSeconds:
 		return true
 	default:
 		return false
@@ -591,6 +685,24 @@ func (c *Config) validateKQLEditorSetting(name, value string) error {
 			return fmt.Errorf("queryHistoryFile cannot be empty")
 		}
 		c.QueryHistoryFile = trimmed
+	case settingSavedQueriesFile:
+		trimmed := strings.TrimSpace(value)
+		if trimmed == "" {
+			return fmt.Errorf("savedQueriesFile cannot be empty")
+		}
+		c.SavedQueriesFile = trimmed
+	case settingColumnLayoutsFile:
+		trimmed := strings.TrimSpace(value)
+		if trimmed == "" {
+			return fmt.Errorf("columnLayoutsFile cannot be empty")
+		}
+		c.ColumnLayoutsFile = trimmed
+	case settingQueryTimespan:
+		// Empty clears the window
+		if err := validateQueryTimespan(value); err != nil {
+			return err
+		}
+		c.QueryTimespan = strings.TrimSpace(value)
 	case settingEditorPanelRatio:
 		trimmed := strings.TrimSpace(value)
 		if parsed, err := strconv.ParseFloat(trimmed, 32); err != nil || parsed <= 0 || parsed >= 1 {
@@ -598,6 +710,34 @@ func (c *Config) validateKQLEditorSetting(name, value string) error {
 		} else {
 			c.EditorPanelRatio = float32(parsed)
 		}
+	case settingQueryRetryMaxAttempts:
+		trimmed := strings.TrimSpace(value)
+		if parsed, err := strconv.Atoi(trimmed); err != nil || parsed <= 0 {
+			return fmt.Errorf("queryRetryMaxAttempts must be a positive integer (1 disables retries), got: %s", value)
+		} else {
+			c.QueryRetryMaxAttempts = parsed
+		}
+	case settingQueryRetryBaseDelayMs:
+		trimmed := strings.TrimSpace(value)
+		if parsed, err := strconv.Atoi(trimmed); err != nil || parsed <= 0 {
+			return fmt.Errorf("queryRetryBaseDelayMs must be a positive integer, got: %s", value)
+		} else {
+			c.QueryRetryBaseDelayMs = parsed
+		}
+	case settingQueryRetryMaxDelayMs:
+		trimmed := strings.TrimSpace(value)
+		if parsed, err := strconv.Atoi(trimmed); err != nil || parsed <= 0 {
+			return fmt.Errorf("queryRetryMaxDelayMs must be a positive integer, got: %s", value)
+		} else {
+			c.QueryRetryMaxDelayMs = parsed
+		}
+	case settingTailIntervalSeconds:
+		trimmed := strings.TrimSpace(value)
+		if parsed, err := strconv.Atoi(trimmed); err != nil || parsed <= 0 {
+			return fmt.Errorf("tailIntervalSeconds must be a positive integer, got: %s", value)
+		} else {
+			c.TailIntervalSeconds = parsed
+		}
 	default:
 		return fmt.Errorf("unknown kql editor setting: %s", name)
 	}
@@ -693,8 +833,31 @@ func (c *Config) getKQLEditorSettingValue(name string) (string, error) {
 			return notSetValue, nil
 		}
 		return c.QueryHistoryFile, nil
+	case settingSavedQueriesFile:
+		if c.SavedQueriesFile == "" {
+			return notSetValue, nil
+		}
+		return c.SavedQueriesFile, nil
+	case settingColumnLayoutsFile:
+		if c.ColumnLayoutsFile == "" {
+			return notSetValue, nil
+		}
+		return c.ColumnLayoutsFile, nil
+	case settingQueryTimespan:
+		if c.QueryTimespan == "" {
+			return notSetValue, nil
+		}
+		return c.QueryTimespan, nil
 	case settingEditorPanelRatio:
 		return fmt.Sprintf("%.2f", c.EditorPanelRatio), nil
+	case settingQueryRetryMaxAttempts:
+		return strconv.Itoa(c.QueryRetryMaxAttempts), nil
+	case settingQueryRetryBaseDelayMs:
+		return strconv.Itoa(c.QueryRetryBaseDelayMs), nil
+	case settingQueryRetryMaxDelayMs:
+		return strconv.Itoa(c.QueryRetryMaxDelayMs), nil
+	case settingTailIntervalSeconds:
+		return strconv.Itoa(c.TailIntervalSeconds), nil
 	default:
 		return "", fmt.Errorf("unknown kql editor setting: %s", name)
 	}
@@ -751,7 +914,26 @@ func (c *Config) ListAllSettings() map[string]string {
 	} else {
 		settings["queryHistoryFile"] = c.QueryHistoryFile
 	}
+	if c.SavedQueriesFile == "" {
+		settings["savedQueriesFile"] = notSetValue
+	} else {
+		settings["savedQueriesFile"] = c.SavedQueriesFile
+	}
+	if c.ColumnLayoutsFile == "" {
+		settings["columnLayoutsFile"] = notSetValue
+	} else {
+		settings["columnLayoutsFile"] = c.ColumnLayoutsFile
+	}
+	if c.QueryTimespan == "" {
+		settings["queryTimespan"] = notSetValue
+	} else {
+		settings["queryTimespan"] = c.QueryTimespan
+	}
 	settings["editorPanelRatio"] = fmt.Sprintf("%.2f", c.EditorPanelRatio)
+	settings["queryRetryMaxAttempts"] = strconv.Itoa(c.QueryRetryMaxAttempts)
+	settings["queryRetryBaseDelayMs"] = strconv.Itoa(c.QueryRetryBaseDelayMs)
+	settings["queryRetryMaxDelayMs"] = strconv.Itoa(c.QueryRetryMaxDelayMs)
+	settings["tailIntervalSeconds"] = strconv.Itoa(c.TailIntervalSeconds)
 
 	// Debug - AI Raw capture (exposed for visibility; toggle via env/file)
 	settings["debug.appInsightsRawEnable"] = fmt.Sprintf("%t", c.DebugAppInsightsRawEnable)
@@ -785,6 +967,23 @@ func getConfigFilePath() (string, error) {
 	return configPath, nil
 }
 
+// ResolveDataFilePath resolves an auxiliary data file (e.g., query history) to an absolute path.
+// Absolute paths are returned unchanged; relative paths are placed next to the config file.
+func ResolveDataFilePath(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("data file name cannot be empty")
+	}
+	if filepath.IsAbs(name) {
+		return name, nil
+	}
+	configPath, err := getConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), name), nil
+}
+
 // SaveConfig saves the current configuration to a JSON file atomically
 func (c *Config) SaveConfig() error {
 	// Don't save config files during tests to maintain test isolation
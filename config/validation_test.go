@@ -381,7 +381,8 @@ func TestListAllSettings(t *testing.T) {
 	expectedSettings := []string{
 		"fetchSize", "environment", "applicationInsightsKey", "applicationInsightsAppId",
 		"oauth2.tenantId", "oauth2.clientId", "oauth2.scopes",
-		"queryHistoryMaxEntries", "queryTimeoutSeconds", "queryHistoryFile", "editorPanelRatio",
+		"queryHistoryMaxEntries", "queryTimeoutSeconds", "queryHistoryFile", "savedQueriesFile", "columnLayoutsFile", "queryTimespan", "editorPanelRatio",
+		"queryRetryMaxAttempts", "queryRetryBaseDelayMs", "queryRetryMaxDelayMs", "tailIntervalSeconds",
 		"azure.subscriptionId",
 		// Debug settings
 		"debug.appInsightsRawEnable", "debug.appInsightsRawFile", "debug.appInsightsRawMaxBytes",
@@ -417,8 +418,8 @@ func TestListAllSettings(t *testing.T) {
 	}
 
 	// Check that the total count matches expected with debug settings included
-	if len(settings) != 16 {
-		t.Errorf("Expected exactly 16 settings, got %d: %v", len(settings), settings)
+	if len(settings) != 23 {
+		t.Errorf("Expected exactly 23 settings, got %d: %v", len(settings), settings)
 	}
 }
 
@@ -507,3 +508,29 @@ func TestValidation_ConcurrentAccess(t *testing.T) {
 	// If we get here without hanging or panicking, the test passes
 	t.Log("Concurrent access test completed successfully")
 }
+
+func TestQueryTimespan_ValidatedWhenSetAndLoaded(t *testing.T) {
+	setupTestModeForValidation(t)
+	cfg := NewConfig()
+	for _, v := range []string{"PT1H", "7d", "2024-05-01/2024-05-02", "off", ""} {
+		if err := cfg.ValidateAndUpdateSetting(SettingQueryTimespan, v); err != nil {
+			t.Errorf("%q: unexpected error %v", v, err)
+		}
+	}
+	for _, v := range []string{"yesterday", "P", "2024-05-02/2024-05-01"} {
+		if err := cfg.ValidateAndUpdateSetting(SettingQueryTimespan, v); err == nil {
+			t.Errorf("%q: expected an error", v)
+		}
+	}
+
+	loader, fs := createTestLoader(t)
+	fs.WriteFile("/test/config.json", []byte(`{"queryTimespan": "last week"}`), 0o644)
+	if got := loader.LoadWithArgs([]string{}).QueryTimespan; got != "" {
+		t.Errorf("expected the invalid file value ignored, got %q", got)
+	}
+	t.Setenv("BCINSIGHTS_QUERY_TIMESPAN", "bogus")
+	fs.WriteFile("/test/config.json", []byte(`{"queryTimespan": "P1D"}`), 0o644)
+	if got := loader.LoadWithArgs([]string{}).QueryTimespan; got != "P1D" {
+		t.Errorf("expected the invalid env value ignored, got %q", got)
+	}
+}
@@ -42,7 +42,20 @@ type AIRawResponse struct {
 
 // AIRawError represents an error payload
 type AIRawError struct {
-	Message string `yaml:"message"`
+	Message  string `yaml:"message"`
+	Canceled bool   `yaml:"canceled,omitempty"` // the caller canceled the request (e.g. user pressed cancel)
+}
+
+// AIRawAttempt records one HTTP attempt when a request was retried
+type AIRawAttempt struct {
+	Attempt    int    `yaml:"attempt"`
+	StartedAt  string `yaml:"startedAt"`
+	DurationMs int64  `yaml:"durationMs"`
+	Status     int    `yaml:"status,omitempty"`
+	Error      string `yaml:"error,omitempty"`
+	RetryAfter string `yaml:"retryAfter,omitempty"`
+	WaitMs     int64  `yaml:"waitMs,omitempty"`
+	Note       string `yaml:"note,omitempty"`
 }
 
 // AIRawCapture is the root document for request-only capture
@@ -52,6 +65,7 @@ type AIRawCapture struct {
 	Request    AIRawRequest   `yaml:"request"`
 	Response   *AIRawResponse `yaml:"response,omitempty"`
 	Error      *AIRawError    `yaml:"error"`
+	Attempts   []AIRawAttempt `yaml:"attempts,omitempty"`
 }
 
 // AIRawFullCapture is identical shape; kept for semantic clarity if needed later.
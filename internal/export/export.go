@@ -0,0 +1,244 @@
+package export
+
+// Tabular result writers shared by the non-interactive runner and the TUI.
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format identifies an output encoding for tabular results.
+type Format string
+
+const (
+	FormatTable    Format = "table"
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatMarkdown Format = "markdown"
+	FormatXLSX     Format = "xlsx"
+)
+
+// maxTableCellWidth caps cell width in the aligned text table to keep lines readable.
+const maxTableCellWidth = 80
+
+// Table is a format-neutral result set: ordered column names and positional row values.
+type Table struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// ParseFormat resolves a user-supplied format name (case-insensitive, with common aliases).
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "table", "text":
+		return FormatTable, nil
+	case "csv":
+		return FormatCSV, nil
+	case "json":
+		return FormatJSON, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "xlsx", "excel":
+		return FormatXLSX, nil
+	default:
+		return "", fmt.Errorf("unknown format %q; use one of: table, csv, json, ndjson, markdown, xlsx", s)
+	}
+}
+
+// Write encodes t to w using the given format.
+func Write(w io.Writer, f Format, t Table) error {
+	switch f {
+	case FormatTable:
+		return WriteText(w, t)
+	case FormatCSV:
+		return WriteCSV(w, t)
+	case FormatJSON:
+		return WriteJSON(w, t)
+	case FormatNDJSON:
+		return WriteNDJSON(w, t)
+	case FormatMarkdown:
+		return WriteMarkdown(w, t)
+	case FormatXLSX:
+		return WriteXLSX(w, t)
+	default:
+		return fmt.Errorf("unsupported format: %s", f)
+	}
+}
+
+// WriteText writes an aligned plain-text table with a header row.
+func WriteText(w io.Writer, t Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = singleLine(c, maxTableCellWidth)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range t.Rows {
+		cells := make([]string, len(t.Columns))
+		for i := range t.Columns {
+			cells[i] = singleLine(CellString(valueAt(r, i)), maxTableCellWidth)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes RFC 4180 CSV with a header row.
+func WriteCSV(w io.Writer, t Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	for _, r := range t.Rows {
+		rec := make([]string, len(t.Columns))
+		for i := range t.Columns {
+			rec[i] = CellString(valueAt(r, i))
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes a JSON array of objects; keys keep the column order.
+func WriteJSON(w io.Writer, t Table) error {
+	if _, err := io.WriteString(w, "[\n"); err != nil {
+		return err
+	}
+	for i, r := range t.Rows {
+		obj, err := RowObject(t.Columns, r)
+		if err != nil {
+			return err
+		}
+		sep := ",\n"
+		if i == len(t.Rows)-1 {
+			sep = "\n"
+		}
+		if _, err := fmt.Fprintf(w, "  %s%s", obj, sep); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]\n")
+	return err
+}
+
+// WriteNDJSON writes one JSON object per line.
+func WriteNDJSON(w io.Writer, t Table) error {
+	for _, r := range t.Rows {
+		obj, err := RowObject(t.Columns, r)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMarkdown writes a GitHub-flavored Markdown table.
+func WriteMarkdown(w io.Writer, t Table) error {
+	b := &strings.Builder{}
+	b.WriteString("|")
+	for _, c := range t.Columns {
+		b.WriteString(" " + markdownCell(c) + " |")
+	}
+	b.WriteString("\n|")
+	for range t.Columns {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, r := range t.Rows {
+		b.WriteString("|")
+		for i := range t.Columns {
+			b.WriteString(" " + markdownCell(CellString(valueAt(r, i))) + " |")
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// RowObject renders a row as a compact JSON object whose keys follow the column order.
+func RowObject(columns []string, row []interface{}) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, c := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(c)
+		if err != nil {
+			return "", err
+		}
+		v, err := json.Marshal(valueAt(row, i))
+		if err != nil {
+			return "", fmt.Errorf("failed to encode column %s: %w", c, err)
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.String(), nil
+}
+
+// valueAt returns row[i] or nil when the row is short.
+func valueAt(row []interface{}, i int) interface{} {
+	if i < 0 || i >= len(row) {
+		return nil
+	}
+	return row[i]
+}
+
+// CellString converts a decoded JSON value into its display string.
+// Objects and arrays are rendered as compact JSON.
+func CellString(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return vv
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(vv)
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(vv)
+		if err != nil {
+			return fmt.Sprint(vv)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(vv)
+	}
+}
+
+// singleLine collapses newlines/tabs and truncates to max runes with an ellipsis.
+func singleLine(s string, max int) string {
+	s = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ").Replace(s)
+	r := []rune(s)
+	if max > 0 && len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return s
+}
+
+// markdownCell escapes pipes and converts newlines to <br> so cells stay on one row.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	return s
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func sampleTable() Table {
+	return Table{
+		Columns: []string{"timestamp", "message", "customDimensions"},
+		Rows: [][]interface{}{
+			{"2025-01-01T00:00:00Z", "hello, world", map[string]interface{}{"eventId": "RT0005"}},
+			{"2025-01-01T00:00:01Z", "line1\nline2 | pipe", nil},
+		},
+	}
+}
+
+func TestParseFormat_Aliases(t *testing.T) {
+	cases := map[string]Format{"": FormatTable, "TEXT": FormatTable, "csv": FormatCSV, "jsonl": FormatNDJSON, "md": FormatMarkdown, "Excel": FormatXLSX}
+	for in, want := range cases {
+		got, err := ParseFormat(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestWriteCSV_QuotesAndCompactsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, sampleTable()); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "timestamp,message,customDimensions\n") {
+		t.Fatalf("unexpected header: %q", out)
+	}
+	if !strings.Contains(out, `"hello, world"`) || !strings.Contains(out, `"{""eventId"":""RT0005""}"`) {
+		t.Fatalf("expected quoted cells; got %q", out)
+	}
+}
+
+func TestWriteJSON_PreservesColumnOrder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, sampleTable()); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(decoded))
+	}
+	if strings.Index(buf.String(), `"timestamp"`) > strings.Index(buf.String(), `"message"`) {
+		t.Fatalf("expected timestamp key before message key")
+	}
+}
+
+func TestWriteJSON_EmptyIsArray(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, Table{Columns: []string{"a"}}); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var decoded []interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 0 {
+		t.Fatalf("expected empty JSON array; got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestWriteNDJSON_OneObjectPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, sampleTable()); err != nil {
+		t.Fatalf("WriteNDJSON: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	for _, l := range lines {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(l), &obj); err != nil {
+			t.Fatalf("line is not JSON: %q", l)
+		}
+	}
+}
+
+func TestWriteMarkdown_EscapesPipesAndNewlines(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, sampleTable()); err != nil {
+		t.Fatalf("WriteMarkdown: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "| --- | --- | --- |") {
+		t.Fatalf("missing separator row: %q", out)
+	}
+	if !strings.Contains(out, `line1<br>line2 \| pipe`) {
+		t.Fatalf("expected escaped cell; got %q", out)
+	}
+}
+
+func TestWriteText_SingleLineCells(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteText(&buf, sampleTable()); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d: %q", len(lines), buf.String())
+	}
+}
+
+func TestWriteXLSX_TypedCellsInOneSheet(t *testing.T) {
+	tbl := sampleTable()
+	tbl.Columns = append(tbl.Columns, "count", "ok")
+	tbl.Rows[0] = append(tbl.Rows[0], 42.5, true)
+	tbl.Rows[1][1] = "=1+1 & <b>"
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, tbl); err != nil {
+		t.Fatalf("WriteXLSX: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		sheet = string(b)
+	}
+	for _, want := range []string{
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">timestamp</t></is></c>`,
+		`<c r="D2"><v>42.5</v></c>`,
+		`<c r="E2" t="b"><v>1</v></c>`,
+		`<t xml:space="preserve">{"eventId":"RT0005"}</t>`,
+		`<t xml:space="preserve">=1+1 &amp; &lt;b&gt;</t>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("expected %s in sheet:\n%s", want, sheet)
+		}
+	}
+	if got := xlsxColumnName(27); got != "AB" {
+		t.Fatalf("xlsxColumnName(27) = %q", got)
+	}
+}
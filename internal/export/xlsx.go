@@ -0,0 +1,163 @@
+package export
+
+// Minimal XLSX (Office Open XML) writer: one worksheet with a bold, frozen header
+// row. Numbers and booleans keep their cell types; everything else is written
+// as inline strings so values like "=1+1" are never evaluated as formulas.
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// xlsxMaxCellChars is Excel's limit on characters in one cell.
+	xlsxMaxCellChars = 32767
+	// xlsxMaxColumnWidth caps the estimated column width (in characters).
+	xlsxMaxColumnWidth = 60
+)
+
+var xlsxStaticParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Results" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`},
+}
+
+// WriteXLSX writes t as an Excel workbook with a single "Results" sheet.
+func WriteXLSX(w io.Writer, t Table) error {
+	zw := zip.NewWriter(w)
+	for _, p := range xlsxStaticParts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, werr := io.WriteString(f, p.body); werr != nil {
+			return werr
+		}
+	}
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if werr := writeXLSXSheet(f, t); werr != nil {
+		return werr
+	}
+	return zw.Close()
+}
+
+func writeXLSXSheet(w io.Writer, t Table) error {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	if len(t.Columns) > 0 {
+		b.WriteString("<cols>")
+		for i, width := range xlsxColumnWidths(t) {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString("</cols>")
+	}
+	b.WriteString("<sheetData>")
+	b.WriteString(`<row r="1">`)
+	for i, c := range t.Columns {
+		fmt.Fprintf(&b, `<c r="%s1" s="1" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, xlsxColumnName(i), xlsxText(c))
+	}
+	b.WriteString("</row>")
+	for ri, r := range t.Rows {
+		fmt.Fprintf(&b, `<row r="%d">`, ri+2)
+		for i := range t.Columns {
+			ref := xlsxColumnName(i) + strconv.Itoa(ri+2)
+			switch v := valueAt(r, i).(type) {
+			case nil:
+				continue
+			case float64:
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+			case bool:
+				val := "0"
+				if v {
+					val = "1"
+				}
+				fmt.Fprintf(&b, `<c r="%s" t="b"><v>%s</v></c>`, ref, val)
+			default:
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xlsxText(CellString(v)))
+			}
+		}
+		b.WriteString("</row>")
+		// Flush periodically so large results do not build one huge string
+		if b.Len() > 1<<20 {
+			if _, err := io.WriteString(w, b.String()); err != nil {
+				return err
+			}
+			b.Reset()
+		}
+	}
+	b.WriteString("</sheetData></worksheet>")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// xlsxColumnWidths estimates column widths from the header and the first rows.
+func xlsxColumnWidths(t Table) []int {
+	widths := make([]int, len(t.Columns))
+	for i, c := range t.Columns {
+		widths[i] = utf8.RuneCountInString(c) + 2
+	}
+	for ri, r := range t.Rows {
+		if ri >= 200 {
+			break
+		}
+		for i := range t.Columns {
+			widths[i] = max(widths[i], utf8.RuneCountInString(CellString(valueAt(r, i)))+1)
+		}
+	}
+	for i := range widths {
+		widths[i] = min(max(widths[i], 8), xlsxMaxColumnWidth)
+	}
+	return widths
+}
+
+// xlsxColumnName converts a 0-based column index to its letter name (0 -> A, 26 -> AA).
+func xlsxColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxText escapes s for XML, drops characters XML cannot carry and applies the cell length limit.
+func xlsxText(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n >= xlsxMaxCellChars {
+			break
+		}
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '\t' || r == '\n' || r == '\r':
+			b.WriteRune(r)
+		case r < 0x20 || r == 0xFFFE || r == 0xFFFF:
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		n++
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package kql
+
+// Catalog of names offered by editor completion: Application Insights tables and
+// their common columns, tabular operators and scalar/aggregation functions. The
+// lists are not exhaustive; the service remains the authority on what exists.
+
+import "strings"
+
+// Tables are the Application Insights tables, in the casing the service uses.
+var Tables = []string{
+	"traces", "requests", "dependencies", "exceptions", "pageViews", "browserTimings",
+	"customEvents", "customMetrics", "performanceCounters", "availabilityResults",
+}
+
+// SourceKeywords can start a tabular expression instead of a table name.
+var SourceKeywords = []string{"let", "union", "print", "range", "datatable", "search", "find", "set", "declare"}
+
+// Operators are the tabular operators that follow a pipe.
+var Operators = []string{
+	"where", "project", "project-away", "project-keep", "project-rename", "project-reorder",
+	"extend", "summarize", "order", "sort", "take", "limit", "top", "top-nested", "top-hitters",
+	"count", "distinct", "join", "lookup", "union", "mv-expand", "mv-apply", "parse", "parse-where",
+	"parse-kv", "evaluate", "render", "as", "invoke", "getschema", "serialize", "sample",
+	"sample-distinct", "make-series", "search", "facet", "fork", "partition", "scan", "consume",
+}
+
+// Functions are common scalar functions.
+var Functions = []string{
+	"ago", "now", "bin", "floor", "datetime", "timespan", "todatetime", "totimespan", "tostring",
+	"toint", "tolong", "todouble", "toreal", "tobool", "todecimal", "toguid", "todynamic", "parse_json",
+	"strcat", "strcat_delim", "strlen", "substring", "split", "extract", "extract_all", "replace_string",
+	"replace_regex", "trim", "trim_start", "trim_end", "tolower", "toupper", "indexof", "isempty",
+	"isnotempty", "isnull", "isnotnull", "iif", "iff", "case", "coalesce", "format_datetime",
+	"format_timespan", "datetime_diff", "datetime_add", "datetime_part", "startofday", "startofweek",
+	"startofmonth", "endofday", "dayofweek", "hourofday", "bag_keys", "bag_pack", "pack_array",
+	"array_length", "array_concat", "set_has_element", "round", "abs", "log10", "pow", "sqrt",
+	"hash", "rand", "ingestion_time", "row_number", "prev", "next", "materialize", "toscalar",
+}
+
+// Aggregations are the functions allowed in summarize and make-series.
+var Aggregations = []string{
+	"count", "countif", "dcount", "dcountif", "sum", "sumif", "avg", "avgif", "min", "minif", "max",
+	"maxif", "percentile", "percentiles", "stdev", "variance", "make_list", "make_list_if", "make_set",
+	"make_set_if", "make_bag", "arg_max", "arg_min", "take_any", "any", "hll", "tdigest",
+}
+
+// commonColumns are shared by every Application Insights table.
+var commonColumns = []string{
+	"timestamp", "itemType", "customDimensions", "customMeasurements", "operation_Name", "operation_Id",
+	"operation_ParentId", "operation_SyntheticSource", "session_Id", "user_Id", "user_AuthenticatedId",
+	"user_AccountId", "application_Version", "client_Type", "client_Model", "client_OS", "client_IP",
+	"client_City", "client_StateOrProvince", "client_CountryOrRegion", "client_Browser", "cloud_RoleName",
+	"cloud_RoleInstance", "appId", "appName", "iKey", "sdkVersion", "itemId", "itemCount",
+}
+
+// tableColumns are the columns specific to each table, keyed by lowercase table name.
+var tableColumns = map[string][]string{
+	"traces":              {"message", "severityLevel"},
+	"requests":            {"id", "source", "name", "url", "success", "resultCode", "duration", "performanceBucket"},
+	"dependencies":        {"target", "type", "name", "data", "success", "resultCode", "duration", "performanceBucket", "id"},
+	"exceptions":          {"problemId", "handledAt", "type", "message", "assembly", "method", "outerType", "outerMessage", "outerAssembly", "outerMethod", "innermostType", "innermostMessage", "innermostAssembly", "innermostMethod", "severityLevel", "details"},
+	"pageviews":           {"id", "name", "url", "duration", "performanceBucket"},
+	"browsertimings":      {"navigation", "url", "name", "networkDuration", "sendDuration", "receiveDuration", "processingDuration", "totalDuration", "performanceBucket"},
+	"customevents":        {"name"},
+	"custommetrics":       {"name", "value", "valueCount", "valueSum", "valueMin", "valueMax", "valueStdDev"},
+	"performancecounters": {"name", "category", "counter", "instance", "value"},
+	"availabilityresults": {"id", "name", "location", "success", "message", "size", "duration", "performanceBucket"},
+}
+
+// TableColumns returns the known columns of table (case-insensitive): the common
+// columns followed by the table's own. An unknown table gets every known column.
+func TableColumns(table string) []string {
+	out := append([]string{}, commonColumns...)
+	if own, ok := tableColumns[strings.ToLower(table)]; ok {
+		return append(out, own...)
+	}
+	seen := make(map[string]bool)
+	for _, t := range Tables {
+		for _, c := range tableColumns[strings.ToLower(t)] {
+			if !seen[c] {
+				seen[c] = true
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
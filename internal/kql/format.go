@@ -0,0 +1,220 @@
+package kql
+
+// Formatter: re-lays out a query with one pipe operator per line. Only whitespace
+// between tokens changes, so the formatted query means the same thing:
+//   - statements end with ';' and a line break; pipes start a new line, indented
+//     under let bindings and inside multi-line brackets;
+//   - a bracket whose content has its own pipes or ';' (a subquery or let body)
+//     is broken over several lines and its content indented;
+//   - other whitespace runs become one space, and tokens written without space
+//     between them stay that way (ago(1h), customDimensions.eventId);
+//   - comments are kept, on their own line or after code as in the source.
+// Formatting formatted text returns it unchanged.
+
+import "strings"
+
+const formatIndent = "    "
+
+// Format pretty-prints src. Queries that do not parse are returned unchanged
+// with the *SyntaxError.
+func Format(src string) (string, error) {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	script, err := Parse(src)
+	if err != nil {
+		return src, err
+	}
+	f := &formatter{src: src, tokens: script.Tokens, atStart: true}
+	f.multi = f.multiLineBrackets()
+	f.run()
+	return strings.TrimRight(f.out.String(), " \n"), nil
+}
+
+type formatFrame struct {
+	openIndent int // indent of the line holding the opening bracket
+	pipeIndent int // indent of pipes inside the frame
+	multi      bool
+	let        bool // the current statement inside the frame is a let
+}
+
+type formatter struct {
+	src       string
+	tokens    []Token
+	multi     map[int]bool // opening bracket index -> broken over lines
+	out       strings.Builder
+	frames    []formatFrame
+	indent    int  // indent of the current output line
+	atStart   bool // nothing written on the current line yet
+	pending   int  // indent of a line break owed before the next token, or -1
+	stmtLet   bool // the current top-level statement is a let
+	stmtStart bool // the next code token starts a statement
+}
+
+// multiLineBrackets finds brackets whose own level contains a pipe or ';'.
+func (f *formatter) multiLineBrackets() map[int]bool {
+	multi := make(map[int]bool)
+	var stack []int
+	for i, t := range f.tokens {
+		switch {
+		case t.Kind == LeftBracket:
+			stack = append(stack, i)
+		case t.Kind == RightBracket:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case len(stack) > 0 && (t.Is("|") || t.Is(";")):
+			multi[stack[len(stack)-1]] = true
+		}
+	}
+	return multi
+}
+
+// pipeIndent is the indent of a pipe at the current level.
+func (f *formatter) pipeIndent() int {
+	if n := len(f.frames); n > 0 {
+		if fr := f.frames[n-1]; fr.multi {
+			if fr.let {
+				return fr.pipeIndent + 1
+			}
+			return fr.pipeIndent
+		}
+		return f.indent + 1
+	}
+	if f.stmtLet {
+		return 1
+	}
+	return 0
+}
+
+func (f *formatter) newline(indent int) {
+	s := strings.TrimRight(f.out.String(), " ")
+	f.out.Reset()
+	f.out.WriteString(s)
+	if !f.atStart || s != "" {
+		f.out.WriteString("\n")
+	}
+	f.out.WriteString(strings.Repeat(formatIndent, indent))
+	f.indent, f.atStart = indent, true
+}
+
+// write emits a token, preceded by an owed line break or the space it needs.
+func (f *formatter) write(i int, spaced bool) {
+	if f.pending >= 0 {
+		f.newline(f.pending)
+		f.pending = -1
+	} else if !f.atStart && spaced {
+		f.out.WriteString(" ")
+	}
+	f.out.WriteString(strings.TrimRight(f.tokens[i].Text, " \t\r"))
+	f.atStart = false
+}
+
+// spaceBefore decides whether a space separates tokens[i] from the previous token.
+func (f *formatter) spaceBefore(i int) bool {
+	if i == 0 {
+		return false
+	}
+	prev, t := f.tokens[i-1], f.tokens[i]
+	switch {
+	case t.Is(",") || t.Is(";") || t.Is(")") || t.Is("]"):
+		return false
+	case prev.Is("(") || prev.Is("["):
+		return false
+	case prev.Is(",") || prev.Is("|") || t.Is("|"):
+		return true
+	}
+	return prev.End.Offset < t.Pos.Offset
+}
+
+// nolint:gocyclo // One case per layout rule.
+func (f *formatter) run() {
+	f.pending, f.stmtStart = -1, true
+	for i, t := range f.tokens {
+		switch {
+		case t.Kind == Comment:
+			ownLine := i == 0 || strings.Contains(f.src[f.tokens[i-1].End.Offset:t.Pos.Offset], "\n")
+			if ownLine && !f.atStart {
+				f.pending = f.continuationIndent(i)
+			} else if !ownLine {
+				f.pending = -1 // keep a trailing comment on its line
+			}
+			f.write(i, true)
+			f.pending = f.continuationIndent(i)
+		case t.Is("|") && f.levelIsMulti():
+			f.stmtStart = false
+			if !f.atStart || f.pending >= 0 {
+				f.pending = -1
+				f.newline(f.pipeIndent())
+			}
+			f.write(i, false)
+		case t.Is(";") && f.levelIsMulti():
+			f.write(i, false)
+			f.pending, f.stmtStart = 0, true
+			if n := len(f.frames); n > 0 {
+				f.pending = f.frames[n-1].pipeIndent
+			}
+		case t.Kind == LeftBracket:
+			f.stmtStart = false
+			f.write(i, f.spaceBefore(i))
+			fr := formatFrame{openIndent: f.indent, multi: f.multi[i]}
+			if fr.multi {
+				fr.pipeIndent = f.indent + 1
+				f.pending, f.stmtStart = fr.pipeIndent, true
+			}
+			f.frames = append(f.frames, fr)
+		case t.Kind == RightBracket:
+			f.stmtStart = false
+			if n := len(f.frames); n > 0 {
+				fr := f.frames[n-1]
+				f.frames = f.frames[:n-1]
+				if fr.multi {
+					f.pending = -1
+					f.newline(fr.openIndent)
+					f.write(i, false)
+					continue
+				}
+			}
+			f.write(i, f.spaceBefore(i))
+		default:
+			if f.stmtStart {
+				if n := len(f.frames); n > 0 {
+					f.frames[n-1].let = t.IsWord("let")
+				} else {
+					f.stmtLet = t.IsWord("let")
+				}
+				f.stmtStart = false
+			}
+			f.write(i, f.spaceBefore(i))
+		}
+	}
+}
+
+// levelIsMulti reports whether pipes at the current level go on their own lines:
+// at top level and inside multi-line brackets.
+func (f *formatter) levelIsMulti() bool {
+	return len(f.frames) == 0 || f.frames[len(f.frames)-1].multi
+}
+
+// continuationIndent is the indent for the line after a comment at tokens[i]:
+// pipes align with their level, other tokens continue one level deeper.
+func (f *formatter) continuationIndent(i int) int {
+	for j := i + 1; j < len(f.tokens); j++ {
+		t := f.tokens[j]
+		if t.Kind == Comment {
+			continue
+		}
+		switch {
+		case t.Is("|"):
+			return f.pipeIndent()
+		case f.stmtStart && len(f.frames) == 0:
+			return 0
+		case f.stmtStart:
+			return f.frames[len(f.frames)-1].pipeIndent
+		case t.Kind == RightBracket && len(f.frames) > 0 && f.frames[len(f.frames)-1].multi:
+			return f.frames[len(f.frames)-1].openIndent
+		case f.atStart:
+			return f.indent
+		}
+		return f.indent + 1
+	}
+	return f.indent
+}
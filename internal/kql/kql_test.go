@@ -0,0 +1,214 @@
+package kql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func kinds(tokens []Token) string {
+	parts := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		parts = append(parts, t.Kind.String()+":"+t.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestLex_TokenClasses(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"traces | take 10", "identifier:traces operator:| keyword:take number:10"},
+		{"where message has '(' // not a bracket", "keyword:where identifier:message keyword:has string:'(' comment:// not a bracket"},
+		{`print @'C:\temp', "a\"b", h'secret'`, `keyword:print string:@'C:\temp' operator:, string:"a\"b" operator:, string:h'secret'`},
+		{"project-away x | mv-expand y", "keyword:project-away identifier:x operator:| keyword:mv-expand identifier:y"},
+		{"where timestamp > ago(1.5h) and d <= 30min", "keyword:where identifier:timestamp operator:> identifier:ago bracket:( number:1.5h bracket:) keyword:and identifier:d operator:<= number:30min"},
+		{"where timestamp <= datetime(2025-01-01T10:00:01.5Z)", "keyword:where identifier:timestamp operator:<= keyword:datetime bracket:( literal:2025-01-01T10:00:01.5Z bracket:)"},
+		{"customDimensions.eventId =~ 'rt0005'", "identifier:customDimensions operator:. identifier:eventId operator:=~ string:'rt0005'"},
+		{"let s = ```a\n'b```;", "keyword:let identifier:s operator:= string:```a\n'b``` operator:;"},
+	}
+	for _, tt := range tests {
+		tokens, err := Lex(tt.src)
+		if err != nil {
+			t.Fatalf("Lex(%q): %v", tt.src, err)
+		}
+		if got := kinds(tokens); got != tt.want {
+			t.Errorf("Lex(%q)\n got: %s\nwant: %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestLex_Positions(t *testing.T) {
+	tokens, err := Lex("traces\n| where ä == 'x'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := tokens[len(tokens)-1]
+	if last.Pos.Line != 2 || last.Pos.Column != 14 || last.End.Column != 17 {
+		t.Fatalf("unexpected position of %q: %+v-%+v", last.Text, last.Pos, last.End)
+	}
+}
+
+func TestParse_AcceptsKQLForms(t *testing.T) {
+	valid := []string{
+		"traces | take 10",
+		"let since = ago(1h);\nlet errs = traces | where severityLevel >= 3;\nerrs | where timestamp > since",
+		"union traces, exceptions | take 5",
+		"union withsource=T (traces | take 1), (requests | take 1)",
+		"app('my-app').traces | take 1",
+		"datatable(a:int, b:string) [1, 'x', 2, 'y'] | where a > 1",
+		"print now()",
+		"range x from 1 to 10 step 1",
+		"// recent errors\ntraces // all traces\n| where severityLevel >= 3 // errors",
+		"set truncationmaxrecords=100;\ntraces | take 1",
+		"let f = (n:int) { traces | take n };\nf(5)",
+		"traces | where message has ';' and message !has '|'",
+		"traces | where customDimensions['al Object Id'] == '50100';",
+		"declare query_parameters(company:string = 'CRONUS');\ntraces | where customDimensions.companyName == company",
+	}
+	for _, src := range valid {
+		if err := Validate(src); err != nil {
+			t.Errorf("Validate(%q): unexpected %v", src, err)
+		}
+	}
+}
+
+func TestParse_ReportsPositions(t *testing.T) {
+	tests := []struct {
+		src       string
+		line, col int
+		msg       string
+	}{
+		{"traces | where (timestamp > ago(1h)", 1, 16, "'(' is never closed"},
+		{"traces | where timestamp > ago(1h))", 1, 35, "without a matching opening bracket"},
+		{"traces\n| where (customDimensions['key'] == 'value']", 2, 44, "does not close '(' opened at line 2, column 9"},
+		{"traces | where message == 'oops", 1, 27, "not terminated"},
+		{"traces | where x > 1 |", 1, 22, "ends with a pipe"},
+		{"traces | | take 1", 1, 10, "found \"|\""},
+		{"traces | 'x'", 1, 10, "expected a query operator"},
+		{"| where x", 1, 1, "starts with '|'"},
+		{"123invalid | project name", 1, 1, "invalid number"},
+		{"let x 5;\ntraces", 1, 7, "expected '=' after 'let x'"},
+		{"let x = 5;", 1, 11, "expected a query after the let statement"},
+		{"// only a comment", 1, 18, "no statements"},
+		{"traces | where a # b", 1, 18, "unexpected character"},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.src)
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Validate(%q): expected *SyntaxError, got %v", tt.src, err)
+			continue
+		}
+		if se.Pos.Line != tt.line || se.Pos.Column != tt.col || !strings.Contains(se.Msg, tt.msg) {
+			t.Errorf("Validate(%q) = %v; want line %d, column %d containing %q", tt.src, err, tt.line, tt.col, tt.msg)
+		}
+	}
+}
+
+func TestParse_StatementsAndStages(t *testing.T) {
+	script, err := Parse("let t = traces;\nt | where a in ('x', 'y') | summarize count() by b\n| take 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(script.Statements) != 2 || script.Statements[0].Kind != LetStatement || script.Statements[0].Name != "t" {
+		t.Fatalf("unexpected statements: %+v", script.Statements)
+	}
+	q := script.Query()
+	stages := q.Stages()
+	if len(stages) != 4 || !stages[2][0].IsWord("summarize") || !stages[3][0].IsWord("take") {
+		t.Fatalf("unexpected stages: %d", len(stages))
+	}
+}
+
+func TestStatement_RowLimit(t *testing.T) {
+	tests := map[string]string{
+		"traces | take 10": "take",
+		"traces | where x > 1 | summarize count() by y":     "summarize",
+		"traces | TOP 5 by timestamp":                       "top",
+		"traces | join (requests | take 5) on operation_Id": "",
+		"union (traces | limit 1), requests":                "",
+		"traces | where message has '| take 5'":             "",
+		"traces | project take = 1":                         "",
+	}
+	for src, want := range tests {
+		script, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", src, err)
+		}
+		if got := script.Query().RowLimit(); got != want {
+			t.Errorf("RowLimit(%q) = %q, want %q", src, got, want)
+		}
+	}
+}
+
+func TestMatchBrackets(t *testing.T) {
+	tokens, _ := Lex("f(a[1]) ]")
+	pairs, unmatched := MatchBrackets(tokens)
+	if len(pairs) != 2 || len(unmatched) != 1 || tokens[unmatched[0]].Text != "]" {
+		t.Fatalf("pairs=%v unmatched=%v", pairs, unmatched)
+	}
+}
+
+func TestFormat_Layout(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{
+			"traces | where timestamp > ago(1h) and severityLevel >= 2 | summarize count() by bin(timestamp,5m) | order by timestamp desc",
+			"traces\n| where timestamp > ago(1h) and severityLevel >= 2\n| summarize count() by bin(timestamp, 5m)\n| order by timestamp desc",
+		},
+		{
+			"let since = ago(1h);\nlet errs = exceptions | where timestamp > since | project operation_Id;\ntraces | join kind=inner (errs | take 10) on operation_Id | render timechart",
+			"let since = ago(1h);\nlet errs = exceptions\n    | where timestamp > since\n    | project operation_Id;\ntraces\n| join kind=inner (\n    errs\n    | take 10\n) on operation_Id\n| render timechart",
+		},
+		{
+			"let f = (n:int) { let x = traces | take n; x | project message };\nf(3)",
+			"let f = (n:int) {\n    let x = traces\n        | take n;\n    x\n    | project message\n};\nf(3)",
+		},
+		{
+			"// recent errors\ntraces   // all rows\n// only warnings and up\n| where severityLevel >= 2 // trailing\n| take 5",
+			"// recent errors\ntraces // all rows\n// only warnings and up\n| where severityLevel >= 2 // trailing\n| take 5",
+		},
+		{
+			"traces | where customDimensions['al Object Id'] in ('1','2') | extend id = tostring(customDimensions.eventId)",
+			"traces\n| where customDimensions['al Object Id'] in ('1', '2')\n| extend id = tostring(customDimensions.eventId)",
+		},
+	}
+	for _, tt := range tests {
+		got, err := Format(tt.src)
+		if err != nil {
+			t.Fatalf("Format(%q): %v", tt.src, err)
+		}
+		if got != tt.want {
+			t.Errorf("Format(%q) =\n%s\nwant\n%s", tt.src, got, tt.want)
+		}
+	}
+	if _, err := Format("traces | where (a"); err == nil {
+		t.Fatalf("expected a syntax error")
+	}
+}
+
+func TestFormat_IdempotentAndKeepsTokens(t *testing.T) {
+	sources := []string{
+		"traces|where message has '| take 5'|take 1;",
+		"union traces, (exceptions | where x == 1 | project a), requests | count",
+		"let a = 1; // one\n// two\ntraces\n\n\n| take a",
+		"requests | summarize avg(duration) by bin(timestamp, 1h) | render timechart with (title='x')",
+		"traces | join (requests | join (dependencies | take 1) on id | take 2) on id",
+		"datatable(a:int, b:string) [1, 'x', 2, 'y'] | where a > 1",
+	}
+	for _, src := range sources {
+		once, err := Format(src)
+		if err != nil {
+			t.Fatalf("Format(%q): %v", src, err)
+		}
+		if twice, _ := Format(once); twice != once {
+			t.Errorf("not idempotent for %q:\n%s\n---\n%s", src, once, twice)
+		}
+		before, _ := Lex(src)
+		after, _ := Lex(once)
+		if kinds(before) != kinds(after) {
+			t.Errorf("tokens changed for %q:\n%s\n%s", src, kinds(before), kinds(after))
+		}
+	}
+}
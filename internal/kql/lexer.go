@@ -0,0 +1,399 @@
+package kql
+
+// KQL tokenizer: identifiers and keywords, numbers (with timespan suffixes),
+// quoted, verbatim, obfuscated and multi-line strings, raw datetime/timespan/guid
+// literals, // comments and operators. Whitespace is skipped but every token keeps
+// its position so callers can map back to the source.
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind classifies a token.
+type Kind int
+
+const (
+	Illegal      Kind = iota
+	Ident             // table, column, function or operator name that is not a keyword
+	Keyword           // reserved or well-known query word (where, project, let, and, …)
+	Number            // 10, 1.5, 1e3, 0x1F, 30m, 1.5d
+	String            // 'x', "x", @'x', h'x', ```x```
+	Literal           // raw content of datetime(…), timespan(…) and guid(…)
+	Comment           // // to end of line
+	Operator          // | ; , . : = == != =~ !~ < <= > >= => + - * / % ! ? ..
+	LeftBracket       // ( [ {
+	RightBracket      // ) ] }
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Ident:
+		return "identifier"
+	case Keyword:
+		return "keyword"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Literal:
+		return "literal"
+	case Comment:
+		return "comment"
+	case Operator:
+		return "operator"
+	case LeftBracket, RightBracket:
+		return "bracket"
+	default:
+		return "illegal"
+	}
+}
+
+// Pos is a location in the source: Offset is a byte offset, Line and Column are
+// 1-based with Column counted in runes.
+type Pos struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string { return fmt.Sprintf("line %d, column %d", p.Line, p.Column) }
+
+// Token is one lexical element. End is the position just after the token.
+type Token struct {
+	Kind Kind
+	Text string
+	Pos  Pos
+	End  Pos
+}
+
+// Is reports whether t is the operator or bracket text s.
+func (t Token) Is(s string) bool {
+	return (t.Kind == Operator || t.Kind == LeftBracket || t.Kind == RightBracket) && t.Text == s
+}
+
+// IsWord reports whether t is an identifier or keyword spelled w.
+func (t Token) IsWord(w string) bool {
+	return (t.Kind == Ident || t.Kind == Keyword) && t.Text == w
+}
+
+// keywords are highlighted and treated as reserved words by the parser. KQL is
+// case-sensitive, so only the lowercase spelling is a keyword.
+var keywords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		let set declare alias pattern restrict query_parameters materialize toscalar
+		where filter project project-away project-keep project-rename project-reorder extend
+		summarize by order sort asc desc nulls first last take limit top top-nested top-hitters
+		count distinct join kind on lookup union withsource isfuzzy mv-expand mv-apply
+		parse parse-where parse-kv with evaluate render as invoke getschema serialize sample
+		sample-distinct make-series from to step range print datatable search find in
+		facet fork partition scan externaldata consume reduce
+		and or not between has has_cs hasprefix hassuffix has_any has_all contains contains_cs
+		startswith startswith_cs endswith endswith_cs matches regex like
+		true false null bool int long real double decimal string datetime timespan dynamic guid
+		inner outer leftouter rightouter fullouter leftanti rightanti leftsemi rightsemi innerunique anti semi
+	`) {
+		keywords[w] = true
+	}
+}
+
+// IsKeyword reports whether word is a KQL keyword.
+func IsKeyword(word string) bool { return keywords[word] }
+
+// hyphenated operator names are lexed as one word when written without spaces.
+var hyphenated = map[string]bool{
+	"project-away": true, "project-keep": true, "project-rename": true, "project-reorder": true,
+	"mv-expand": true, "mv-apply": true, "make-series": true, "top-nested": true, "top-hitters": true,
+	"sample-distinct": true, "parse-where": true, "parse-kv": true,
+}
+
+// rawLiteralFuncs take unquoted literal text in parentheses: datetime(2025-01-01T10:00:00Z).
+var rawLiteralFuncs = map[string]bool{"datetime": true, "timespan": true, "guid": true}
+
+// timespanUnits are the suffixes allowed directly after a number (1h, 30min, 5tick).
+var timespanUnits = map[string]bool{
+	"d": true, "day": true, "days": true, "h": true, "hr": true, "hrs": true, "hour": true, "hours": true,
+	"m": true, "min": true, "minute": true, "minutes": true, "s": true, "sec": true, "second": true, "seconds": true,
+	"ms": true, "milli": true, "millis": true, "millisec": true, "millisecond": true, "milliseconds": true,
+	"micro": true, "micros": true, "microsec": true, "microsecond": true, "microseconds": true,
+	"nanosecond": true, "nanoseconds": true, "tick": true, "ticks": true,
+}
+
+// operators, longest first so that "==" wins over "=".
+var operators = []string{"..", "==", "!=", "<>", "<=", ">=", "=>", "=~", "!~", "|", ";", ",", ".", ":", "=", "<", ">", "+", "-", "*", "/", "%", "!", "?"}
+
+// LexError is a token that cannot be read, such as an unterminated string.
+type LexError struct {
+	Pos Pos
+	Msg string
+}
+
+func (e *LexError) Error() string { return e.Pos.String() + ": " + e.Msg }
+
+type lexer struct {
+	src    string
+	off    int
+	line   int
+	col    int
+	tokens []Token
+}
+
+// Lex splits src into tokens, comments included. On a lexical error it returns the
+// tokens read so far and a *LexError.
+func Lex(src string) ([]Token, error) {
+	lx := &lexer{src: src, line: 1, col: 1}
+	for {
+		lx.skipSpace()
+		if lx.off >= len(lx.src) {
+			return lx.tokens, nil
+		}
+		if err := lx.next(); err != nil {
+			return lx.tokens, err
+		}
+	}
+}
+
+func (lx *lexer) pos() Pos { return Pos{Offset: lx.off, Line: lx.line, Column: lx.col} }
+
+func (lx *lexer) peek(n int) byte {
+	if lx.off+n < len(lx.src) {
+		return lx.src[lx.off+n]
+	}
+	return 0
+}
+
+// advance moves past n bytes, tracking lines and rune columns.
+func (lx *lexer) advance(n int) {
+	end := min(lx.off+n, len(lx.src))
+	for lx.off < end {
+		r, size := utf8.DecodeRuneInString(lx.src[lx.off:])
+		lx.off += size
+		if r == '\n' {
+			lx.line++
+			lx.col = 1
+		} else {
+			lx.col++
+		}
+	}
+}
+
+func (lx *lexer) skipSpace() {
+	for lx.off < len(lx.src) {
+		r, size := utf8.DecodeRuneInString(lx.src[lx.off:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		lx.advance(size)
+	}
+}
+
+func (lx *lexer) emit(kind Kind, start Pos) {
+	lx.tokens = append(lx.tokens, Token{Kind: kind, Text: lx.src[start.Offset:lx.off], Pos: start, End: lx.pos()})
+}
+
+// nolint:gocyclo // One case per token class keeps the dispatch readable.
+func (lx *lexer) next() error {
+	start := lx.pos()
+	c := lx.src[lx.off]
+	switch {
+	case c == '/' && lx.peek(1) == '/':
+		for lx.off < len(lx.src) && lx.src[lx.off] != '\n' {
+			lx.advance(1)
+		}
+		lx.emit(Comment, start)
+		return nil
+	case c == '`' && strings.HasPrefix(lx.src[lx.off:], "```"):
+		return lx.multiLineString(start)
+	case c == '\'' || c == '"':
+		return lx.quoted(start, false)
+	case c == '@' && (lx.peek(1) == '\'' || lx.peek(1) == '"'):
+		lx.advance(1)
+		return lx.quoted(start, true)
+	case (c == 'h' || c == 'H') && (lx.peek(1) == '\'' || lx.peek(1) == '"'):
+		lx.advance(1)
+		return lx.quoted(start, false)
+	case (c == 'h' || c == 'H') && lx.peek(1) == '@' && (lx.peek(2) == '\'' || lx.peek(2) == '"'):
+		lx.advance(2)
+		return lx.quoted(start, true)
+	case isDigit(c) || (c == '.' && isDigit(lx.peek(1)) && !lx.afterValue()):
+		return lx.number(start)
+	case isIdentStart(c):
+		lx.word(start)
+		return nil
+	case c == '(' || c == '[' || c == '{':
+		lx.advance(1)
+		lx.emit(LeftBracket, start)
+		return nil
+	case c == ')' || c == ']' || c == '}':
+		lx.advance(1)
+		lx.emit(RightBracket, start)
+		return nil
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(lx.src[lx.off:], op) {
+			lx.advance(len(op))
+			lx.emit(Operator, start)
+			return nil
+		}
+	}
+	r, size := utf8.DecodeRuneInString(lx.src[lx.off:])
+	lx.advance(size)
+	lx.emit(Illegal, start)
+	return &LexError{Pos: start, Msg: fmt.Sprintf("unexpected character %q", r)}
+}
+
+// afterValue reports whether the previous token ends a value, so '.' is member access.
+func (lx *lexer) afterValue() bool {
+	if len(lx.tokens) == 0 {
+		return false
+	}
+	switch lx.tokens[len(lx.tokens)-1].Kind {
+	case Ident, Keyword, String, Number, Literal, RightBracket:
+		return true
+	}
+	return false
+}
+
+// quoted reads a '…' or "…" string; verbatim strings have no escapes and a doubled
+// quote stands for one quote. Regular strings cannot span lines.
+func (lx *lexer) quoted(start Pos, verbatim bool) error {
+	quote := lx.src[lx.off]
+	lx.advance(1)
+	for lx.off < len(lx.src) {
+		c := lx.src[lx.off]
+		switch {
+		case c == '\n':
+			return &LexError{Pos: start, Msg: "string literal is not terminated before the end of the line"}
+		case c == '\\' && !verbatim:
+			lx.advance(2)
+		case c == quote && verbatim && lx.peek(1) == quote:
+			lx.advance(2)
+		case c == quote:
+			lx.advance(1)
+			lx.emit(String, start)
+			return nil
+		default:
+			lx.advance(1)
+		}
+	}
+	return &LexError{Pos: start, Msg: "string literal is not terminated"}
+}
+
+func (lx *lexer) multiLineString(start Pos) error {
+	end := strings.Index(lx.src[lx.off+3:], "```")
+	if end < 0 {
+		return &LexError{Pos: start, Msg: "multi-line string (```) is not terminated"}
+	}
+	lx.advance(3 + end + 3)
+	lx.emit(String, start)
+	return nil
+}
+
+// number reads integers, reals, exponents, hex and timespan literals like 1.5h.
+func (lx *lexer) number(start Pos) error {
+	if lx.src[lx.off] == '0' && (lx.peek(1) == 'x' || lx.peek(1) == 'X') {
+		lx.advance(2)
+		for lx.off < len(lx.src) && isHexDigit(lx.src[lx.off]) {
+			lx.advance(1)
+		}
+	} else {
+		lx.digits()
+		if lx.peek(0) == '.' && isDigit(lx.peek(1)) {
+			lx.advance(1)
+			lx.digits()
+		}
+		if e := lx.peek(0); (e == 'e' || e == 'E') && (isDigit(lx.peek(1)) || ((lx.peek(1) == '+' || lx.peek(1) == '-') && isDigit(lx.peek(2)))) {
+			lx.advance(2)
+			lx.digits()
+		}
+	}
+	if lx.off < len(lx.src) && isIdentPart(lx.src[lx.off]) {
+		suffixStart := lx.off
+		for lx.off < len(lx.src) && isIdentPart(lx.src[lx.off]) {
+			lx.advance(1)
+		}
+		if suffix := lx.src[suffixStart:lx.off]; !timespanUnits[suffix] {
+			lx.emit(Illegal, start)
+			return &LexError{Pos: start, Msg: fmt.Sprintf("invalid number %q (names cannot start with a digit)", lx.src[start.Offset:lx.off])}
+		}
+	}
+	lx.emit(Number, start)
+	return nil
+}
+
+func (lx *lexer) digits() {
+	for lx.off < len(lx.src) && isDigit(lx.src[lx.off]) {
+		lx.advance(1)
+	}
+}
+
+// word reads an identifier or keyword, joining hyphenated operator names and
+// reading the raw argument of datetime(…)-style literals.
+func (lx *lexer) word(start Pos) {
+	lx.identChars()
+	if lx.peek(0) == '-' && lx.off+1 < len(lx.src) && isIdentStart(lx.src[lx.off+1]) {
+		save := *lx
+		lx.advance(1)
+		lx.identChars()
+		if !hyphenated[lx.src[start.Offset:lx.off]] {
+			*lx = save
+		}
+	}
+	text := lx.src[start.Offset:lx.off]
+	kind := Ident
+	if keywords[text] {
+		kind = Keyword
+	}
+	lx.emit(kind, start)
+	if rawLiteralFuncs[text] {
+		lx.rawLiteral()
+	}
+}
+
+func (lx *lexer) identChars() {
+	for lx.off < len(lx.src) && isIdentPart(lx.src[lx.off]) {
+		lx.advance(1)
+	}
+}
+
+// rawLiteral emits '(' , the unparsed literal text and ')' after datetime/timespan/guid.
+// Without a closing ')' on the same line nothing is consumed and normal lexing resumes.
+func (lx *lexer) rawLiteral() {
+	save := *lx
+	lx.skipSpace()
+	if lx.peek(0) != '(' {
+		*lx = save
+		return
+	}
+	rest := lx.src[lx.off:]
+	end := strings.IndexAny(rest, ")\n")
+	if end < 0 || rest[end] != ')' || strings.ContainsAny(rest[1:end], "('\"") {
+		*lx = save
+		return
+	}
+	open := lx.pos()
+	lx.advance(1)
+	lx.emit(LeftBracket, open)
+	lx.skipSpace()
+	litStart := lx.pos()
+	content := strings.TrimRight(lx.src[lx.off:open.Offset+end], " \t")
+	if content != "" {
+		lx.advance(len(content))
+		lx.emit(Literal, litStart)
+	}
+	lx.skipSpace()
+	closePos := lx.pos()
+	lx.advance(1)
+	lx.emit(RightBracket, closePos)
+}
+
+func isDigit(c byte) bool    { return c >= '0' && c <= '9' }
+func isHexDigit(c byte) bool { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= utf8.RuneSelf
+}
+func isIdentPart(c byte) bool { return isIdentStart(c) || isDigit(c) }
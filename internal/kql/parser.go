@@ -0,0 +1,344 @@
+package kql
+
+// Statement parser: splits a script into ';'-separated statements (let, set,
+// declare, alias and tabular queries), matches brackets and checks the pipe
+// structure of each query. It does not type-check; unknown tables and columns are
+// left to the service.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StatementKind classifies a statement.
+type StatementKind int
+
+const (
+	QueryStatement   StatementKind = iota // a tabular expression: traces | where …
+	LetStatement                          // let name = …
+	SetStatement                          // set option [= value]
+	DeclareStatement                      // declare query_parameters(…)
+	AliasStatement                        // alias database x = …
+)
+
+func (k StatementKind) String() string {
+	switch k {
+	case LetStatement:
+		return "let"
+	case SetStatement:
+		return "set"
+	case DeclareStatement:
+		return "declare"
+	case AliasStatement:
+		return "alias"
+	default:
+		return "query"
+	}
+}
+
+// Statement is one statement of a script. Tokens excludes comments and the
+// terminating ';'.
+type Statement struct {
+	Kind   StatementKind
+	Name   string // the bound name of a let statement
+	Tokens []Token
+}
+
+// Stages splits a query statement on the pipes that are not inside brackets.
+// The first stage is the source (table, union, print, …).
+func (s Statement) Stages() [][]Token {
+	var stages [][]Token
+	depth, from := 0, 0
+	for i, t := range s.Tokens {
+		switch {
+		case t.Kind == LeftBracket:
+			depth++
+		case t.Kind == RightBracket:
+			depth--
+		case depth == 0 && t.Is("|"):
+			stages = append(stages, s.Tokens[from:i])
+			from = i + 1
+		}
+	}
+	return append(stages, s.Tokens[from:])
+}
+
+// rowLimitingOperators reduce a query's rows to a fixed count or to aggregates.
+var rowLimitingOperators = map[string]bool{
+	"take": true, "limit": true, "top": true, "top-nested": true, "top-hitters": true,
+	"sample": true, "sample-distinct": true, "summarize": true, "count": true, "distinct": true, "make-series": true,
+}
+
+// RowLimit returns the first pipe operator of the statement, outside brackets,
+// that limits or aggregates its rows (take, limit, top, sample, summarize, count,
+// distinct, …), or "". Operators inside subqueries do not count. The match is
+// case-insensitive.
+func (s Statement) RowLimit() string {
+	for i, stage := range s.Stages() {
+		if i == 0 || len(stage) == 0 {
+			continue
+		}
+		if op := strings.ToLower(stage[0].Text); rowLimitingOperators[op] {
+			return op
+		}
+	}
+	return ""
+}
+
+// Script is a parsed query text.
+type Script struct {
+	Tokens     []Token // every token, comments included
+	Statements []Statement
+}
+
+// Queries returns every query statement; each one produces a result table.
+func (s *Script) Queries() []*Statement {
+	var out []*Statement
+	for i := range s.Statements {
+		if s.Statements[i].Kind == QueryStatement {
+			out = append(out, &s.Statements[i])
+		}
+	}
+	return out
+}
+
+// Query returns the last query statement, the one whose results are returned, or nil.
+func (s *Script) Query() *Statement {
+	for i := len(s.Statements) - 1; i >= 0; i-- {
+		if s.Statements[i].Kind == QueryStatement {
+			return &s.Statements[i]
+		}
+	}
+	return nil
+}
+
+// SyntaxError is a problem at a position in the query text.
+type SyntaxError struct {
+	Pos Pos
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %s: %s", e.Pos, e.Msg)
+}
+
+// BracketPair links an opening bracket token to its closing token (indexes into the token slice).
+type BracketPair struct {
+	Open  int
+	Close int
+}
+
+var closerFor = map[string]string{"(": ")", "[": "]", "{": "}"}
+
+// MatchBrackets pairs brackets in tokens. unmatched holds the indexes of brackets
+// without a partner, including closers that do not match the innermost opener.
+func MatchBrackets(tokens []Token) (pairs []BracketPair, unmatched []int) {
+	var stack []int
+	for i, t := range tokens {
+		switch t.Kind {
+		case LeftBracket:
+			stack = append(stack, i)
+		case RightBracket:
+			if len(stack) > 0 && closerFor[tokens[stack[len(stack)-1]].Text] == t.Text {
+				pairs = append(pairs, BracketPair{Open: stack[len(stack)-1], Close: i})
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			unmatched = append(unmatched, i)
+		}
+	}
+	return pairs, append(unmatched, stack...)
+}
+
+// Parse tokenizes and parses src. The returned error is a *SyntaxError; the script
+// is returned as far as it could be read.
+func Parse(src string) (*Script, error) {
+	tokens, lexErr := Lex(src)
+	script := &Script{Tokens: tokens}
+	var le *LexError
+	if errors.As(lexErr, &le) {
+		return script, &SyntaxError{Pos: le.Pos, Msg: le.Msg}
+	}
+	code := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		if t.Kind != Comment {
+			code = append(code, t)
+		}
+	}
+	if err := checkBrackets(code); err != nil {
+		return script, err
+	}
+	for _, stmt := range splitStatements(code) {
+		s, err := parseStatement(stmt)
+		if err != nil {
+			return script, err
+		}
+		script.Statements = append(script.Statements, s)
+	}
+	if len(script.Statements) == 0 {
+		return script, &SyntaxError{Pos: endPos(src), Msg: "the query has no statements (only comments or ';')"}
+	}
+	if last := script.Statements[len(script.Statements)-1]; last.Kind != QueryStatement {
+		return script, &SyntaxError{Pos: endPos(src), Msg: fmt.Sprintf("expected a query after the %s statement", last.Kind)}
+	}
+	return script, nil
+}
+
+// Validate reports the first syntax error in src, or nil.
+func Validate(src string) error {
+	_, err := Parse(src)
+	return err
+}
+
+func checkBrackets(tokens []Token) error {
+	var stack []Token
+	for _, t := range tokens {
+		switch t.Kind {
+		case LeftBracket:
+			stack = append(stack, t)
+		case RightBracket:
+			if len(stack) == 0 {
+				return &SyntaxError{Pos: t.Pos, Msg: fmt.Sprintf("unexpected '%s' without a matching opening bracket", t.Text)}
+			}
+			open := stack[len(stack)-1]
+			if want := closerFor[open.Text]; want != t.Text {
+				return &SyntaxError{Pos: t.Pos, Msg: fmt.Sprintf("'%s' does not close '%s' opened at %s (expected '%s')", t.Text, open.Text, open.Pos, want)}
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) > 0 {
+		open := stack[len(stack)-1]
+		return &SyntaxError{Pos: open.Pos, Msg: fmt.Sprintf("'%s' is never closed", open.Text)}
+	}
+	return nil
+}
+
+// splitStatements splits on ';' outside brackets (let bodies in { } keep theirs),
+// dropping empty statements.
+func splitStatements(tokens []Token) [][]Token {
+	var out [][]Token
+	depth, from := 0, 0
+	for i, t := range tokens {
+		switch {
+		case t.Kind == LeftBracket:
+			depth++
+		case t.Kind == RightBracket:
+			depth--
+		case depth == 0 && t.Is(";"):
+			if i > from {
+				out = append(out, tokens[from:i])
+			}
+			from = i + 1
+		}
+	}
+	if from < len(tokens) {
+		out = append(out, tokens[from:])
+	}
+	return out
+}
+
+func parseStatement(tokens []Token) (Statement, error) {
+	first := tokens[0]
+	s := Statement{Tokens: tokens}
+	switch {
+	case first.IsWord("let"):
+		s.Kind = LetStatement
+		return s, parseLet(&s)
+	case first.IsWord("set"):
+		s.Kind = SetStatement
+		if len(tokens) < 2 || (tokens[1].Kind != Ident && tokens[1].Kind != Keyword) {
+			return s, expected(tokens, 1, "an option name after 'set'")
+		}
+		return s, nil
+	case first.IsWord("declare"):
+		s.Kind = DeclareStatement
+		return s, nil
+	case first.IsWord("alias"):
+		s.Kind = AliasStatement
+		return s, nil
+	}
+	return s, checkQuery(tokens)
+}
+
+func parseLet(s *Statement) error {
+	t := s.Tokens
+	switch {
+	case len(t) < 2:
+		return expected(t, 1, "a name after 'let'")
+	case t[1].Kind == Ident || t[1].Kind == Keyword:
+		s.Name = t[1].Text
+	case t[1].Is("[") && len(t) > 3 && t[2].Kind == String && t[3].Is("]"):
+		s.Name = unquote(t[2].Text)
+		t = append([]Token{t[0], t[2]}, t[4:]...)
+	default:
+		return expected(t, 1, "a name after 'let'")
+	}
+	if len(t) < 3 || !t[2].Is("=") {
+		return expected(t, 2, fmt.Sprintf("'=' after 'let %s'", s.Name))
+	}
+	if len(t) < 4 {
+		return expected(t, 3, fmt.Sprintf("a value after 'let %s ='", s.Name))
+	}
+	return checkPipes(t[3:])
+}
+
+// checkQuery validates the start of a tabular expression and its pipes.
+func checkQuery(tokens []Token) error {
+	first := tokens[0]
+	switch {
+	case first.Is("|"):
+		return &SyntaxError{Pos: first.Pos, Msg: "the query starts with '|'; expected a table name or tabular expression first"}
+	case first.Kind == Number || first.Kind == String || first.Kind == Operator:
+		return &SyntaxError{Pos: first.Pos, Msg: fmt.Sprintf("expected a table name or tabular expression, found %s %q", first.Kind, first.Text)}
+	}
+	return checkPipes(tokens)
+}
+
+// checkPipes requires an operator name after every '|' and something before it.
+func checkPipes(tokens []Token) error {
+	for i, t := range tokens {
+		if !t.Is("|") {
+			continue
+		}
+		if i == 0 || tokens[i-1].Is("|") || tokens[i-1].Is(",") || tokens[i-1].Kind == LeftBracket {
+			return &SyntaxError{Pos: t.Pos, Msg: "expected a tabular expression before '|'"}
+		}
+		if i+1 >= len(tokens) {
+			return &SyntaxError{Pos: t.Pos, Msg: "expected a query operator after '|' (the query ends with a pipe)"}
+		}
+		if next := tokens[i+1]; next.Kind != Ident && next.Kind != Keyword {
+			return &SyntaxError{Pos: next.Pos, Msg: fmt.Sprintf("expected a query operator after '|', found %q", next.Text)}
+		}
+	}
+	return nil
+}
+
+// expected reports that tokens[i] (or the end of the statement) is not what is expected.
+func expected(tokens []Token, i int, what string) error {
+	if i < len(tokens) {
+		return &SyntaxError{Pos: tokens[i].Pos, Msg: fmt.Sprintf("expected %s, found %q", what, tokens[i].Text)}
+	}
+	return &SyntaxError{Pos: tokens[len(tokens)-1].End, Msg: "expected " + what}
+}
+
+// endPos returns the position just after the last non-space character of src.
+func endPos(src string) Pos {
+	trimmed := strings.TrimRight(src, " \t\r\n")
+	line := 1 + strings.Count(trimmed, "\n")
+	lastLine := trimmed[strings.LastIndex(trimmed, "\n")+1:]
+	return Pos{Offset: len(trimmed), Line: line, Column: len([]rune(lastLine)) + 1}
+}
+
+// unquote returns the content of a string token without quotes or prefixes.
+func unquote(s string) string {
+	s = strings.TrimLeft(s, "hH@")
+	if strings.HasPrefix(s, "```") && len(s) >= 6 {
+		return s[3 : len(s)-3]
+	}
+	if len(s) >= 2 {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,216 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TreeNode is one value of a JSON document shown as a tree in the details view.
+// Unlike BuildDetails there is no depth or entry cap: children are decoded on
+// first use, so deep or large payloads cost nothing until they are expanded.
+// String values that hold JSON (BC logs many of them) expand like objects.
+type TreeNode struct {
+	Key   string // object key, "[i]" for array elements, the document name for the root
+	Path  string // e.g. customDimensions.nest.items[0]
+	Depth int    // 0 for the root
+
+	value    interface{}
+	embedded bool        // value is a string holding a JSON object or array
+	decoded  interface{} // the embedded JSON, decoded on first use
+	children []*TreeNode
+	loaded   bool
+}
+
+func newTreeNode(key, path string, depth int, v interface{}) *TreeNode {
+	s, ok := v.(string)
+	return &TreeNode{Key: key, Path: path, Depth: depth, value: v, embedded: ok && looksLikeJSON(s)}
+}
+
+// content returns the node's JSON value, decoding an embedded string.
+func (n *TreeNode) content() interface{} {
+	if !n.embedded {
+		return n.value
+	}
+	if n.decoded == nil {
+		n.decoded, _ = decodeEmbedded(n.value.(string))
+	}
+	return n.decoded
+}
+
+// NewTree decodes a customDimensions value into a tree rooted at name. A value
+// that is not a JSON object or array becomes a scalar root and reports a parse warning.
+func NewTree(name string, raw interface{}) (*TreeNode, bool) {
+	root := newTreeNode(name, name, 0, nil)
+	switch v := raw.(type) {
+	case nil:
+		root.value = nil
+	case map[string]interface{}, []interface{}:
+		root.value = v
+	case string:
+		decoded, ok := decodeEmbedded(v)
+		if !ok {
+			root.value = v
+			return root, true
+		}
+		root.value = decoded
+	default:
+		root.value = v
+		return root, true
+	}
+	return root, false
+}
+
+// Expandable reports whether the node has children: a non-empty object or
+// array, or a string holding one.
+func (n *TreeNode) Expandable() bool {
+	switch v := n.content().(type) {
+	case map[string]interface{}:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return false
+}
+
+// Embedded reports whether the node is a string holding JSON.
+func (n *TreeNode) Embedded() bool {
+	return n.embedded
+}
+
+// Children returns the node's children, decoding them on the first call.
+// Object keys are sorted case-insensitively, as in BuildDetails.
+func (n *TreeNode) Children() []*TreeNode {
+	if n.loaded {
+		return n.children
+	}
+	n.loaded = true
+	switch vv := n.content().(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			li, lj := strings.ToLower(keys[i]), strings.ToLower(keys[j])
+			if li == lj {
+				return keys[i] < keys[j]
+			}
+			return li < lj
+		})
+		n.children = make([]*TreeNode, 0, len(keys))
+		for _, k := range keys {
+			n.children = append(n.children, newTreeNode(k, n.Path+pathKey(k), n.Depth+1, vv[k]))
+		}
+	case []interface{}:
+		n.children = make([]*TreeNode, 0, len(vv))
+		for i, e := range vv {
+			k := fmt.Sprintf("[%d]", i)
+			n.children = append(n.children, newTreeNode(k, n.Path+k, n.Depth+1, e))
+		}
+	}
+	return n.children
+}
+
+// Summary describes the node on its line: the value of a scalar, or the size of
+// an object or array ({3 keys}, [2 items]); strings holding JSON say so.
+func (n *TreeNode) Summary() string {
+	suffix := ""
+	if n.embedded {
+		suffix = " in a string"
+	}
+	switch vv := n.content().(type) {
+	case map[string]interface{}:
+		return plural(len(vv), "{", "key", "}") + suffix
+	case []interface{}:
+		return plural(len(vv), "[", "item", "]") + suffix
+	}
+	return scalarText(n.value)
+}
+
+// Preview returns the node's value as compact JSON (strings as they are), cut
+// to limit runes with an ellipsis.
+func (n *TreeNode) Preview(limit int) string {
+	s := scalarText(n.value)
+	switch n.value.(type) {
+	case map[string]interface{}, []interface{}:
+		s = jsonCompact(n.value)
+	}
+	if r := []rune(s); limit > 0 && len(r) > limit {
+		return string(r[:limit-1]) + "…"
+	}
+	return s
+}
+
+// Text returns the node's full value: the scalar, or indented JSON.
+func (n *TreeNode) Text() string {
+	switch n.value.(type) {
+	case map[string]interface{}, []interface{}:
+		b, err := json.MarshalIndent(n.value, "", "  ")
+		if err == nil {
+			return string(b)
+		}
+	}
+	return scalarText(n.value)
+}
+
+var plainPathKey = regexp.MustCompile(`^[A-Za-z_][\w\-]*$`)
+
+// pathKey renders an object key as a path step: .key, or ["key"] when it needs quoting.
+func pathKey(k string) string {
+	if plainPathKey.MatchString(k) {
+		return "." + k
+	}
+	return "[" + strconv.Quote(k) + "]"
+}
+
+func plural(n int, open, noun, closing string) string {
+	if n == 1 {
+		return fmt.Sprintf("%s1 %s%s", open, noun, closing)
+	}
+	return fmt.Sprintf("%s%d %ss%s", open, n, noun, closing)
+}
+
+func scalarText(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return vv
+	case json.Number:
+		return vv.String()
+	case bool:
+		return strconv.FormatBool(vv)
+	case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(vv)
+	}
+	return jsonCompact(v)
+}
+
+// looksLikeJSON reports whether s is a JSON object or array.
+func looksLikeJSON(s string) bool {
+	t := strings.TrimSpace(s)
+	if t == "" || (t[0] != '{' && t[0] != '[') {
+		return false
+	}
+	return json.Valid([]byte(t))
+}
+
+// decodeEmbedded decodes a JSON object or array held in a string, keeping
+// numbers exact.
+func decodeEmbedded(s string) (interface{}, bool) {
+	if !looksLikeJSON(s) {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
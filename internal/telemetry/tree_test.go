@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewTree_LazyChildrenAndEmbeddedJSON(t *testing.T) {
+	deep := `{"a":{"b":{"c":{"d":{"e":[1,{"f":"deep"}]}}}}}`
+	raw := `{"eventId":"RT0005","payload":"{\"lines\":[{\"no\":10000,\"qty\":1.5}]}","deep":` + deep + `,"my key":true}`
+	root, warn := NewTree("customDimensions", raw)
+	if warn || !root.Expandable() {
+		t.Fatalf("expected an expandable root, warn=%v", warn)
+	}
+	if root.loaded {
+		t.Fatal("children should not be decoded before use")
+	}
+	kids := root.Children()
+	var keys []string
+	for _, k := range kids {
+		keys = append(keys, k.Key)
+	}
+	if got := strings.Join(keys, ","); got != "deep,eventId,my key,payload" {
+		t.Fatalf("keys = %s", got)
+	}
+	if kids[2].Path != `customDimensions["my key"]` || kids[2].Summary() != "true" {
+		t.Fatalf("quoted key: path %q summary %q", kids[2].Path, kids[2].Summary())
+	}
+
+	payload := kids[3]
+	if !payload.Embedded() || payload.Summary() != "{1 key} in a string" {
+		t.Fatalf("expected payload to be embedded JSON, got %q", payload.Summary())
+	}
+	qty := payload.Children()[0].Children()[0].Children()[1]
+	if qty.Path != "customDimensions.payload.lines[0].qty" || qty.Summary() != "1.5" {
+		t.Fatalf("got %s = %s", qty.Path, qty.Summary())
+	}
+
+	// No depth cap: walk to the innermost value
+	n := kids[0]
+	for _, k := range []int{0, 0, 0, 0, 0, 1, 0} {
+		n = n.Children()[k]
+	}
+	if n.Path != "customDimensions.deep.a.b.c.d.e[1].f" || n.Summary() != "deep" {
+		t.Fatalf("got %s = %s", n.Path, n.Summary())
+	}
+	if got := kids[0].Preview(12); got != `{"a":{"b":{…` {
+		t.Fatalf("preview = %q", got)
+	}
+}
+
+func TestNewTree_NoCapAndParseWarning(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("{")
+	for i := range maxFlattenEntries + 50 {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `"k%03d":%d`, i, i)
+	}
+	b.WriteString("}")
+	root, _ := NewTree("customDimensions", b.String())
+	if got := len(root.Children()); got != maxFlattenEntries+50 {
+		t.Fatalf("expected every key, got %d", got)
+	}
+
+	root, warn := NewTree("customDimensions", "not json")
+	if !warn || root.Expandable() || root.Summary() != "not json" {
+		t.Fatalf("expected a scalar root with a warning, warn=%v", warn)
+	}
+}
@@ -0,0 +1,104 @@
+// Package timespan parses the query time window sent as the API's ISO 8601
+// "timespan" field. It is shared by config (to validate queryTimespan) and
+// appinsights (to send it).
+package timespan
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Timespan restricts a query to a time window. Either Duration is set (a window
+// ending now, e.g. PT1H or P1D) or Start and End form an absolute window.
+// The zero value applies no window, leaving filtering to the query itself.
+type Timespan struct {
+	Duration string
+	Start    time.Time
+	End      time.Time
+}
+
+var (
+	isoDurationRe = regexp.MustCompile(`^P(?:\d+W)?(?:\d+D)?(?:T(?:\d+H)?(?:\d+M)?(?:\d+(?:\.\d+)?S)?)?$`)
+	shortDurRe    = regexp.MustCompile(`^(\d+)([smhdw])$`)
+)
+
+// Parse accepts an ISO 8601 duration (PT1H, P1D, P1DT12H), a shorthand
+// (15m, 1h, 7d, 2w), or an absolute "start/end" pair of RFC 3339 timestamps or
+// dates (2006-01-02). Empty, "none", "off" and "all" yield the zero Timespan.
+func Parse(s string) (Timespan, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "", "none", "off", "all":
+		return Timespan{}, nil
+	}
+	if startStr, endStr, ok := strings.Cut(s, "/"); ok {
+		start, err := parseTimespanInstant(startStr)
+		if err != nil {
+			return Timespan{}, fmt.Errorf("invalid timespan start: %w", err)
+		}
+		end, err := parseTimespanInstant(endStr)
+		if err != nil {
+			return Timespan{}, fmt.Errorf("invalid timespan end: %w", err)
+		}
+		if !end.After(start) {
+			return Timespan{}, fmt.Errorf("invalid timespan: end %s must be after start %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+		}
+		return Timespan{Start: start, End: end}, nil
+	}
+	if m := shortDurRe.FindStringSubmatch(strings.ToLower(s)); m != nil {
+		switch m[2] {
+		case "s", "m", "h":
+			return Timespan{Duration: "PT" + m[1] + strings.ToUpper(m[2])}, nil
+		default:
+			return Timespan{Duration: "P" + m[1] + strings.ToUpper(m[2])}, nil
+		}
+	}
+	upper := strings.ToUpper(s)
+	if upper == "P" || strings.HasSuffix(upper, "T") || !isoDurationRe.MatchString(upper) {
+		return Timespan{}, fmt.Errorf("invalid timespan %q: use an ISO 8601 duration (PT1H, P1D), a shorthand (30m, 1h, 7d) or start/end timestamps", s)
+	}
+	return Timespan{Duration: upper}, nil
+}
+
+// parseTimespanInstant parses an RFC 3339 timestamp or a plain date (UTC midnight).
+func parseTimespanInstant(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an RFC 3339 timestamp or YYYY-MM-DD date", s)
+	}
+	return t.UTC(), nil
+}
+
+// IsZero reports whether no window is applied.
+func (t Timespan) IsZero() bool {
+	return t.Duration == "" && t.Start.IsZero() && t.End.IsZero()
+}
+
+// String returns the API "timespan" value (empty when no window is applied).
+func (t Timespan) String() string {
+	if t.Duration != "" {
+		return t.Duration
+	}
+	if t.Start.IsZero() || t.End.IsZero() {
+		return ""
+	}
+	return t.Start.UTC().Format(time.RFC3339) + "/" + t.End.UTC().Format(time.RFC3339)
+}
+
+// Describe returns a short human-readable label for status lines.
+func (t Timespan) Describe() string {
+	switch {
+	case t.IsZero():
+		return "query-defined"
+	case t.Duration != "":
+		return "last " + t.Duration
+	default:
+		return t.Start.UTC().Format("2006-01-02 15:04") + " → " + t.End.UTC().Format("2006-01-02 15:04") + " UTC"
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+// Non-interactive KQL execution (-run=kql:<query>) and formatting (-run=kql-format:<query>)
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/auth"
+	"github.com/FBakkensen/bc-insights-tui/config"
+	"github.com/FBakkensen/bc-insights-tui/internal/export"
+	"github.com/FBakkensen/bc-insights-tui/internal/kql"
+	"github.com/FBakkensen/bc-insights-tui/internal/util"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// Process exit codes for non-interactive commands. 1 remains the generic failure code.
+const (
+	exitCodeUsage  = 2 // bad arguments, unreadable query source, missing configuration
+	exitCodeAuth   = 3 // no stored credentials; run -run=login first
+	exitCodeQuery  = 4 // validation or App Insights API failure
+	exitCodeOutput = 5 // result could not be written
+)
+
+// exitError carries a specific process exit code alongside the underlying error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode wraps err so main exits with code instead of the generic 1.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCodeFor returns the exit code carried by err, defaulting to 1.
+func exitCodeFor(err error) int {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return 1
+}
+
+// runOptions holds flags that only apply to some non-interactive commands.
+type runOptions struct {
+	format   string
+	output   string
+	timespan string // overrides cfg.QueryTimespan when non-empty
+}
+
+// kqlExecutor is the subset of appinsights.Client used by the runner (allows fakes in tests).
+type kqlExecutor interface {
+	ValidateQuery(query string) error
+	ExecuteQuery(ctx context.Context, query string, timespan appinsights.Timespan) (*appinsights.QueryResponse, error)
+}
+
+// runKQLNonInteractive executes a KQL query headlessly using the stored refresh token
+// and writes the primary result table in the requested format.
+func runKQLNonInteractive(arg string, cfg config.Config, opts runOptions) error {
+	format, err := export.ParseFormat(opts.format)
+	if err != nil {
+		return withExitCode(exitCodeUsage, err)
+	}
+	if format == export.FormatXLSX && strings.TrimSpace(opts.output) == "" {
+		return withExitCode(exitCodeUsage, fmt.Errorf("-format=xlsx writes a binary workbook; use it with -output=<file>.xlsx"))
+	}
+	timespan, err := appinsights.ParseTimespan(util.FirstNonEmpty(opts.timespan, cfg.QueryTimespan))
+	if err != nil {
+		return withExitCode(exitCodeUsage, err)
+	}
+	query, err := readKQLSource(arg, os.Stdin)
+	if err != nil {
+		return withExitCode(exitCodeUsage, err)
+	}
+	if strings.TrimSpace(cfg.ApplicationInsightsID) == "" {
+		return withExitCode(exitCodeUsage, fmt.Errorf("application insights app id is not set. set applicationInsightsAppId in config or BCINSIGHTS_APP_INSIGHTS_ID"))
+	}
+
+	authenticator := auth.NewAuthenticator(cfg.OAuth2)
+	if !authenticator.HasValidToken() {
+		return withExitCode(exitCodeAuth, fmt.Errorf("no valid authentication token found. Run with -run=login first"))
+	}
+	client := appinsights.NewClientWithAuthenticator(authenticator, cfg.ApplicationInsightsID)
+
+	timeoutSec := cfg.QueryTimeoutSeconds
+	if timeoutSec <= 0 {
+		timeoutSec = 30
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
+	defer cancel()
+	return writeOutput(opts.output, func(out io.Writer) error {
+		return executeKQLToWriter(ctx, client, query, timespan, format, out, os.Stderr)
+	})
+}
+
+// writeOutput runs write against stdout or, with path set, against a buffer that
+// replaces the file only once write succeeds, so a failed run keeps the old file.
+func writeOutput(path string, write func(out io.Writer) error) error {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return write(os.Stdout)
+	}
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	if err := util.WriteFileAtomic(path, buf.Bytes()); err != nil {
+		return withExitCode(exitCodeOutput, fmt.Errorf("failed to write output file %s: %w", path, err))
+	}
+	return nil
+}
+
+// executeKQLToWriter validates and executes query, then encodes the primary table to out.
+// A one-line summary is written to status (stderr) so stdout stays machine-readable.
+func executeKQLToWriter(ctx context.Context, client kqlExecutor, query string, timespan appinsights.Timespan, format export.Format, out, status io.Writer) error {
+	if err := client.ValidateQuery(query); err != nil {
+		logging.Error("Non-interactive KQL validation failed", "error", err.Error())
+		return withExitCode(exitCodeQuery, fmt.Errorf("invalid query: %w", err))
+	}
+	if status != nil {
+		// Report automatic retries on stderr so long waits are not mistaken for a hang
+		ctx = appinsights.WithRetryNotifier(ctx, func(ev appinsights.RetryEvent) {
+			fmt.Fprintf(status, "retrying (attempt %d/%d) in %.1fs\n", ev.Attempt, ev.MaxAttempts, ev.Wait.Seconds())
+		})
+	}
+	start := time.Now()
+	resp, err := client.ExecuteQuery(ctx, query, timespan)
+	dur := time.Since(start)
+	if err != nil {
+		logging.Error("Non-interactive KQL execute failed", "error", err.Error(), "duration_ms", fmt.Sprintf("%d", dur.Milliseconds()))
+		if ctx.Err() == context.DeadlineExceeded {
+			return withExitCode(exitCodeQuery, fmt.Errorf("query timed out after %s: %w", dur.Round(time.Second), err))
+		}
+		return withExitCode(exitCodeQuery, err)
+	}
+	table := primaryTable(resp)
+	if err := export.Write(out, format, table); err != nil {
+		return withExitCode(exitCodeOutput, fmt.Errorf("failed to write results: %w", err))
+	}
+	logging.Info("Non-interactive KQL complete",
+		"rows", fmt.Sprintf("%d", len(table.Rows)),
+		"cols", fmt.Sprintf("%d", len(table.Columns)),
+		"format", string(format),
+		"duration_ms", fmt.Sprintf("%d", dur.Milliseconds()),
+	)
+	if status != nil {
+		if timespan.IsZero() {
+			fmt.Fprintf(status, "%d rows in %.3fs\n", len(table.Rows), dur.Seconds())
+		} else {
+			fmt.Fprintf(status, "%d rows in %.3fs (time: %s)\n", len(table.Rows), dur.Seconds(), timespan.Describe())
+		}
+	}
+	return nil
+}
+
+// primaryTable converts the PrimaryResult table (or the first table) into an export.Table.
+func primaryTable(resp *appinsights.QueryResponse) export.Table {
+	if resp == nil || len(resp.Tables) == 0 {
+		return export.Table{}
+	}
+	idx := 0
+	for i, t := range resp.Tables {
+		if strings.EqualFold(t.Name, "PrimaryResult") {
+			idx = i
+			break
+		}
+	}
+	t := resp.Tables[idx]
+	cols := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		cols[i] = c.Name
+	}
+	return export.Table{Columns: cols, Rows: t.Rows}
+}
+
+// runKQLFormatNonInteractive pretty-prints a query read like -run=kql reads it.
+// Nothing is sent, so no configuration or sign-in is needed.
+func runKQLFormatNonInteractive(arg string, opts runOptions) error {
+	query, err := readKQLSource(arg, os.Stdin)
+	if err != nil {
+		return withExitCode(exitCodeUsage, err)
+	}
+	return writeOutput(opts.output, func(out io.Writer) error {
+		return formatKQLToWriter(query, out)
+	})
+}
+
+// formatKQLToWriter writes the formatted query to out; syntax errors exit with exitCodeQuery.
+func formatKQLToWriter(query string, out io.Writer) error {
+	formatted, err := kql.Format(query)
+	if err != nil {
+		return withExitCode(exitCodeQuery, fmt.Errorf("cannot format query: %w", err))
+	}
+	if _, werr := fmt.Fprintln(out, formatted); werr != nil {
+		return withExitCode(exitCodeOutput, fmt.Errorf("failed to write formatted query: %w", werr))
+	}
+	return nil
+}
+
+// readKQLSource resolves the query text from the -run argument:
+//   - empty or "-": read from stdin
+//   - "@path": read from file
+//   - a single-line value ending in .kql that names an existing file: read from file
+//   - anything else: the literal query text
+func readKQLSource(arg string, stdin io.Reader) (string, error) {
+	arg = strings.TrimSpace(arg)
+	var query string
+	switch {
+	case arg == "" || arg == "-":
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read query from stdin: %w", err)
+		}
+		query = string(b)
+	case strings.HasPrefix(arg, "@"):
+		b, err := os.ReadFile(strings.TrimSpace(arg[1:]))
+		if err != nil {
+			return "", fmt.Errorf("failed to read query file: %w", err)
+		}
+		query = string(b)
+	case isKQLFilePath(arg):
+		b, err := os.ReadFile(arg)
+		if err != nil {
+			return "", fmt.Errorf("failed to read query file: %w", err)
+		}
+		query = string(b)
+	default:
+		query = arg
+	}
+	query = strings.TrimSpace(strings.ReplaceAll(query, "\r\n", "\n"))
+	if query == "" {
+		return "", fmt.Errorf("query is empty. usage: -run=kql:\"traces | take 5\", -run=kql:@query.kql, or pipe the query on stdin with -run=kql")
+	}
+	return query, nil
+}
+
+// isKQLFilePath reports whether arg looks like a path to an existing .kql file.
+func isKQLFilePath(arg string) bool {
+	if strings.ContainsAny(arg, "\n|") || !strings.HasSuffix(strings.ToLower(arg), ".kql") {
+		return false
+	}
+	info, err := os.Stat(arg)
+	return err == nil && !info.IsDir()
+}
@@ -23,7 +23,10 @@ import (
 
 func main() {
 	// Parse command line flags
-	runCmd := flag.String("run", "", "Run a command non-interactively (e.g., 'subs', 'login')")
+	runCmd := flag.String("run", "", "Run a command non-interactively (e.g., 'subs', 'login', 'kql:<query>')")
+	formatFlag := flag.String("format", "table", "Output format for -run=kql: table, csv, json, ndjson, markdown, xlsx (xlsx needs -output)")
+	outputFlag := flag.String("output", "", "Write -run=kql results to this file instead of stdout")
+	timespanFlag := flag.String("timespan", "", "Time window for -run=kql (PT1H, P1D, 7d, start/end or 'none'); overrides queryTimespan")
 	flag.Parse()
 
 	// Load .env early so env vars affect logging level and config
@@ -62,11 +65,12 @@ func main() {
 
 	// If run command is specified, execute it non-interactively
 	if *runCmd != "" {
-		err := runNonInteractiveCommand(*runCmd, cfg)
+		err := runNonInteractiveCommand(*runCmd, cfg, runOptions{format: *formatFlag, output: *outputFlag, timespan: *timespanFlag})
 		if err != nil {
 			logging.Error("Non-interactive command failed", "command", *runCmd, "error", err.Error())
-			fmt.Printf("Error running command '%s': %v\n", *runCmd, err)
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "Error running command '%s': %v\n", *runCmd, err)
+			logging.Close()
+			os.Exit(exitCodeFor(err))
 		}
 		return
 	}
@@ -79,7 +83,7 @@ func main() {
 }
 
 // runNonInteractiveCommand executes a command without starting the TUI
-func runNonInteractiveCommand(command string, cfg config.Config) error {
+func runNonInteractiveCommand(command string, cfg config.Config, opts runOptions) error {
 	logging.Info("Running non-interactive command", "command", command)
 
 	// Split optional argument (e.g., logs:250)
@@ -103,6 +107,14 @@ func runNonInteractiveCommand(command string, cfg config.Config) error {
 		return tailLatestLogFileNonInteractive(lines)
 	}
 
+	// kql:<query> | kql:@file.kql | kql (stdin)
+	if name == "kql" {
+		return runKQLNonInteractive(arg, cfg, opts)
+	}
+	if name == "kql-format" {
+		return runKQLFormatNonInteractive(arg, opts)
+	}
+
 	// Command registry to keep complexity low
 	handlers := map[string]func() error{
 		"subs":         func() error { return listSubscriptionsNonInteractive(cfg) },
@@ -120,7 +132,7 @@ func runNonInteractiveCommand(command string, cfg config.Config) error {
 	if h, ok := handlers[name]; ok {
 		return h()
 	}
-	return fmt.Errorf("unknown command: %s. Available commands: subs, login, login-status, keyring-info, keyring-test, resources, config, config-save, config-reset, config-path, logs[:N]", command)
+	return fmt.Errorf("unknown command: %s. Available commands: subs, login, login-status, keyring-info, keyring-test, resources, config, config-save, config-reset, config-path, logs[:N], kql[:query], kql-format[:query]", command)
 }
 
 // tailLatestLogFileNonInteractive prints the last N lines of the newest log file in logs/.
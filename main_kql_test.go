@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/config"
+	"github.com/FBakkensen/bc-insights-tui/internal/export"
+)
+
+type fakeKQL struct {
+	resp        *appinsights.QueryResponse
+	validateErr error
+	execErr     error
+	gotQuery    string
+	gotTimespan appinsights.Timespan
+}
+
+func (f *fakeKQL) ValidateQuery(query string) error { return f.validateErr }
+func (f *fakeKQL) ExecuteQuery(ctx context.Context, query string, timespan appinsights.Timespan) (*appinsights.QueryResponse, error) {
+	f.gotQuery = query
+	f.gotTimespan = timespan
+	return f.resp, f.execErr
+}
+
+func TestReadKQLSource_LiteralFileAndStdin(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "q.kql")
+	if err := os.WriteFile(p, []byte("traces\r\n| take 3\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		arg   string
+		stdin string
+		want  string
+	}{
+		{arg: "traces | take 5", want: "traces | take 5"},
+		{arg: "@" + p, want: "traces\n| take 3"},
+		{arg: p, want: "traces\n| take 3"},
+		{arg: "", stdin: "requests | take 1\n", want: "requests | take 1"},
+		{arg: "-", stdin: "exceptions", want: "exceptions"},
+	}
+	for _, c := range cases {
+		got, err := readKQLSource(c.arg, strings.NewReader(c.stdin))
+		if err != nil {
+			t.Fatalf("readKQLSource(%q): %v", c.arg, err)
+		}
+		if got != c.want {
+			t.Fatalf("readKQLSource(%q) = %q; want %q", c.arg, got, c.want)
+		}
+	}
+}
+
+func TestReadKQLSource_EmptyIsUsageError(t *testing.T) {
+	if _, err := readKQLSource("", strings.NewReader("   ")); err == nil {
+		t.Fatalf("expected error for empty stdin")
+	}
+	if _, err := readKQLSource("@does-not-exist.kql", strings.NewReader("")); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+}
+
+func TestExecuteKQLToWriter_WritesPrimaryResult(t *testing.T) {
+	resp := &appinsights.QueryResponse{Tables: []appinsights.Table{
+		{Name: "Other", Columns: []appinsights.Column{{Name: "x"}}, Rows: [][]interface{}{{"ignored"}}},
+		{Name: "PrimaryResult", Columns: []appinsights.Column{{Name: "a"}, {Name: "b"}}, Rows: [][]interface{}{{"1", 2.0}}},
+	}}
+	var out, status bytes.Buffer
+	err := executeKQLToWriter(context.Background(), &fakeKQL{resp: resp}, "traces", appinsights.Timespan{}, export.FormatCSV, &out, &status)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "a,b\n1,2\n" {
+		t.Fatalf("unexpected csv: %q", out.String())
+	}
+	if !strings.Contains(status.String(), "1 rows") {
+		t.Fatalf("expected row summary on status writer; got %q", status.String())
+	}
+}
+
+func TestExecuteKQLToWriter_ExitCodes(t *testing.T) {
+	var out bytes.Buffer
+	err := executeKQLToWriter(context.Background(), &fakeKQL{validateErr: fmt.Errorf("bad")}, "x", appinsights.Timespan{}, export.FormatTable, &out, nil)
+	if exitCodeFor(err) != exitCodeQuery {
+		t.Fatalf("expected exit code %d for validation error, got %d (%v)", exitCodeQuery, exitCodeFor(err), err)
+	}
+	err = executeKQLToWriter(context.Background(), &fakeKQL{execErr: fmt.Errorf("API request failed with status 400")}, "x", appinsights.Timespan{}, export.FormatTable, &out, nil)
+	if exitCodeFor(err) != exitCodeQuery {
+		t.Fatalf("expected exit code %d for API error, got %d", exitCodeQuery, exitCodeFor(err))
+	}
+	if exitCodeFor(fmt.Errorf("plain")) != 1 {
+		t.Fatalf("expected generic exit code 1 for untyped errors")
+	}
+}
+
+func TestRunKQLNonInteractive_BadFormatIsUsage(t *testing.T) {
+	err := runNonInteractiveCommand("kql:traces", config.NewConfig(), runOptions{format: "xml"})
+	if exitCodeFor(err) != exitCodeUsage {
+		t.Fatalf("expected usage exit code, got %d (%v)", exitCodeFor(err), err)
+	}
+	err = runNonInteractiveCommand("kql:traces", config.NewConfig(), runOptions{format: "xlsx"})
+	if exitCodeFor(err) != exitCodeUsage || !strings.Contains(err.Error(), "-output") {
+		t.Fatalf("expected usage exit code for xlsx on stdout, got %d (%v)", exitCodeFor(err), err)
+	}
+}
+
+func TestExecuteKQLToWriter_PassesTimespan(t *testing.T) {
+	ts, err := appinsights.ParseTimespan("1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeKQL{resp: &appinsights.QueryResponse{}}
+	var out, status bytes.Buffer
+	if err := executeKQLToWriter(context.Background(), f, "traces", ts, export.FormatCSV, &out, &status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.gotTimespan.String() != "P1D" {
+		t.Fatalf("expected timespan P1D to reach the client, got %q", f.gotTimespan.String())
+	}
+	if !strings.Contains(status.String(), "time: last P1D") {
+		t.Fatalf("expected time window in status line; got %q", status.String())
+	}
+}
+
+func TestRunKQLNonInteractive_BadTimespanIsUsage(t *testing.T) {
+	err := runNonInteractiveCommand("kql:traces", config.NewConfig(), runOptions{format: "csv", timespan: "yesterday"})
+	if exitCodeFor(err) != exitCodeUsage {
+		t.Fatalf("expected usage exit code, got %d (%v)", exitCodeFor(err), err)
+	}
+}
+
+func TestFormatKQLToWriter(t *testing.T) {
+	var out bytes.Buffer
+	if err := formatKQLToWriter("traces | where severityLevel > 2 | take 5", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "traces\n| where severityLevel > 2\n| take 5\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+	err := formatKQLToWriter("traces | where (a", &out)
+	if exitCodeFor(err) != exitCodeQuery || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected query exit code with location, got %d (%v)", exitCodeFor(err), err)
+	}
+}
+
+func TestRunKQLFormat_WritesOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "q.kql")
+	if err := os.WriteFile(src, []byte("requests | summarize count() by resultCode"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "out.kql")
+	if err := runNonInteractiveCommand("kql-format:@"+src, config.NewConfig(), runOptions{output: dst}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := os.ReadFile(dst)
+	if string(b) != "requests\n| summarize count() by resultCode\n" {
+		t.Fatalf("unexpected output file: %q", b)
+	}
+}
+
+func TestWriteOutput_FailureKeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "out.kql")
+	if err := os.WriteFile(dst, []byte("previous\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := runNonInteractiveCommand("kql-format:traces | where (a", config.NewConfig(), runOptions{output: dst})
+	if exitCodeFor(err) != exitCodeQuery {
+		t.Fatalf("expected a query error, got %v", err)
+	}
+	if b, _ := os.ReadFile(dst); string(b) != "previous\n" {
+		t.Fatalf("expected the existing file kept, got %q", b)
+	}
+
+	err = writeOutput(filepath.Join(dst, "not-a-dir.csv"), func(out io.Writer) error {
+		_, werr := fmt.Fprintln(out, "a,b")
+		return werr
+	})
+	if exitCodeFor(err) != exitCodeOutput {
+		t.Fatalf("expected an output error when the file cannot be written, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package tui
+
+// Clipboard: copies go through OSC 52, an escape sequence the terminal turns
+// into a clipboard write. It works over SSH and in Windows Terminal without
+// platform clipboard libraries; tmux and screen get their passthrough wrapping.
+//
+// Keys: in the results table c copies the cell under the column cursor
+// (Left/Right), y the row as JSON and q the query; in details c copies the
+// pane text. 'copy' in chat copies the last query.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/internal/export"
+	"github.com/FBakkensen/bc-insights-tui/internal/util"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// clipboardOut receives the OSC 52 sequences; replaced in tests.
+var clipboardOut io.Writer = os.Stdout
+
+// osc52Sequence builds the clipboard sequence for text, wrapped for tmux or screen.
+func osc52Sequence(text string) osc52.Sequence {
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	return seq
+}
+
+// copyToClipboard reports the copy in the status line and returns the command
+// that writes it to the terminal.
+func (m *model) copyToClipboard(what, text string) tea.Cmd {
+	if text == "" {
+		m.statusNote = "nothing to copy: " + what + " is empty"
+		return nil
+	}
+	m.statusNote = fmt.Sprintf("copied %s (%d chars)", what, utf8.RuneCountInString(text))
+	logging.Info("Copied to clipboard", "what", what, "chars", fmt.Sprintf("%d", utf8.RuneCountInString(text)))
+	seq := osc52Sequence(text)
+	return func() tea.Msg {
+		if _, err := seq.WriteTo(clipboardOut); err != nil {
+			logging.Warn("Clipboard write failed", "error", err.Error())
+		}
+		return nil
+	}
+}
+
+// handleCopyKey handles the copy keys of the results table and details view.
+func (m model) handleCopyKey(key string) (tea.Model, tea.Cmd, bool) {
+	idx := m.rowIndex(m.tbl.Cursor())
+	if m.mode == modeDetails {
+		idx = m.detailsRow
+	}
+	var cmd tea.Cmd
+	switch {
+	case key == "q":
+		cmd = m.copyToClipboard("query", m.resultQuery())
+	case idx < 0 || idx >= len(m.lastRows):
+		return m, nil, false
+	case key == "y":
+		cmd = m.copyToClipboard("row as JSON", rowJSON(m.lastColumns, m.lastRows[idx]))
+	case key == "c" && m.mode == modeDetails:
+		cmd = m.copyToClipboard("details", strings.TrimRight(m.detailsContent, "\n"))
+	case key == "c":
+		header, value := m.selectedCell(idx)
+		cmd = m.copyToClipboard(header, value)
+	default:
+		return m, nil, false
+	}
+	return m, cmd, true
+}
+
+// resultQuery returns the query behind the current results, or the last query run.
+func (m model) resultQuery() string {
+	if m.haveResults {
+		return util.FirstNonEmpty(m.paging.query, m.lastTemplate)
+	}
+	return m.lastTemplate
+}
+
+// selectedCell returns the header and full value of the cell under the column cursor in row idx.
+func (m model) selectedCell(idx int) (string, string) {
+	headers := m.lastDisplayHeaders
+	if len(headers) == 0 || m.tblCol < 0 || m.tblCol >= len(headers) {
+		return "cell", ""
+	}
+	_, data := buildDisplayMatrixFromHeaders(headers, m.lastColumns, m.lastRows[idx:idx+1])
+	if len(data) == 0 || m.tblCol >= len(data[0]) {
+		return headers[m.tblCol], ""
+	}
+	return headers[m.tblCol], data[0][m.tblCol]
+}
+
+// rowJSON renders a raw result row as indented JSON in column order. Dynamic
+// values that arrive as JSON text (customDimensions) are embedded as objects.
+func rowJSON(columns []appinsights.Column, row []interface{}) string {
+	names := make([]string, len(columns))
+	values := make([]interface{}, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+		if i >= len(row) {
+			continue
+		}
+		values[i] = row[i]
+		if s, ok := row[i].(string); ok && strings.EqualFold(c.Type, "dynamic") {
+			if t := strings.TrimSpace(s); (strings.HasPrefix(t, "{") || strings.HasPrefix(t, "[")) && json.Valid([]byte(t)) {
+				values[i] = json.RawMessage(t)
+			}
+		}
+	}
+	obj, err := export.RowObject(names, values)
+	if err != nil {
+		return ""
+	}
+	var b bytes.Buffer
+	if json.Indent(&b, []byte(obj), "", "  ") != nil {
+		return obj
+	}
+	return b.String()
+}
+
+// moveColumnCursor moves the table's column cursor by delta across all headers,
+// scrolling the table when the cursor leaves the shown columns.
+func (m *model) moveColumnCursor(delta int) {
+	n := len(m.lastDisplayHeaders)
+	if n == 0 || len(m.tblVisible) == 0 {
+		return
+	}
+	m.tblCol = clamp(m.tblCol+delta, 0, n-1)
+	if slices.Contains(m.tblVisible, m.tblCol) {
+		m.markColumnCursor()
+		return
+	}
+	m.refreshTable(m.rowIndex(m.tbl.Cursor()))
+}
+
+// columnCursorMark prefixes the title of the column under the cursor.
+const columnCursorMark = "▸"
+
+// markColumnCursor updates the column titles so the one under the cursor is marked.
+func (m *model) markColumnCursor() {
+	cols := m.tbl.Columns()
+	for i := range cols {
+		cols[i].Title = strings.TrimPrefix(cols[i].Title, columnCursorMark)
+		if i < len(m.tblVisible) && m.tblVisible[i] == m.tblCol {
+			cols[i].Title = columnCursorMark + cols[i].Title
+		}
+	}
+	m.tbl.SetColumns(cols)
+}
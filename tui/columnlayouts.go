@@ -0,0 +1,456 @@
+package tui
+
+// Column layouts: 'C' in the results table (or 'columns' in chat) opens a picker
+// to show, hide, pin and reorder the customDimensions columns. The choice is
+// saved per eventId in cfg.ColumnLayoutsFile, so the next result of the same
+// event type opens with it; results mixing event types use the default layout
+// ("*"). Pinned keys come first in their saved order, the rest follow the
+// ranking; timestamp and message always lead.
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/config"
+	"github.com/FBakkensen/bc-insights-tui/internal/telemetry"
+	"github.com/FBakkensen/bc-insights-tui/internal/util"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// columnLayoutsFileVersion is bumped when the on-disk layout changes incompatibly.
+const columnLayoutsFileVersion = 1
+
+// defaultLayoutKey names the layout for results without a single eventId.
+const defaultLayoutKey = "*"
+
+// eventIDSampleSize bounds the rows inspected to find the results' eventId.
+const eventIDSampleSize = 200
+
+// savedLayout is the saved column choice for one event type.
+type savedLayout struct {
+	EventID string    `yaml:"eventId"`
+	Pinned  []string  `yaml:"pinned,omitempty"`
+	Hidden  []string  `yaml:"hidden,omitempty"`
+	SavedAt time.Time `yaml:"savedAt"`
+}
+
+// columnLayoutsFile is the YAML document stored at cfg.ColumnLayoutsFile.
+type columnLayoutsFile struct {
+	Version int           `yaml:"version"`
+	Layouts []savedLayout `yaml:"layouts"`
+}
+
+// columnLayoutStore holds the saved layouts. An empty path keeps them in memory only.
+type columnLayoutStore struct {
+	path    string
+	layouts []savedLayout
+}
+
+// loadColumnLayouts reads the layouts file if present; failures are logged and
+// yield no layouts.
+func loadColumnLayouts(path string) *columnLayoutStore {
+	s := &columnLayoutStore{path: path}
+	if strings.TrimSpace(path) == "" {
+		return s
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Warn("Column layouts read failed", "path", path, "error", err.Error())
+		}
+		return s
+	}
+	var doc columnLayoutsFile
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		logging.Warn("Column layouts parse failed; using ranking only", "path", path, "error", err.Error())
+		return s
+	}
+	for _, l := range doc.Layouts {
+		if strings.TrimSpace(l.EventID) != "" {
+			s.layouts = append(s.layouts, l)
+		}
+	}
+	logging.Debug("Column layouts loaded", "path", path, "count", fmt.Sprintf("%d", len(s.layouts)))
+	return s
+}
+
+// resolveColumnLayoutsPath places a relative layouts file next to the config file.
+func resolveColumnLayoutsPath(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return ""
+	}
+	p, err := config.ResolveDataFilePath(name)
+	if err != nil {
+		logging.Warn("Column layouts path unavailable; layouts kept in memory", "error", err.Error())
+		return ""
+	}
+	return p
+}
+
+// find returns the index of the layout saved for eventID, or -1.
+func (s *columnLayoutStore) find(eventID string) int {
+	if s == nil {
+		return -1
+	}
+	return slices.IndexFunc(s.layouts, func(l savedLayout) bool { return strings.EqualFold(l.EventID, eventID) })
+}
+
+// get returns the layout for eventID, falling back to the default layout.
+func (s *columnLayoutStore) get(eventID string) (savedLayout, bool) {
+	if i := s.find(layoutKey(eventID)); i >= 0 {
+		return s.layouts[i], true
+	}
+	if i := s.find(defaultLayoutKey); i >= 0 {
+		return s.layouts[i], true
+	}
+	return savedLayout{}, false
+}
+
+// layoutKey maps an empty eventId to the default layout key.
+func layoutKey(eventID string) string {
+	if eventID == "" {
+		return defaultLayoutKey
+	}
+	return eventID
+}
+
+// put inserts or replaces the layout for l.EventID and persists.
+func (s *columnLayoutStore) put(l savedLayout) error {
+	if i := s.find(l.EventID); i >= 0 {
+		s.layouts[i] = l
+	} else {
+		s.layouts = append(s.layouts, l)
+	}
+	return s.save()
+}
+
+// remove deletes the layout for eventID and persists; it reports whether one existed.
+func (s *columnLayoutStore) remove(eventID string) (bool, error) {
+	i := s.find(eventID)
+	if i < 0 {
+		return false, nil
+	}
+	s.layouts = slices.Delete(s.layouts, i, i+1)
+	return true, s.save()
+}
+
+// save writes the layouts atomically (temp file + rename) next to their target.
+func (s *columnLayoutStore) save() error {
+	if strings.TrimSpace(s.path) == "" {
+		return nil
+	}
+	b, err := yaml.Marshal(columnLayoutsFile{Version: columnLayoutsFileVersion, Layouts: s.layouts})
+	if err != nil {
+		return fmt.Errorf("failed to encode column layouts: %w", err)
+	}
+	if err := util.WriteFileAtomic(s.path, b); err != nil {
+		return fmt.Errorf("failed to save column layouts %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// resultEventID returns the customDimensions eventId shared by the sampled rows,
+// or "" when rows carry none or several.
+func resultEventID(columns []appinsights.Column, rows [][]interface{}) string {
+	id := ""
+	for i, r := range rows {
+		if i >= eventIDSampleSize {
+			break
+		}
+		_, _, fields := telemetry.BuildDetails(columns, r)
+		for _, f := range fields {
+			if !strings.EqualFold(f.Key, "eventId") || f.Value == "" {
+				continue
+			}
+			if id != "" && !strings.EqualFold(id, f.Value) {
+				return ""
+			}
+			id = f.Value
+		}
+	}
+	return id
+}
+
+// applyColumnLayout orders ranked headers by the layout: timestamp and message
+// first, then pinned keys in their saved order, then the remaining ranked keys.
+// Hidden keys are dropped.
+func applyColumnLayout(ranked []string, l savedLayout) []string {
+	if len(ranked) < 2 {
+		return ranked
+	}
+	hidden := make(map[string]bool, len(l.Hidden))
+	for _, h := range l.Hidden {
+		hidden[strings.ToLower(h)] = true
+	}
+	out := append([]string(nil), ranked[:2]...)
+	used := map[string]bool{}
+	for _, p := range l.Pinned {
+		for _, k := range ranked[2:] {
+			lk := strings.ToLower(k)
+			if strings.EqualFold(k, p) && !hidden[lk] && !used[lk] {
+				out = append(out, k)
+				used[lk] = true
+			}
+		}
+	}
+	for _, k := range ranked[2:] {
+		if lk := strings.ToLower(k); !hidden[lk] && !used[lk] {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// layoutHeaders ranks the headers of a result table and applies its saved layout.
+func (m *model) layoutHeaders(columns []appinsights.Column, rows [][]interface{}) []string {
+	ranked := computeRankedHeaders(columns, rows, m.cfg)
+	if l, ok := m.columnLayouts.get(resultEventID(columns, rows)); ok {
+		return applyColumnLayout(ranked, l)
+	}
+	return ranked
+}
+
+// withoutHiddenKeys drops the keys the active results' layout hides.
+func (m *model) withoutHiddenKeys(keys []string) []string {
+	l, ok := m.columnLayouts.get(resultEventID(m.lastColumns, m.lastRows))
+	if !ok || len(l.Hidden) == 0 {
+		return keys
+	}
+	return slices.DeleteFunc(keys, func(k string) bool {
+		return slices.ContainsFunc(l.Hidden, func(h string) bool { return strings.EqualFold(h, k) })
+	})
+}
+
+// columnPicker is the state of the column chooser panel.
+type columnPicker struct {
+	eventID    string   // layout key: the results' eventId or defaultLayoutKey
+	keys       []string // customDimensions keys in ranked order
+	pinned     []string
+	hidden     map[string]bool // lower-cased keys
+	returnMode uiMode
+}
+
+// columnItem is one row of the column chooser.
+type columnItem struct {
+	key    string
+	fixed  bool // timestamp and message are always shown first
+	shown  bool
+	pinned bool
+}
+
+func (i columnItem) FilterValue() string { return i.key }
+func (i columnItem) Title() string {
+	box := "[x]"
+	if !i.shown {
+		box = "[ ]"
+	}
+	return box + " " + i.key
+}
+func (i columnItem) Description() string {
+	switch {
+	case i.fixed:
+		return "    always shown first"
+	case i.pinned && i.shown:
+		return "    pinned"
+	case i.pinned:
+		return "    pinned · hidden"
+	case i.shown:
+		return "    ranked"
+	default:
+		return "    ranked · hidden"
+	}
+}
+
+// items lists fixed columns, pinned keys in order, then the other keys in ranked order.
+func (p *columnPicker) items() []list.Item {
+	items := []list.Item{
+		columnItem{key: "timestamp", fixed: true, shown: true},
+		columnItem{key: "message", fixed: true, shown: true},
+	}
+	for _, k := range p.pinned {
+		items = append(items, columnItem{key: k, pinned: true, shown: !p.hidden[strings.ToLower(k)]})
+	}
+	for _, k := range p.keys {
+		if !p.isPinned(k) {
+			items = append(items, columnItem{key: k, shown: !p.hidden[strings.ToLower(k)]})
+		}
+	}
+	return items
+}
+
+func (p *columnPicker) isPinned(key string) bool {
+	return slices.ContainsFunc(p.pinned, func(k string) bool { return strings.EqualFold(k, key) })
+}
+
+// togglePin pins key at the end of the pinned keys, or unpins it.
+func (p *columnPicker) togglePin(key string) {
+	if i := slices.IndexFunc(p.pinned, func(k string) bool { return strings.EqualFold(k, key) }); i >= 0 {
+		p.pinned = slices.Delete(p.pinned, i, i+1)
+		return
+	}
+	p.pinned = append(p.pinned, key)
+}
+
+// move shifts key among the pinned keys by delta, pinning it first if needed.
+func (p *columnPicker) move(key string, delta int) {
+	if !p.isPinned(key) {
+		p.pinned = append(p.pinned, key)
+	}
+	i := slices.IndexFunc(p.pinned, func(k string) bool { return strings.EqualFold(k, key) })
+	j := clamp(i+delta, 0, len(p.pinned)-1)
+	p.pinned[i], p.pinned[j] = p.pinned[j], p.pinned[i]
+}
+
+// layout returns the picker state as a layout.
+func (p *columnPicker) layout() savedLayout {
+	l := savedLayout{EventID: p.eventID, Pinned: slices.Clone(p.pinned), SavedAt: time.Now().UTC()}
+	for _, k := range p.keys {
+		if p.hidden[strings.ToLower(k)] {
+			l.Hidden = append(l.Hidden, k)
+		}
+	}
+	return l
+}
+
+// openColumnPicker shows the column chooser for the active result table.
+func (m model) openColumnPicker() (tea.Model, tea.Cmd) {
+	if !m.haveResults || len(m.lastColumns) == 0 || len(m.lastRows) == 0 {
+		m.append("No results to choose columns for. Run a query first.")
+		return m, nil
+	}
+	p := &columnPicker{
+		eventID:    layoutKey(resultEventID(m.lastColumns, m.lastRows)),
+		hidden:     map[string]bool{},
+		returnMode: m.mode,
+	}
+	ranked := computeRankedHeaders(m.lastColumns, m.lastRows, m.cfg)
+	if len(ranked) > 2 {
+		p.keys = ranked[2:]
+	}
+	if l, ok := m.columnLayouts.get(p.eventID); ok {
+		for _, k := range l.Pinned {
+			if slices.ContainsFunc(p.keys, func(r string) bool { return strings.EqualFold(r, k) }) {
+				p.pinned = append(p.pinned, k)
+			}
+		}
+		for _, h := range l.Hidden {
+			p.hidden[strings.ToLower(h)] = true
+		}
+	}
+	m.colPicker = p
+	m.mode = modeListColumns
+	m.list.Title = "Columns — " + p.title()
+	m.list.ResetFilter()
+	m.list.SetItems(p.items())
+	m.list.Select(0)
+	logging.Info("Column picker opened", "eventId", p.eventID, "keys", fmt.Sprintf("%d", len(p.keys)))
+	return m, nil
+}
+
+func (p *columnPicker) title() string {
+	if p.eventID == defaultLayoutKey {
+		return "default layout (results without a single eventId)"
+	}
+	return "eventId " + p.eventID
+}
+
+// columnPickerHint is shown in the status line while the picker is open.
+const columnPickerHint = "Space show/hide · p pin · Shift+Up/Down or K/J move · r reset · Enter save · Esc cancel"
+
+// handleColumnPickerKey processes keys in the column chooser.
+func (m model) handleColumnPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.colPicker
+	sel, _ := m.list.SelectedItem().(columnItem)
+	switch msg.String() {
+	case keyEsc:
+		m.closeColumnPicker()
+		m.append("Closed column chooser; layout unchanged.")
+		return m, nil
+	case keyEnter:
+		return m.saveColumnPicker()
+	case " ", "x":
+		if sel.key != "" && !sel.fixed {
+			p.hidden[strings.ToLower(sel.key)] = sel.shown
+		}
+	case "p":
+		if sel.key != "" && !sel.fixed {
+			p.togglePin(sel.key)
+		}
+	case "K", "shift+up":
+		if sel.key != "" && !sel.fixed {
+			p.move(sel.key, -1)
+		}
+	case "J", "shift+down":
+		if sel.key != "" && !sel.fixed {
+			p.move(sel.key, 1)
+		}
+	case "r":
+		p.pinned = nil
+		p.hidden = map[string]bool{}
+	case "q", "/":
+		return m, nil // no quit or filter in this panel
+	default:
+		var cmd tea.Cmd
+		m.list, cmd = m.list.Update(msg)
+		return m, cmd
+	}
+	m.list.SetItems(p.items())
+	if sel.key != "" {
+		for i, it := range m.list.Items() {
+			if it.(columnItem).key == sel.key {
+				m.list.Select(i)
+			}
+		}
+	}
+	return m, nil
+}
+
+// saveColumnPicker stores the chosen layout, applies it to the active table and
+// closes the picker.
+func (m model) saveColumnPicker() (tea.Model, tea.Cmd) {
+	p := m.colPicker
+	l := p.layout()
+	var err error
+	if len(l.Pinned) == 0 && len(l.Hidden) == 0 {
+		_, err = m.columnLayouts.remove(l.EventID)
+		m.append("Column layout reset to the ranking for " + p.title() + ".")
+	} else {
+		err = m.columnLayouts.put(l)
+		m.append(fmt.Sprintf("Column layout saved for %s: %d pinned, %d hidden.", p.title(), len(l.Pinned), len(l.Hidden)))
+	}
+	if err != nil {
+		logging.Error("Failed to save column layout", "error", err.Error())
+		m.append("Layout applied for this session, but saving it failed: " + err.Error())
+	}
+	logging.Info("Column layout saved",
+		"eventId", l.EventID,
+		"pinned", fmt.Sprintf("%d", len(l.Pinned)),
+		"hidden", fmt.Sprintf("%d", len(l.Hidden)),
+	)
+	m.lastDisplayHeaders = m.layoutHeaders(m.lastColumns, m.lastRows)
+	if m.activeTable >= 0 && m.activeTable < len(m.lastTables) {
+		m.lastTables[m.activeTable].headers = m.lastDisplayHeaders
+	}
+	m.closeColumnPicker()
+	if m.mode == modeTableResults {
+		m.refreshTable(m.rowIndex(m.tbl.Cursor()))
+	}
+	return m, nil
+}
+
+// closeColumnPicker returns to the mode the picker was opened from.
+func (m *model) closeColumnPicker() {
+	m.mode = modeChat
+	if m.colPicker != nil && m.colPicker.returnMode == modeTableResults {
+		m.mode = modeTableResults
+	}
+	m.colPicker = nil
+}
@@ -2,6 +2,10 @@ package tui
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
 
 	"github.com/FBakkensen/bc-insights-tui/logging"
 )
@@ -59,3 +63,142 @@ func computeColumnLayout(headers []string, totalWidth, minColWidth int) columnLa
 	}
 	return columnLayout{headers: headers, visible: headers[:dataCols], hiddenCount: hidden, truncated: true, dataCols: dataCols, colWidth: colWidth}
 }
+
+// Content-aware widths for the interactive table. Each column is as wide as its
+// header and widest sampled cell, within [minScrollColWidth, maxScrollColWidth].
+const (
+	minScrollColWidth = 6
+	maxScrollColWidth = 40
+	columnWidthSample = 200 // rows sampled for widths
+	cellPadding       = 2   // bubbles table cells pad one space on each side
+	headerMarksWidth  = 4   // room for the cursor, scroll and sort marks in a title
+)
+
+// scrollLayout is the part of the headers the interactive table shows: the
+// frozen columns, then the columns from offset that fit the width.
+type scrollLayout struct {
+	visible     []int // header indexes shown, in order
+	widths      []int // content width of each shown column
+	frozen      int   // leading headers that never scroll
+	offset      int   // first scrolled header shown
+	hiddenLeft  int   // scrolled headers before offset
+	hiddenRight int   // headers after the last shown column
+}
+
+// contentColumnWidths sizes every header from its title and the widest of the
+// first columnWidthSample rows.
+func contentColumnWidths(headers []string, data [][]string) []int {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = lipgloss.Width(h) + headerMarksWidth
+	}
+	for _, r := range data[:min(len(data), columnWidthSample)] {
+		for i := 0; i < len(r) && i < len(widths); i++ {
+			if w := lipgloss.Width(r[i]); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	for i := range widths {
+		widths[i] = clamp(widths[i], minScrollColWidth, maxScrollColWidth)
+	}
+	return widths
+}
+
+// computeScrollLayout fits the frozen headers and as many headers from offset as
+// totalWidth allows. When headers remain on the right it keeps room for the
+// "(+N)" indicator column. At least one scrolled column is always shown, cut to
+// the remaining width if needed. totalWidth <= 0 defaults to 80.
+func computeScrollLayout(widths []int, frozen, offset, totalWidth int) scrollLayout {
+	if totalWidth <= 0 {
+		totalWidth = 80
+	}
+	n := len(widths)
+	frozen = clamp(frozen, 0, max(n-1, 0))
+	offset = clamp(offset, frozen, max(n-1, frozen))
+	l := scrollLayout{frozen: frozen, offset: offset, hiddenLeft: offset - frozen}
+	if n == 0 {
+		return l
+	}
+	used := 0
+	add := func(i, w int) {
+		l.visible = append(l.visible, i)
+		l.widths = append(l.widths, w)
+		used += w + cellPadding
+	}
+	for i := 0; i < frozen; i++ {
+		add(i, min(widths[i], max(totalWidth-used-cellPadding, 1)))
+	}
+	indicator := len("(+99)") + cellPadding
+	last := offset - 1
+	for i := offset; i < n; i++ {
+		room := totalWidth - used
+		if i < n-1 {
+			room -= indicator
+		}
+		if widths[i]+cellPadding > room {
+			break
+		}
+		add(i, widths[i])
+		last = i
+	}
+	if last < offset && offset < n { // nothing scrolled fits: cut the first one
+		room := totalWidth - used - cellPadding
+		if offset < n-1 {
+			room -= indicator
+		}
+		add(offset, max(room, 1))
+		last = offset
+	}
+	l.hiddenRight = n - 1 - last
+	return l
+}
+
+// scrolledLeftMark prefixes the first scrolled column title when columns are
+// scrolled off to the left.
+const scrolledLeftMark = "‹"
+
+// frozenColumns returns how many leading headers stay put while scrolling:
+// timestamp, and message too when frozen with 'M'.
+func (m model) frozenColumns(headers []string) int {
+	if len(headers) == 0 || !strings.EqualFold(headers[0], "timestamp") {
+		return 0
+	}
+	if m.tblFreezeMsg && len(headers) > 1 && strings.EqualFold(headers[1], "message") {
+		return 2
+	}
+	return 1
+}
+
+// scrollToColumnCursor adjusts the horizontal scroll so the column under the
+// cursor is shown, without leaving free space on the right, and returns the layout.
+func (m *model) scrollToColumnCursor(widths []int, frozen, width int) scrollLayout {
+	shows := func(l scrollLayout) bool { return m.tblCol < frozen || slices.Contains(l.visible, m.tblCol) }
+	if m.tblCol >= frozen && m.tblCol < m.tblColOff {
+		m.tblColOff = m.tblCol
+	}
+	l := computeScrollLayout(widths, frozen, m.tblColOff, width)
+	for !shows(l) && l.offset < m.tblCol {
+		l = computeScrollLayout(widths, frozen, l.offset+1, width)
+	}
+	for l.offset > frozen && l.hiddenRight == 0 {
+		prev := computeScrollLayout(widths, frozen, l.offset-1, width)
+		if prev.hiddenRight > 0 || !shows(prev) {
+			break
+		}
+		l = prev
+	}
+	m.tblColOff = l.offset
+	return l
+}
+
+// toggleFreezeMessage freezes or unfreezes the message column next to timestamp.
+func (m *model) toggleFreezeMessage() {
+	m.tblFreezeMsg = !m.tblFreezeMsg
+	m.refreshTable(m.rowIndex(m.tbl.Cursor()))
+	if m.tblFreezeMsg {
+		m.statusNote = "message column frozen"
+	} else {
+		m.statusNote = "message column scrolls"
+	}
+}
@@ -0,0 +1,544 @@
+package tui
+
+// Editor completion: Tab or Ctrl+Space in the multi-line editor offers tables,
+// operators, functions, columns and customDimensions keys for the word at the
+// cursor. What is offered depends on the pipe stage the cursor is in: a source at
+// the start of a statement or subquery, an operator right after '|', and columns
+// and functions inside an operator. customDimensions keys and result columns are
+// remembered per app from earlier results.
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/FBakkensen/bc-insights-tui/internal/kql"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+const (
+	completionMaxVisible = 8   // popup rows before it scrolls
+	completionVocabMax   = 500 // remembered columns/keys per app
+)
+
+var (
+	completionBoxStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("63"))
+	completionSelStyle = lipgloss.NewStyle().Reverse(true)
+)
+
+// completionKind labels an item in the popup.
+type completionKind int
+
+const (
+	complTable completionKind = iota
+	complOperator
+	complFunction
+	complColumn
+	complKey
+	complKeyword
+)
+
+func (k completionKind) String() string {
+	switch k {
+	case complTable:
+		return "table"
+	case complOperator:
+		return "operator"
+	case complFunction:
+		return "function"
+	case complColumn:
+		return "column"
+	case complKey:
+		return "key"
+	default:
+		return "keyword"
+	}
+}
+
+type completionItem struct {
+	label  string // shown and matched against the prefix
+	insert string // replaces the prefix when accepted
+	kind   completionKind
+}
+
+// completionState is the open popup; the zero value is closed.
+type completionState struct {
+	active bool
+	prefix string // the word before the cursor that an accepted item replaces
+	items  []completionItem
+	sel    int
+}
+
+// completionVocab holds names seen in the results of one app.
+type completionVocab struct {
+	columns []string
+	keys    []string // customDimensions keys
+}
+
+// completionScope is what belongs at the cursor.
+type completionScope int
+
+const (
+	scopeNone       completionScope = iota
+	scopeSource                     // a table or let name
+	scopeOperator                   // a tabular operator after '|'
+	scopeExpression                 // columns, functions and keywords inside an operator
+	scopeKey                        // customDimensions.<key>
+)
+
+func (s completionScope) String() string {
+	return [...]string{"none", "source", "operator", "expression", "key"}[s]
+}
+
+// completionContext describes the cursor position for completion.
+type completionContext struct {
+	prefix    string
+	scope     completionScope
+	statement bool     // scopeSource at the start of a top-level statement
+	source    string   // table (or let name) the current expression reads from
+	op        string   // lowercase operator of the current stage, for scopeExpression
+	lets      []string // names bound by earlier let statements
+	names     []string // columns introduced by assignments (extend x = …) before the cursor
+}
+
+// completionFrame tracks one bracket level while scanning tokens.
+type completionFrame struct {
+	first    int  // index of the first token inside the frame
+	start    int  // index of the first token of the current stage
+	pipes    int  // pipes seen in this frame
+	subquery bool // the frame holds a tabular expression (top level, join (…), union (…))
+}
+
+// subqueryOpeners are words whose '(' starts a tabular expression.
+var subqueryOpeners = map[string]bool{"join": true, "lookup": true, "union": true, "toscalar": true, "materialize": true}
+
+// editorIndent is what Tab inserts where there is nothing to complete.
+const editorIndent = "    "
+
+// tabCompletes reports whether Tab should complete the word at the cursor
+// rather than indent: not at the start of a line, and not where nothing
+// completes (strings, comments, numbers). Ctrl+Space always completes.
+func tabCompletes(before string) bool {
+	line := before[strings.LastIndexByte(before, '\n')+1:]
+	if strings.TrimSpace(line) == "" {
+		return false
+	}
+	return analyzeCompletion(before).scope != scopeNone
+}
+
+// analyzeCompletion inspects the text before the cursor.
+// nolint:gocyclo // One branch per stage shape; covered by ui_completion_test.go.
+func analyzeCompletion(before string) completionContext {
+	prefix := wordBeforeCursor(before)
+	ctx := completionContext{prefix: prefix}
+	if prefix != "" && unicode.IsDigit([]rune(prefix)[0]) {
+		return ctx
+	}
+	rest := before[:len(before)-len(prefix)]
+	tokens, err := kql.Lex(rest)
+	if err != nil {
+		return ctx // inside an unterminated string
+	}
+	code := make([]kql.Token, 0, len(tokens))
+	for i, t := range tokens {
+		if t.Kind == kql.Comment {
+			if i == len(tokens)-1 && !strings.HasSuffix(rest, "\n") {
+				return ctx // inside a comment
+			}
+			continue
+		}
+		code = append(code, t)
+	}
+	frames := []completionFrame{{subquery: true}}
+	for i, t := range code {
+		f := &frames[len(frames)-1]
+		switch {
+		case t.Kind == kql.LeftBracket:
+			// join kind=inner (…) and union (…), (…) hold subqueries too
+			sub := t.Is("(") && ((i > 0 && subqueryOpeners[strings.ToLower(code[i-1].Text)]) ||
+				(f.start < i && subqueryOpeners[strings.ToLower(code[f.start].Text)]))
+			frames = append(frames, completionFrame{first: i + 1, start: i + 1, subquery: sub})
+		case t.Kind == kql.RightBracket:
+			if len(frames) > 1 {
+				frames = frames[:len(frames)-1]
+			}
+		case t.Is("|"):
+			f.start, f.pipes = i+1, f.pipes+1
+		case t.Is(";") && len(frames) == 1:
+			frames[0] = completionFrame{first: i + 1, start: i + 1, subquery: true}
+		case t.IsWord("let") && (i == 0 || code[i-1].Is(";")) && i+1 < len(code):
+			ctx.lets = append(ctx.lets, code[i+1].Text)
+		case t.Is("=") && i > 0 && code[i-1].Kind == kql.Ident && (i < 2 || !code[i-2].IsWord("let")):
+			ctx.names = append(ctx.names, code[i-1].Text)
+		}
+	}
+	f := frames[len(frames)-1]
+	ctx.source = frameSource(code[f.first:])
+	stage := code[f.start:]
+
+	if i := strings.LastIndex(prefix, "."); i >= 0 {
+		if strings.EqualFold(prefix[:i], "customDimensions") {
+			ctx.scope = scopeKey
+		}
+		return ctx
+	}
+	switch {
+	case len(stage) == 0 && f.pipes > 0:
+		ctx.scope = scopeOperator
+	case len(stage) == 0 && f.subquery:
+		ctx.scope = scopeSource
+		ctx.statement = len(frames) == 1
+	case len(stage) == 0:
+		ctx.scope = scopeExpression
+	case f.pipes == 0 && f.subquery:
+		ctx.scope = sourceStageScope(stage)
+	default:
+		ctx.op = strings.ToLower(stage[0].Text)
+		ctx.scope = scopeExpression
+		last := stage[len(stage)-1]
+		switch ctx.op {
+		case "join", "lookup", "union":
+			// join kind=inner <table> on …; union <table>, <table>
+			if len(stage) == 1 || last.Is(",") || (len(stage) > 2 && stage[len(stage)-2].Is("=") && !containsWord(stage, "on")) {
+				ctx.scope = scopeSource
+			}
+		}
+	}
+	return ctx
+}
+
+// sourceStageScope handles the first stage of a statement that already has tokens:
+// let bindings, union lists and print expressions.
+func sourceStageScope(stage []kql.Token) completionScope {
+	first, last := stage[0], stage[len(stage)-1]
+	switch {
+	case first.IsWord("let"):
+		if last.Is("=") {
+			return scopeSource
+		}
+		for _, t := range stage {
+			if t.Is("=") {
+				return scopeExpression
+			}
+		}
+	case first.IsWord("union"):
+		if len(stage) == 1 || last.Is(",") {
+			return scopeSource
+		}
+	case first.IsWord("print"), first.IsWord("range"):
+		return scopeExpression
+	}
+	return scopeNone
+}
+
+// frameSource returns the table a tabular expression reads from: its first word,
+// or the value of a let binding.
+func frameSource(tokens []kql.Token) string {
+	if len(tokens) > 0 && tokens[0].IsWord("let") {
+		for i, t := range tokens {
+			if t.Is("=") && i+1 < len(tokens) {
+				return tokens[i+1].Text
+			}
+		}
+		return ""
+	}
+	if len(tokens) > 0 && tokens[0].Kind == kql.Ident {
+		return tokens[0].Text
+	}
+	return ""
+}
+
+func containsWord(tokens []kql.Token, w string) bool {
+	for _, t := range tokens {
+		if t.IsWord(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordBeforeCursor returns the identifier-like text ending at the cursor,
+// including dots (customDimensions.eventId) and hyphens (project-away).
+func wordBeforeCursor(before string) string {
+	r := []rune(before)
+	i := len(r)
+	for i > 0 && (unicode.IsLetter(r[i-1]) || unicode.IsDigit(r[i-1]) || strings.ContainsRune("_.-", r[i-1])) {
+		i--
+	}
+	return strings.TrimLeft(string(r[i:]), ".-")
+}
+
+// textBeforeCursor returns the textarea value up to the cursor.
+func textBeforeCursor(ta textarea.Model) string {
+	lines := strings.Split(ta.Value(), "\n")
+	row, col := textareaCursor(ta)
+	if row >= len(lines) {
+		return ta.Value()
+	}
+	line := []rune(lines[row])
+	col = min(col, len(line))
+	return strings.Join(append(lines[:row:row], string(line[:col])), "\n")
+}
+
+// expressionKeywords are offered inside operators, by operator.
+var expressionKeywords = map[string][]string{
+	"where":       {"and", "or", "not", "in", "has", "contains", "startswith", "endswith", "between", "matches regex"},
+	"summarize":   {"by"},
+	"make-series": {"on", "from", "to", "step", "by"},
+	"order":       {"by", "asc", "desc"},
+	"sort":        {"by", "asc", "desc"},
+	"top":         {"by", "asc", "desc"},
+	"join":        {"kind", "on"},
+	"lookup":      {"kind", "on"},
+}
+
+// completionItems lists what fits ctx, filtered by its prefix (case-insensitive).
+// nolint:gocyclo // One list per scope; each is a simple append loop.
+func (m model) completionItems(ctx completionContext) []completionItem {
+	var items []completionItem
+	add := func(kind completionKind, label, insert string) {
+		items = append(items, completionItem{label: label, insert: insert, kind: kind})
+	}
+	vocab := m.completionVocab[strings.TrimSpace(m.cfg.ApplicationInsightsID)]
+	switch ctx.scope {
+	case scopeSource:
+		for _, n := range ctx.lets {
+			add(complTable, n, n)
+		}
+		for _, t := range kql.Tables {
+			add(complTable, t, t)
+		}
+		if ctx.statement {
+			for _, k := range kql.SourceKeywords {
+				add(complKeyword, k, k+" ")
+			}
+		}
+	case scopeOperator:
+		for _, op := range kql.Operators {
+			if op == "order" || op == "sort" {
+				add(complOperator, op+" by", op+" by ")
+				continue
+			}
+			add(complOperator, op, op+" ")
+		}
+	case scopeKey:
+		if vocab != nil {
+			for _, k := range vocab.keys {
+				add(complKey, dimensionAccessor(k), dimensionAccessor(k))
+			}
+		}
+	case scopeExpression:
+		for _, n := range ctx.names {
+			add(complColumn, n, n)
+		}
+		for _, c := range kql.TableColumns(ctx.source) {
+			add(complColumn, c, c)
+		}
+		if vocab != nil {
+			for _, c := range vocab.columns {
+				add(complColumn, c, c)
+			}
+			for _, k := range vocab.keys {
+				add(complKey, dimensionAccessor(k), dimensionAccessor(k))
+			}
+		}
+		if ctx.op == "summarize" || ctx.op == "make-series" {
+			for _, f := range kql.Aggregations {
+				add(complFunction, f+"()", f+"(")
+			}
+		}
+		for _, f := range kql.Functions {
+			add(complFunction, f+"()", f+"(")
+		}
+		for _, k := range expressionKeywords[ctx.op] {
+			add(complKeyword, k, k+" ")
+		}
+	}
+	return filterCompletions(items, ctx.prefix)
+}
+
+// filterCompletions keeps items whose label starts with prefix, dropping repeated labels.
+func filterCompletions(items []completionItem, prefix string) []completionItem {
+	lp := strings.ToLower(prefix)
+	seen := make(map[string]bool, len(items))
+	out := items[:0]
+	for _, it := range items {
+		l := strings.ToLower(it.label)
+		if seen[l] || !strings.HasPrefix(l, lp) {
+			continue
+		}
+		seen[l] = true
+		out = append(out, it)
+	}
+	return out
+}
+
+// dimensionAccessor returns KQL for a customDimensions key; keys that are not
+// identifiers need the bracket form.
+func dimensionAccessor(key string) string {
+	for i, r := range key {
+		if !(unicode.IsLetter(r) || r == '_' || (i > 0 && unicode.IsDigit(r))) {
+			return "customDimensions['" + strings.ReplaceAll(key, "'", "\\'") + "']"
+		}
+	}
+	return "customDimensions." + key
+}
+
+// triggerCompletion handles Tab/Ctrl+Space: a single match is inserted directly,
+// several open the popup.
+func (m *model) triggerCompletion() {
+	ctx := analyzeCompletion(textBeforeCursor(m.ta))
+	items := m.completionItems(ctx)
+	logging.Debug("Completion requested",
+		"scope", ctx.scope.String(),
+		"prefix_len", fmt.Sprintf("%d", len(ctx.prefix)),
+		"items", fmt.Sprintf("%d", len(items)),
+	)
+	m.completion = completionState{prefix: ctx.prefix, items: items}
+	switch len(items) {
+	case 0:
+		return
+	case 1:
+		m.acceptCompletion()
+	default:
+		m.completion.active = true
+	}
+}
+
+// refreshCompletion re-filters the open popup after the buffer changed; it closes
+// when the word at the cursor is gone or nothing matches.
+func (m *model) refreshCompletion() {
+	ctx := analyzeCompletion(textBeforeCursor(m.ta))
+	items := m.completionItems(ctx)
+	if ctx.prefix == "" || len(items) == 0 {
+		m.completion = completionState{}
+		return
+	}
+	m.completion = completionState{active: true, prefix: ctx.prefix, items: items}
+}
+
+// acceptCompletion replaces the prefix with the selected item.
+func (m *model) acceptCompletion() {
+	c := m.completion
+	m.completion = completionState{}
+	if c.sel < 0 || c.sel >= len(c.items) {
+		return
+	}
+	before := textBeforeCursor(m.ta)
+	after := strings.TrimPrefix(m.ta.Value(), before)
+	head := strings.TrimSuffix(before, c.prefix) + c.items[c.sel].insert
+	m.ta.SetValue(head + after)
+	moveTextareaCursor(&m.ta, strings.Count(head, "\n"), len([]rune(head[strings.LastIndex(head, "\n")+1:])))
+	m.clearEditorError()
+}
+
+// handleCompletionKey navigates the open popup; other keys go to the editor.
+func (m model) handleCompletionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	switch msg.String() {
+	case "esc":
+		m.completion = completionState{}
+	case "up", "ctrl+p":
+		m.completion.sel = (m.completion.sel - 1 + len(m.completion.items)) % len(m.completion.items)
+	case "down", "ctrl+n":
+		m.completion.sel = (m.completion.sel + 1) % len(m.completion.items)
+	case "tab", "enter":
+		m.acceptCompletion()
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// completionPopup renders the open popup, at most width columns wide.
+func (m model) completionPopup(width int) string {
+	c := m.completion
+	start := max(0, c.sel-completionMaxVisible+1)
+	end := min(len(c.items), start+completionMaxVisible)
+	labelW := 0
+	for _, it := range c.items[start:end] {
+		labelW = max(labelW, len([]rune(it.label)))
+	}
+	labelW = max(1, min(labelW, width-14))
+	lines := make([]string, 0, end-start+1)
+	for i := start; i < end; i++ {
+		it := c.items[i]
+		line := fmt.Sprintf("%-*s  %-8s", labelW, truncateRunes(it.label, labelW), it.kind)
+		if i == c.sel {
+			line = completionSelStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	footer := fmt.Sprintf("%d/%d · Tab/Enter insert · Esc close", c.sel+1, len(c.items))
+	lines = append(lines, statusStyle.Render(truncateRunes(footer, max(labelW+10, width-2))))
+	return completionBoxStyle.Render(strings.Join(lines, "\n"))
+}
+
+// overlayBottom draws popup over the last lines of base.
+func overlayBottom(base, popup string) string {
+	lines := strings.Split(base, "\n")
+	pop := strings.Split(popup, "\n")
+	if len(pop) > len(lines) {
+		pop = pop[len(pop)-len(lines):]
+	}
+	copy(lines[len(lines)-len(pop):], pop)
+	return strings.Join(lines, "\n")
+}
+
+// rememberCompletionNames records the columns and customDimensions keys of the
+// current result tables for completion in later queries against appID.
+func (m *model) rememberCompletionNames(appID string) {
+	appID = strings.TrimSpace(appID)
+	if appID == "" || len(m.lastTables) == 0 {
+		return
+	}
+	if m.completionVocab == nil {
+		m.completionVocab = make(map[string]*completionVocab)
+	}
+	v := m.completionVocab[appID]
+	if v == nil {
+		v = &completionVocab{}
+		m.completionVocab[appID] = v
+	}
+	for i, t := range m.lastTables {
+		headers := t.headers
+		if i == m.activeTable {
+			headers = m.lastDisplayHeaders
+		}
+		isColumn := make(map[string]bool, len(t.columns))
+		var cols []string
+		for _, c := range t.columns {
+			isColumn[strings.ToLower(c.Name)] = true
+			cols = append(cols, c.Name)
+		}
+		var keys []string
+		for _, h := range headers {
+			if !isColumn[strings.ToLower(h)] {
+				keys = append(keys, h)
+			}
+		}
+		v.columns = mergeNames(v.columns, cols)
+		v.keys = mergeNames(v.keys, keys)
+	}
+}
+
+// mergeNames appends names not yet in list (case-insensitive), up to completionVocabMax.
+func mergeNames(list, names []string) []string {
+	seen := make(map[string]bool, len(list))
+	for _, n := range list {
+		seen[strings.ToLower(n)] = true
+	}
+	for _, n := range names {
+		if len(list) >= completionVocabMax {
+			break
+		}
+		if l := strings.ToLower(n); n != "" && !seen[l] {
+			seen[l] = true
+			list = append(list, n)
+		}
+	}
+	return list
+}
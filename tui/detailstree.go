@@ -0,0 +1,328 @@
+package tui
+
+// The details view shows customDimensions as a tree: objects, arrays and JSON
+// held in string values expand and collapse in place, at any depth. Children are
+// decoded only when a node is first expanded. The status line shows the path of
+// the selected node. [ and ] step to the previous and next row of the table,
+// { and } jump to the first and last.
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/internal/telemetry"
+	"github.com/FBakkensen/bc-insights-tui/internal/util"
+)
+
+const (
+	treeCollapsedMark = "▸ "
+	treeExpandedMark  = "▾ "
+	treeLeafMark      = "  "
+)
+
+var detailsSelStyle = lipgloss.NewStyle().Reverse(true)
+
+// detailsTree is the state of the details view for one row.
+type detailsTree struct {
+	head      []string // lines above the customDimensions tree
+	root      *telemetry.TreeNode
+	parseWarn bool
+	expanded  map[string]bool       // expanded node paths; kept when another row is shown
+	nodes     []*telemetry.TreeNode // shown nodes, in order
+	sel       int
+}
+
+// newDetailsTree builds the tree for a row, keeping the expanded paths of the previous one.
+func newDetailsTree(table string, rowIdx int, timestamp, message string, columns []appinsights.Column, row []interface{}, expanded map[string]bool) detailsTree {
+	if expanded == nil {
+		expanded = map[string]bool{}
+	}
+	t := detailsTree{expanded: expanded}
+	t.head = append(t.head, fmt.Sprintf("Details — %s · row %d", util.FirstNonEmpty(table, "PrimaryResult"), rowIdx))
+	if strings.TrimSpace(timestamp) != "" {
+		t.head = append(t.head, "timestamp: "+timestamp)
+	}
+	if strings.TrimSpace(message) != "" {
+		t.head = append(t.head, "message: "+message)
+	}
+	t.head = append(t.head, "customDimensions:")
+	for i, c := range columns {
+		if strings.EqualFold(strings.TrimSpace(c.Name), "customDimensions") && i < len(row) && row[i] != nil {
+			t.root, t.parseWarn = telemetry.NewTree("customDimensions", row[i])
+			break
+		}
+	}
+	if t.parseWarn {
+		t.head = append(t.head, "  (parse_warning): customDimensions is not valid JSON; showing raw string")
+	}
+	t.rebuild()
+	return t
+}
+
+// rebuild lists the shown nodes: the root's children and, below every expanded
+// node, its own children.
+func (t *detailsTree) rebuild() {
+	t.nodes = t.nodes[:0]
+	if t.root == nil {
+		return
+	}
+	if t.parseWarn {
+		t.nodes = append(t.nodes, t.root)
+		return
+	}
+	var walk func(ns []*telemetry.TreeNode)
+	walk = func(ns []*telemetry.TreeNode) {
+		for _, n := range ns {
+			t.nodes = append(t.nodes, n)
+			if t.expanded[n.Path] && n.Expandable() {
+				walk(n.Children())
+			}
+		}
+	}
+	if t.root.Expandable() {
+		walk(t.root.Children())
+	}
+	t.sel = clamp(t.sel, 0, max(len(t.nodes)-1, 0))
+}
+
+// selected returns the node under the cursor, or nil.
+func (t detailsTree) selected() *telemetry.TreeNode {
+	if t.sel < 0 || t.sel >= len(t.nodes) {
+		return nil
+	}
+	return t.nodes[t.sel]
+}
+
+// selectPath moves the cursor to the node at path when it is shown.
+func (t *detailsTree) selectPath(path string) bool {
+	for i, n := range t.nodes {
+		if n.Path == path {
+			t.sel = i
+			return true
+		}
+	}
+	return false
+}
+
+// expand opens the selected node; on an open node it moves to the first child.
+func (t *detailsTree) expand() {
+	n := t.selected()
+	if n == nil || !n.Expandable() {
+		return
+	}
+	if t.expanded[n.Path] {
+		if len(n.Children()) > 0 {
+			t.sel++
+		}
+		return
+	}
+	t.expanded[n.Path] = true
+	t.rebuild()
+}
+
+// collapse closes the selected node; on a closed node it moves to the parent.
+func (t *detailsTree) collapse() {
+	n := t.selected()
+	if n == nil {
+		return
+	}
+	if t.expanded[n.Path] {
+		delete(t.expanded, n.Path)
+		t.rebuild()
+		return
+	}
+	for i := t.sel - 1; i >= 0; i-- {
+		if t.nodes[i].Depth < n.Depth {
+			t.sel = i
+			return
+		}
+	}
+}
+
+// toggle opens a closed node and closes an open one.
+func (t *detailsTree) toggle() {
+	if n := t.selected(); n != nil && t.expanded[n.Path] {
+		t.collapse()
+		return
+	}
+	t.expand()
+}
+
+// expandAll opens the selected node and everything below it.
+func (t *detailsTree) expandAll() {
+	n := t.selected()
+	if n == nil {
+		return
+	}
+	var walk func(n *telemetry.TreeNode)
+	walk = func(n *telemetry.TreeNode) {
+		if !n.Expandable() {
+			return
+		}
+		t.expanded[n.Path] = true
+		for _, c := range n.Children() {
+			walk(c)
+		}
+	}
+	walk(n)
+	t.rebuild()
+}
+
+// collapseAll closes every node and selects the top-level node above the cursor.
+func (t *detailsTree) collapseAll() {
+	var top string
+	if n := t.selected(); n != nil {
+		top = n.Path
+		for i := t.sel; i >= 0; i-- {
+			if t.nodes[i].Depth == 1 {
+				top = t.nodes[i].Path
+				break
+			}
+		}
+	}
+	clear(t.expanded)
+	t.rebuild()
+	t.selectPath(top)
+}
+
+// lines renders the header and one line per shown node, fitting previews of
+// closed nodes to width; width 0 keeps them whole.
+func (t detailsTree) lines(width int) []string {
+	out := append([]string(nil), t.head...)
+	if len(t.nodes) == 0 {
+		return append(out, "  <none>")
+	}
+	for _, n := range t.nodes {
+		indent := strings.Repeat("  ", n.Depth)
+		key := n.Key
+		if t.parseWarn {
+			key, indent = "raw", "  "
+		}
+		mark := treeLeafMark
+		value := ""
+		switch {
+		case !n.Expandable():
+			value = n.Summary()
+		case t.expanded[n.Path]:
+			mark, value = treeExpandedMark, n.Summary()
+		default:
+			mark = treeCollapsedMark
+			value = n.Preview(0)
+			if width > 0 {
+				prefix := len([]rune(indent+mark+key)) + 2
+				value = n.Preview(max(width-prefix, 20))
+			}
+		}
+		out = append(out, indent+mark+key+": "+oneLine(value))
+	}
+	return out
+}
+
+// oneLine keeps a value on its tree line.
+func oneLine(s string) string {
+	if !strings.ContainsAny(s, "\r\n") {
+		return s
+	}
+	return strings.NewReplacer("\r\n", "↵", "\n", "↵", "\r", "↵").Replace(s)
+}
+
+// renderDetailsTree refreshes the details viewport, highlights the selected
+// node and scrolls it into view. detailsContent keeps the untruncated text for copying.
+func (m *model) renderDetailsTree() {
+	t := m.details
+	lines := t.lines(m.detailsVP.Width)
+	m.detailsContent = strings.Join(t.lines(0), "\n") + "\n"
+	line := -1
+	if len(t.nodes) > 0 {
+		line = len(t.head) + t.sel
+		lines[line] = detailsSelStyle.Render(lines[line])
+	}
+	m.detailsVP.SetContent(strings.Join(lines, "\n"))
+	if line < 0 {
+		return
+	}
+	h := max(m.detailsVP.Height, 1)
+	switch {
+	case t.sel == 0:
+		m.detailsVP.GotoTop() // keep the header in view at the top
+	case line < m.detailsVP.YOffset:
+		m.detailsVP.SetYOffset(line)
+	case line >= m.detailsVP.YOffset+h:
+		m.detailsVP.SetYOffset(line - h + 1)
+	}
+}
+
+// detailsPath is the breadcrumb of the selected node for the status line.
+func (m model) detailsPath() string {
+	if n := m.details.selected(); n != nil {
+		return "path: " + n.Path
+	}
+	return ""
+}
+
+// moveDetailsCursor moves the tree selection by delta nodes, clamped.
+func (m *model) moveDetailsCursor(delta int) {
+	m.details.sel = clamp(m.details.sel+delta, 0, max(len(m.details.nodes)-1, 0))
+}
+
+// detailsTablePos returns the table position of the shown row, or -1 when the
+// row is filtered out or was trimmed by tail (detailsRow is then -1).
+func (m model) detailsTablePos() int {
+	return slices.Index(m.shownRows(), m.detailsRow)
+}
+
+// stepDetailsRow shows the details of the row delta positions away in table order.
+func (m *model) stepDetailsRow(delta int) {
+	pos := m.detailsTablePos()
+	if pos < 0 {
+		pos = m.tbl.Cursor()
+	}
+	m.showDetailsAt(pos + delta)
+}
+
+// showDetailsAt shows the details of the table row at pos, clamped to the
+// table. The tree selection, expanded nodes and scroll position carry over, and
+// the table cursor follows so Esc returns to the row shown.
+func (m *model) showDetailsAt(pos int) {
+	shown := m.shownRows()
+	if len(shown) == 0 {
+		return
+	}
+	next := clamp(pos, 0, len(shown)-1)
+	if cur := m.detailsTablePos(); next == cur {
+		if pos < cur || (pos == cur && cur == 0) {
+			m.statusNote = "first row"
+		} else {
+			m.statusNote = "last row"
+		}
+		return
+	}
+	path := ""
+	if n := m.details.selected(); n != nil {
+		path = n.Path
+	}
+	sel, yOffset := m.details.sel, m.detailsVP.YOffset
+	m.openDetails(shown[next])
+	if !m.details.selectPath(path) {
+		m.details.sel = clamp(sel, 0, max(len(m.details.nodes)-1, 0))
+	}
+	m.detailsVP.SetYOffset(yOffset)
+	m.renderDetailsTree()
+	m.tbl.SetCursor(next)
+}
+
+// detailsRowStatus gives the shown row's table position for the status line.
+func (m model) detailsRowStatus() string {
+	pos := m.detailsTablePos()
+	switch {
+	case m.detailsRow < 0:
+		return "row dropped by tail"
+	case pos < 0:
+		return ""
+	}
+	return fmt.Sprintf("row %d of %d", pos+1, len(m.shownRows()))
+}
@@ -0,0 +1,187 @@
+package tui
+
+// Result export: 'export <format> <path> [display] [flatten]' and Ctrl+S in the
+// results table write the active result table to a file. By default the raw API
+// columns are written; 'display' writes the ranked headers shown in the table,
+// and 'flatten' spreads customDimensions into one column per key.
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/internal/export"
+	"github.com/FBakkensen/bc-insights-tui/internal/telemetry"
+	"github.com/FBakkensen/bc-insights-tui/internal/util"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+const exportUsage = "Usage: export <csv|json|ndjson|md|xlsx> <path> [display] [flatten]"
+
+// exportOptions selects which columns an export writes.
+type exportOptions struct {
+	display bool // ranked display headers instead of the raw API columns
+	flatten bool // customDimensions keys as columns (raw columns only)
+}
+
+// handleExportCommand parses 'export' arguments and writes the active result table.
+func (m model) handleExportCommand(args string) (tea.Model, tea.Cmd) {
+	parts := splitArgs(args)
+	if len(parts) < 2 {
+		m.append(exportUsage)
+		return m, nil
+	}
+	format, err := export.ParseFormat(parts[0])
+	if err != nil || format == export.FormatTable {
+		m.append(fmt.Sprintf("Unknown export format %q. %s", parts[0], exportUsage))
+		return m, nil
+	}
+	var opts exportOptions
+	for _, p := range parts[2:] {
+		switch strings.ToLower(p) {
+		case "display":
+			opts.display = true
+		case "raw":
+			opts.display = false
+		case "flatten":
+			opts.flatten = true
+		default:
+			m.append(fmt.Sprintf("Unknown export option %q. %s", p, exportUsage))
+			return m, nil
+		}
+	}
+	m.exportResults(format, parts[1], opts)
+	return m, nil
+}
+
+// exportTableView writes the table as displayed to a timestamped XLSX file in
+// the working directory (Ctrl+S in the results table).
+func (m *model) exportTableView() {
+	name := fmt.Sprintf("bc-insights-%s-%s.xlsx", strings.ToLower(util.FirstNonEmpty(m.lastTable, primaryResultName)), time.Now().Format("20060102-150405"))
+	m.exportResults(export.FormatXLSX, name, exportOptions{display: true})
+}
+
+// exportResults writes the active result table to path and reports the outcome.
+func (m *model) exportResults(format export.Format, path string, opts exportOptions) {
+	if !m.haveResults || len(m.lastColumns) == 0 {
+		m.append("No results to export. Run a query first.")
+		return
+	}
+	t := m.exportTable(opts)
+	if err := writeExportFile(path, format, t); err != nil {
+		logging.Error("Export failed", "format", string(format), "error", err.Error())
+		m.append("Export failed: " + err.Error())
+		return
+	}
+	abs, absErr := filepath.Abs(path)
+	if absErr != nil {
+		abs = path
+	}
+	logging.Info("Results exported",
+		"format", string(format),
+		"rows", fmt.Sprintf("%d", len(t.Rows)),
+		"columns", fmt.Sprintf("%d", len(t.Columns)),
+		"display", fmt.Sprintf("%t", opts.display),
+		"flatten", fmt.Sprintf("%t", opts.flatten),
+	)
+	m.append(fmt.Sprintf("Exported %d rows × %d columns (%s) to %s", len(t.Rows), len(t.Columns), format, abs))
+}
+
+// exportTable builds the table to write from the active results.
+func (m *model) exportTable(opts exportOptions) export.Table {
+	if opts.display {
+		headers := m.lastDisplayHeaders
+		if len(headers) == 0 {
+			headers = computeRankedHeaders(m.lastColumns, m.lastRows, m.cfg)
+		}
+		headers, data := buildDisplayMatrixFromHeaders(headers, m.lastColumns, m.lastRows)
+		rows := make([][]interface{}, 0, len(data))
+		for _, i := range m.shownRows() { // in the table's order, without filtered rows
+			r := data[i]
+			row := make([]interface{}, len(r))
+			for i, v := range r {
+				row[i] = v
+			}
+			rows = append(rows, row)
+		}
+		return export.Table{Columns: headers, Rows: rows}
+	}
+	if opts.flatten {
+		return flattenCustomDimensions(m.lastColumns, m.lastRows)
+	}
+	cols := make([]string, len(m.lastColumns))
+	for i, c := range m.lastColumns {
+		cols[i] = c.Name
+	}
+	return export.Table{Columns: cols, Rows: m.lastRows}
+}
+
+// flattenCustomDimensions replaces the customDimensions column with one
+// "customDimensions.<key>" column per key seen in any row.
+func flattenCustomDimensions(columns []appinsights.Column, rows [][]interface{}) export.Table {
+	customIdx := -1
+	var cols []string
+	for i, c := range columns {
+		if strings.EqualFold(c.Name, "customDimensions") {
+			customIdx = i
+			continue
+		}
+		cols = append(cols, c.Name)
+	}
+	if customIdx < 0 {
+		return export.Table{Columns: cols, Rows: rows}
+	}
+	var keys []string
+	for _, k := range discoverCanonicalKeys(columns, rows) {
+		if !strings.HasPrefix(k, "(") { // skip the parse warning pseudo-field
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range keys {
+		cols = append(cols, "customDimensions."+k)
+	}
+	out := make([][]interface{}, 0, len(rows))
+	for _, r := range rows {
+		row := make([]interface{}, 0, len(cols))
+		for i := range columns {
+			if i == customIdx {
+				continue
+			}
+			var v interface{}
+			if i < len(r) {
+				v = r[i]
+			}
+			row = append(row, v)
+		}
+		_, _, fields := telemetry.BuildDetails(columns, r)
+		fm, fmLower := normalizeFieldMaps(fields)
+		for _, k := range keys {
+			v, ok := fm[k]
+			if !ok {
+				v, ok = fmLower[strings.ToLower(k)]
+			}
+			if ok {
+				row = append(row, v)
+			} else {
+				row = append(row, nil)
+			}
+		}
+		out = append(out, row)
+	}
+	return export.Table{Columns: cols, Rows: out}
+}
+
+// writeExportFile encodes t and replaces path with it only once encoding
+// succeeded, so a failed export leaves an existing file untouched.
+func writeExportFile(path string, format export.Format, t export.Table) error {
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, t); err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, buf.Bytes())
+}
@@ -0,0 +1,167 @@
+package tui
+
+// External editor: 'edit-external [run]' and Ctrl+O in the editor write the query
+// to a temp .kql file and hand the terminal to $VISUAL/$EDITOR. When the editor
+// exits the file is loaded back into the editor and, with 'run', executed.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// execExternalEditor suspends the program while c runs; replaced in tests.
+var execExternalEditor = func(c *exec.Cmd, fn tea.ExecCallback) tea.Cmd {
+	return tea.ExecProcess(c, fn)
+}
+
+// externalEditorMsg reports that the external editor exited.
+type externalEditorMsg struct {
+	path string // temp file holding the edited query
+	run  bool   // execute the query once loaded
+	err  error
+}
+
+// externalEditorCommand returns $VISUAL or $EDITOR split into program and
+// arguments (e.g. "code --wait"), falling back to notepad on Windows and vi elsewhere.
+// A value naming an existing file is the program as is, so unquoted paths with
+// spaces (C:\Program Files\Notepad++\notepad++.exe) work.
+func externalEditorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		value := strings.TrimSpace(os.Getenv(env))
+		if fi, err := os.Stat(value); value != "" && err == nil && !fi.IsDir() {
+			return []string{value}
+		}
+		if args := splitCommandLine(value); len(args) > 0 {
+			return args
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// splitCommandLine splits s at spaces outside single or double quotes and drops
+// the quotes. Backslashes are kept as they are, for Windows paths.
+func splitCommandLine(s string) []string {
+	var (
+		args    []string
+		cur     strings.Builder
+		quote   rune
+		started bool
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, started = r, true
+		case unicode.IsSpace(r):
+			if started {
+				args = append(args, cur.String())
+				cur.Reset()
+				started = false
+			}
+		default:
+			cur.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// handleEditExternalCommand opens the editor buffer, or in chat the most recent
+// query, in the external editor.
+func (m model) handleEditExternalCommand(arg string) (tea.Model, tea.Cmd) {
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	if arg != "" && arg != "run" {
+		m.append("Usage: edit-external [run]")
+		return m, nil
+	}
+	text := m.ta.Value()
+	if m.mode != modeKQLEditor {
+		text = m.lastTemplate
+		if text == "" {
+			if e, ok := m.history.recent(0); ok {
+				text = e.Query
+			}
+		}
+	}
+	cmd := m.openExternalEditor(text, arg == "run")
+	return m, cmd
+}
+
+// openExternalEditor writes text to a temp .kql file and starts the external editor on it.
+func (m *model) openExternalEditor(text string, run bool) tea.Cmd {
+	f, err := os.CreateTemp("", "bc-insights-*.kql")
+	if err != nil {
+		m.append("Cannot open external editor: " + err.Error())
+		return nil
+	}
+	path := f.Name()
+	_, werr := f.WriteString(text)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		_ = os.Remove(path)
+		m.append("Cannot open external editor: " + werr.Error())
+		return nil
+	}
+	args := externalEditorCommand()
+	logging.Info("Opening external editor", "editor", args[0], "run", fmt.Sprintf("%t", run))
+	m.append(fmt.Sprintf("Opening %s… save and close it to return.", args[0]))
+	c := exec.Command(args[0], append(args[1:], path)...)
+	return execExternalEditor(c, func(err error) tea.Msg {
+		return externalEditorMsg{path: path, run: run, err: err}
+	})
+}
+
+// handleExternalEditorResult loads the edited file into the editor and removes it.
+func (m model) handleExternalEditorResult(msg externalEditorMsg) (tea.Model, tea.Cmd) {
+	defer os.Remove(msg.path)
+	if msg.err != nil {
+		var ee *exec.ExitError
+		if errors.As(msg.err, &ee) {
+			m.append(fmt.Sprintf("External editor exited with code %d; query not loaded.", ee.ExitCode()))
+		} else {
+			m.append("External editor failed: " + msg.err.Error() + ". Set VISUAL or EDITOR to your editor command.")
+		}
+		logging.Warn("External editor failed", "error", msg.err.Error())
+		return m, nil
+	}
+	b, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.append("Cannot read the edited query: " + err.Error())
+		return m, nil
+	}
+	text := strings.TrimRight(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	if strings.TrimSpace(text) == "" {
+		m.append("The edited query is empty; nothing loaded.")
+		return m, nil
+	}
+	mAny, cmd := m.enterEditor(text, false)
+	m = mAny.(model)
+	m.clearEditorError()
+	m.scrollEditor()
+	m.append("Loaded the query from the external editor.")
+	if msg.run {
+		mAny, runCmd := m.handleEditorSubmit()
+		return mAny, tea.Batch(cmd, runCmd)
+	}
+	return m, cmd
+}
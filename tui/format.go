@@ -0,0 +1,104 @@
+package tui
+
+// KQL formatting: Shift+Alt+F (or F4) in the editor and the 'format [kql]' chat
+// command lay the query out with kql.Format, one pipe operator per line.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/internal/kql"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// formatEditor formats the editor buffer in place, keeping the cursor on the
+// same character. Syntax errors are reported and marked; the buffer is kept.
+func (m *model) formatEditor() {
+	value := m.ta.Value()
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	formatted, err := kql.Format(value)
+	if err != nil {
+		m.showFormatError(err, value)
+		return
+	}
+	m.completion = completionState{}
+	if formatted == value {
+		m.append("Query is already formatted.")
+		return
+	}
+	// Count the non-space characters before the cursor and find the same spot afterwards
+	cursor := textareaCursorOffset(m.ta)
+	target := 0
+	for _, r := range value[:min(cursor, len(value))] {
+		if !unicode.IsSpace(r) {
+			target++
+		}
+	}
+	newOff := len(formatted)
+	seen := 0
+	for i, r := range formatted {
+		if seen == target && !unicode.IsSpace(r) {
+			newOff = i
+			break
+		}
+		if !unicode.IsSpace(r) {
+			seen++
+		}
+	}
+	m.clearEditorError()
+	m.ta.SetValue(formatted)
+	before := formatted[:newOff]
+	row := strings.Count(before, "\n")
+	col := len([]rune(before[strings.LastIndexByte(before, '\n')+1:]))
+	moveTextareaCursor(&m.ta, row, col)
+	m.scrollEditor()
+	logging.Debug("Formatted editor query", "lines_before", fmt.Sprintf("%d", strings.Count(value, "\n")+1), "lines_after", fmt.Sprintf("%d", row+1))
+	m.append("Formatted query.")
+}
+
+// showFormatError appends why a query could not be formatted.
+func (m *model) showFormatError(err error, query string) {
+	var se *kql.SyntaxError
+	if !errors.As(err, &se) {
+		m.append("Cannot format: " + err.Error())
+		return
+	}
+	m.append(fmt.Sprintf("Cannot format: syntax error: %s (line %d, column %d)", se.Msg, se.Pos.Line, se.Pos.Column))
+	for _, l := range errorLocationLines(query, se.Pos.Line, se.Pos.Column) {
+		m.append(l)
+	}
+	if m.mode == modeKQLEditor {
+		m.markEditorError(se.Pos.Line, se.Pos.Column)
+	}
+}
+
+// handleFormatCommand formats the given query, or the most recent one, and opens
+// the result in the editor.
+func (m model) handleFormatCommand(arg string) (tea.Model, tea.Cmd) {
+	query := strings.TrimSpace(arg)
+	if query == "" {
+		query = m.lastTemplate
+	}
+	if query == "" {
+		if e, ok := m.history.recent(0); ok {
+			query = e.Query
+		}
+	}
+	if query == "" {
+		m.append("Nothing to format. Use 'format <kql>' or run a query first.")
+		return m, nil
+	}
+	formatted, err := kql.Format(query)
+	if err != nil {
+		m.showFormatError(err, query)
+		return m, nil
+	}
+	m.append("Formatted query opened in the editor.")
+	return m.enterEditor(formatted, false)
+}
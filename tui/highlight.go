@@ -0,0 +1,292 @@
+package tui
+
+// Editor rendering: the multi-line editor draws its buffer itself so KQL can be
+// colored by token class (keywords, operators, strings, comments, numbers and
+// customDimensions accessors). The bracket pairing the one at the cursor is
+// highlighted and brackets without a partner are marked as errors while typing.
+// The textarea still owns the buffer, cursor and key handling.
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/FBakkensen/bc-insights-tui/internal/kql"
+)
+
+// hlClass is the highlight class of a byte in the editor buffer.
+type hlClass uint8
+
+const (
+	hlPlain hlClass = iota
+	hlKeyword
+	hlOperator
+	hlString
+	hlComment
+	hlNumber
+	hlDimension
+	hlBracketMatch
+	hlError
+)
+
+var hlStyles = [...]lipgloss.Style{
+	hlPlain:        lipgloss.NewStyle(),
+	hlKeyword:      lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "26", Dark: "75"}).Bold(true),
+	hlOperator:     lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "127", Dark: "213"}),
+	hlString:       lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "28", Dark: "114"}),
+	hlComment:      lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "245", Dark: "243"}).Italic(true),
+	hlNumber:       lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "130", Dark: "215"}),
+	hlDimension:    lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "30", Dark: "80"}),
+	hlBracketMatch: lipgloss.NewStyle().Background(lipgloss.AdaptiveColor{Light: "229", Dark: "58"}).Bold(true),
+	hlError:        lipgloss.NewStyle().Foreground(lipgloss.Color("231")).Background(lipgloss.Color("160")),
+}
+
+var editorCursorStyle = lipgloss.NewStyle().Reverse(true)
+
+// textareaCursor returns the cursor's 0-based line and rune column in the value.
+func textareaCursor(ta textarea.Model) (row, col int) {
+	li := ta.LineInfo()
+	return ta.Line(), li.StartColumn + li.ColumnOffset
+}
+
+// textareaCursorOffset returns the cursor's byte offset in the value.
+func textareaCursorOffset(ta textarea.Model) int {
+	lines := strings.Split(ta.Value(), "\n")
+	row, col := textareaCursor(ta)
+	off := 0
+	for i := 0; i < row && i < len(lines); i++ {
+		off += len(lines[i]) + 1
+	}
+	if row < len(lines) {
+		off += len(string([]rune(lines[row])[:min(col, len([]rune(lines[row])))]))
+	}
+	return off
+}
+
+// highlightClasses assigns a class to every byte of src. cursor is the byte offset
+// of the cursor; a bracket just before or at it is paired with its partner.
+// nolint:gocyclo // One case per token class.
+func highlightClasses(src string, cursor int) []hlClass {
+	classes := make([]hlClass, len(src))
+	mark := func(from, to int, c hlClass) {
+		for i := max(0, from); i < min(to, len(classes)); i++ {
+			classes[i] = c
+		}
+	}
+	tokens, err := kql.Lex(src)
+	for i, t := range tokens {
+		switch t.Kind {
+		case kql.Keyword:
+			mark(t.Pos.Offset, t.End.Offset, hlKeyword)
+		case kql.Operator:
+			mark(t.Pos.Offset, t.End.Offset, hlOperator)
+		case kql.String:
+			mark(t.Pos.Offset, t.End.Offset, hlString)
+		case kql.Comment:
+			mark(t.Pos.Offset, t.End.Offset, hlComment)
+		case kql.Number, kql.Literal:
+			mark(t.Pos.Offset, t.End.Offset, hlNumber)
+		case kql.Ident:
+			if !strings.EqualFold(t.Text, "customDimensions") {
+				continue
+			}
+			// customDimensions.key and customDimensions['key']
+			end := t.End.Offset
+			if n := tokens[i+1:]; len(n) >= 2 && n[0].Is(".") && (n[1].Kind == kql.Ident || n[1].Kind == kql.Keyword) {
+				end = n[1].End.Offset
+			} else if len(n) >= 3 && n[0].Is("[") && n[1].Kind == kql.String && n[2].Is("]") {
+				end = n[2].End.Offset
+			}
+			mark(t.Pos.Offset, end, hlDimension)
+		}
+	}
+	var le *kql.LexError
+	if errors.As(err, &le) {
+		// Unterminated string or bad character: mark the rest of that line
+		end := strings.IndexByte(src[le.Pos.Offset:], '\n')
+		if end < 0 {
+			end = len(src) - le.Pos.Offset
+		}
+		mark(le.Pos.Offset, le.Pos.Offset+max(end, 1), hlError)
+	}
+	pairs, unmatched := kql.MatchBrackets(tokens)
+	for _, i := range unmatched {
+		mark(tokens[i].Pos.Offset, tokens[i].End.Offset, hlError)
+	}
+	for _, p := range pairs {
+		open, closer := tokens[p.Open], tokens[p.Close]
+		if open.Pos.Offset == cursor || closer.Pos.Offset == cursor || open.End.Offset == cursor || closer.End.Offset == cursor {
+			mark(open.Pos.Offset, open.End.Offset, hlBracketMatch)
+			mark(closer.Pos.Offset, closer.End.Offset, hlBracketMatch)
+			break
+		}
+	}
+	return classes
+}
+
+// editorView renders the editor buffer with highlighting, the cursor and the
+// textarea's prompt, scrolled so the cursor is visible.
+func (m model) editorView() string {
+	if m.ta.Value() == "" {
+		return m.ta.View() // placeholder
+	}
+	width, height := max(1, m.ta.Width()), max(1, m.ta.Height())
+	value := m.ta.Value()
+	lines := strings.Split(value, "\n")
+	row, col := textareaCursor(m.ta)
+	classes := highlightClasses(value, textareaCursorOffset(m.ta))
+	styles := m.ta.FocusedStyle
+	if !m.ta.Focused() {
+		styles = m.ta.BlurredStyle
+	}
+
+	var display []string
+	off := 0
+	for i, line := range lines {
+		var lineBG lipgloss.TerminalColor = lipgloss.NoColor{}
+		if i == row {
+			lineBG = styles.CursorLine.GetBackground()
+		}
+		display = append(display, renderHighlightedLine(line, classes[off:off+len(line)], width, lineBG, i == row, col)...)
+		off += len(line) + 1
+	}
+	cursorDisplay := cursorDisplayRow(lines, row, col, width)
+	top := clamp(m.editorTop, cursorDisplay-height+1, cursorDisplay)
+	top = max(0, min(top, len(display)-1))
+	var b strings.Builder
+	prompt := styles.Prompt.Render(m.ta.Prompt)
+	for i := 0; i < height; i++ {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(prompt)
+		if top+i < len(display) {
+			b.WriteString(display[top+i])
+		} else {
+			b.WriteString(styles.EndOfBuffer.Render(string(m.ta.EndOfBufferCharacter)))
+		}
+	}
+	return b.String()
+}
+
+// renderHighlightedLine styles one buffer line, wrapped as the textarea wraps it
+// (see editorWrap). With cursor set, the rune at col (or the trailing space) is
+// drawn reversed. Whitespace is drawn as a single space, as the textarea does.
+func renderHighlightedLine(line string, classes []hlClass, width int, bg lipgloss.TerminalColor, cursor bool, col int) []string {
+	runes := append([]rune(line), ' ')
+	runeClass := make([]hlClass, 0, len(runes))
+	for i := range line {
+		runeClass = append(runeClass, classes[i])
+	}
+	runeClass = append(runeClass, hlPlain)
+	for i, r := range runes {
+		if unicode.IsSpace(r) {
+			runes[i] = ' '
+		}
+	}
+	var out []string
+	start := 0
+	for _, n := range editorWrap(runes[:len(runes)-1], width) {
+		end := start + n
+		if w := lipgloss.Width(string(runes[start:end])); w > width && runes[end-1] == ' ' && !(cursor && col == end-1) {
+			end-- // the space a word wrapped after, as the textarea drops it
+		}
+		var b strings.Builder
+		for i := start; i < end; {
+			j := i + 1
+			for j < end && runeClass[j] == runeClass[i] && !(cursor && (j == col || i == col)) {
+				j++
+			}
+			style := hlStyles[runeClass[i]]
+			if _, ok := style.GetBackground().(lipgloss.NoColor); ok {
+				style = style.Background(bg)
+			}
+			if cursor && i == col {
+				style = editorCursorStyle
+			}
+			b.WriteString(style.Render(string(runes[i:j])))
+			i = j
+		}
+		if pad := width - lipgloss.Width(string(runes[start:end])); pad > 0 {
+			b.WriteString(lipgloss.NewStyle().Background(bg).Render(strings.Repeat(" ", pad)))
+		}
+		out = append(out, b.String())
+		start += n
+	}
+	return out
+}
+
+// editorWrap splits a buffer line into display rows exactly as the textarea
+// does: at spaces, by display width, cutting a word only when it is wider than
+// the row. It returns the rune count of each row; the counts cover the line
+// plus one trailing space, the cell the cursor takes at the end of the line.
+func editorWrap(runes []rune, width int) []int {
+	var (
+		rows   = []int{0}
+		rowW   = 0
+		word   []rune
+		spaces int
+	)
+	flush := func(newRow bool) {
+		if newRow {
+			rows = append(rows, 0)
+			rowW = 0
+		}
+		rows[len(rows)-1] += len(word) + spaces
+		rowW += lipgloss.Width(string(word)) + spaces
+		word, spaces = nil, 0
+	}
+	for _, r := range runes {
+		if unicode.IsSpace(r) {
+			spaces++
+		} else {
+			word = append(word, r)
+		}
+		if spaces > 0 {
+			flush(rowW+lipgloss.Width(string(word))+spaces > width)
+		} else if lipgloss.Width(string(word))+lipgloss.Width(string(word[len(word)-1])) > width {
+			// The word fills a row of its own
+			flush(rows[len(rows)-1] > 0)
+		}
+	}
+	spaces++
+	flush(rowW+lipgloss.Width(string(word))+spaces-1 >= width)
+	return rows
+}
+
+// scrollEditor keeps the editor's first visible line within reach of the cursor;
+// editorView clamps again for changes made outside the key handler.
+func (m *model) scrollEditor() {
+	row, col := textareaCursor(m.ta)
+	width, height := max(1, m.ta.Width()), max(1, m.ta.Height())
+	cursorDisplay := cursorDisplayRow(strings.Split(m.ta.Value(), "\n"), row, col, width)
+	m.editorTop = clamp(m.editorTop, cursorDisplay-height+1, cursorDisplay)
+}
+
+// cursorDisplayRow returns the wrapped display row of the cursor, matching
+// editorWrap and the textarea's own cursor placement.
+func cursorDisplayRow(lines []string, row, col, width int) int {
+	n := 0
+	for i := 0; i < row && i < len(lines); i++ {
+		n += len(editorWrap([]rune(lines[i]), width))
+	}
+	if row >= len(lines) {
+		return n
+	}
+	rows := editorWrap([]rune(lines[row]), width)
+	counter := 0
+	for i, c := range rows {
+		// At the end of a wrapped row the cursor sits at the start of the next
+		if counter+c == col && i+1 < len(rows) {
+			return n + i + 1
+		}
+		if counter+c >= col {
+			return n + i
+		}
+		counter += c
+	}
+	return n + len(rows) - 1
+}
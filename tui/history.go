@@ -0,0 +1,216 @@
+package tui
+
+// Persisted query history: every query run through runKQLCmd is recorded with
+// its outcome, recalled with Up/Down, and searchable via the 'history' panel.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/FBakkensen/bc-insights-tui/internal/util"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// historyFileVersion is bumped when the on-disk layout changes incompatibly.
+const historyFileVersion = 1
+
+// historyEntry is one executed query and its outcome.
+type historyEntry struct {
+	Query      string    `json:"query"`
+	Timestamp  time.Time `json:"timestamp"`
+	AppID      string    `json:"appId"`
+	Timespan   string    `json:"timespan,omitempty"`
+	RowCount   int       `json:"rowCount"`
+	DurationMs int64     `json:"durationMs"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// historyFile is the JSON document stored at cfg.QueryHistoryFile.
+type historyFile struct {
+	Version int            `json:"version"`
+	Entries []historyEntry `json:"entries"`
+}
+
+// queryHistory holds entries oldest-first, capped at max. An empty path keeps it in memory only.
+type queryHistory struct {
+	path    string
+	max     int
+	entries []historyEntry
+}
+
+// loadQueryHistory reads the history file if present. Read or parse failures are logged
+// and yield an empty history so the UI never fails to start because of it.
+func loadQueryHistory(path string, max int) *queryHistory {
+	h := &queryHistory{path: path, max: max}
+	if strings.TrimSpace(path) == "" {
+		return h
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Warn("Query history read failed", "path", path, "error", err.Error())
+		}
+		return h
+	}
+	var doc historyFile
+	if err := json.Unmarshal(b, &doc); err != nil {
+		logging.Warn("Query history parse failed; starting empty", "path", path, "error", err.Error())
+		return h
+	}
+	h.entries = doc.Entries
+	h.trim()
+	logging.Debug("Query history loaded", "path", path, "entries", fmt.Sprintf("%d", len(h.entries)))
+	return h
+}
+
+// add records e and persists. Re-running the most recent query replaces its entry
+// so recall does not cycle through identical consecutive items.
+func (h *queryHistory) add(e historyEntry) error {
+	if strings.TrimSpace(e.Query) == "" {
+		return nil
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1].Query == e.Query {
+		h.entries[n-1] = e
+	} else {
+		h.entries = append(h.entries, e)
+	}
+	h.trim()
+	return h.save()
+}
+
+// trim drops the oldest entries beyond max (max <= 0 means unbounded).
+func (h *queryHistory) trim() {
+	if h.max > 0 && len(h.entries) > h.max {
+		h.entries = append([]historyEntry(nil), h.entries[len(h.entries)-h.max:]...)
+	}
+}
+
+// len returns the number of entries.
+func (h *queryHistory) len() int {
+	if h == nil {
+		return 0
+	}
+	return len(h.entries)
+}
+
+// recent returns the i-th most recent entry (0 = newest).
+func (h *queryHistory) recent(i int) (historyEntry, bool) {
+	if h == nil || i < 0 || i >= len(h.entries) {
+		return historyEntry{}, false
+	}
+	return h.entries[len(h.entries)-1-i], true
+}
+
+// save writes the history atomically (temp file + rename) next to its target.
+func (h *queryHistory) save() error {
+	if strings.TrimSpace(h.path) == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(historyFile{Version: historyFileVersion, Entries: h.entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode query history: %w", err)
+	}
+	if err := util.WriteFileAtomic(h.path, b); err != nil {
+		return fmt.Errorf("failed to save query history %s: %w", h.path, err)
+	}
+	return nil
+}
+
+// historyItem adapts historyEntry to list.Item for the history panel.
+type historyItem struct{ e historyEntry }
+
+func (i historyItem) FilterValue() string { return i.e.Query }
+func (i historyItem) Title() string {
+	return truncateRunes(strings.Join(strings.Fields(i.e.Query), " "), 100)
+}
+
+func (i historyItem) Description() string {
+	when := i.e.Timestamp.Local().Format("2006-01-02 15:04:05")
+	dur := fmt.Sprintf("%.3fs", float64(i.e.DurationMs)/1000)
+	if !i.e.Success {
+		return fmt.Sprintf("%s · %s · error: %s", when, dur, truncateRunes(i.e.Error, 80))
+	}
+	desc := fmt.Sprintf("%s · %s · %d rows · app %s", when, dur, i.e.RowCount, truncateRunes(i.e.AppID, 8))
+	if i.e.Timespan != "" {
+		desc += " · time " + i.e.Timespan
+	}
+	return desc
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with an ellipsis.
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// recordHistory stores the outcome of a finished query. Failures to persist are
+// logged and surfaced once in the scrollback but never block the result flow.
+func (m *model) recordHistory(res kqlResultMsg) {
+	if strings.TrimSpace(res.query) == "" {
+		return
+	}
+	if m.history == nil {
+		m.history = &queryHistory{max: m.cfg.QueryHistoryMaxEntries}
+	}
+	e := historyEntry{
+		Query:      res.query,
+		Timestamp:  time.Now().UTC(),
+		AppID:      res.appID,
+		Timespan:   res.timespan.String(),
+		RowCount:   len(res.rows),
+		DurationMs: res.duration.Milliseconds(),
+		Success:    res.err == nil,
+	}
+	if res.err != nil {
+		e.Error = res.err.Error()
+	}
+	if err := m.history.add(e); err != nil {
+		logging.Warn("Query history save failed", "error", err.Error())
+		m.append("Warning: failed to save query history: " + err.Error())
+		return
+	}
+	logging.Debug("Query history recorded", "entries", fmt.Sprintf("%d", m.history.len()), "success", fmt.Sprintf("%t", e.Success))
+}
+
+// recallHistory moves through history (older when dir > 0, newer when dir < 0) and
+// loads the entry into the input. Moving newer past the latest entry restores the draft.
+// In chat a multi-line entry is loaded as is; the caller opens it in the editor.
+// Returns false when there is nothing to recall in that direction.
+func (m *model) recallHistory(dir int) bool {
+	next := m.histPos + dir
+	if next < 0 || next > m.history.len() {
+		return false
+	}
+	if m.histPos == 0 {
+		m.histDraft = m.ta.Value()
+	}
+	m.histPos = next
+	if next == 0 {
+		m.ta.SetValue(m.histDraft)
+		m.histDraft = ""
+		m.ta.CursorEnd()
+		return true
+	}
+	e, _ := m.history.recent(next - 1)
+	if m.mode == modeKQLEditor || strings.Contains(e.Query, "\n") {
+		m.ta.SetValue(e.Query)
+	} else {
+		// The chat input is single-line; collapse whitespace so the query stays runnable via 'kql:'
+		m.ta.SetValue("kql: " + strings.Join(strings.Fields(e.Query), " "))
+	}
+	m.ta.CursorEnd()
+	return true
+}
+
+// resetHistoryBrowse ends a recall session (after submit or cancel).
+func (m *model) resetHistoryBrowse() {
+	m.histPos = 0
+	m.histDraft = ""
+}
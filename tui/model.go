@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -30,6 +31,9 @@ const (
 	logModeTable   = "table"
 	logModeListSub = "list_subs"
 	logModeListAI  = "list_insights"
+	logModeHistory = "list_history"
+	logModeSaved   = "list_saved"
+	logModeTime    = "list_timespan"
 )
 
 // model implements the chat-first UI with a top viewport (scrollback) and bottom textarea (input).
@@ -43,14 +47,24 @@ type model struct {
 	ta textarea.Model
 
 	// top panel alternative components
-	list       list.Model
-	tbl        table.Model
-	mode       uiMode
-	returnMode uiMode // stores the authoring mode before opening table view
-	cfg        config.Config
+	list         list.Model
+	tbl          table.Model
+	tblCol       int       // column cursor in the results table (index into lastDisplayHeaders)
+	tblColOff    int       // first scrolled header shown after the frozen columns
+	tblVisible   []int     // header index of each table column, excluding the (+N) indicator
+	tblFreezeMsg bool      // 'M' freezes message next to timestamp
+	tblSort      tableSort // 's' in the results table
+	tblOrder     []int     // lastRows index of each table row when sorted or filtered; nil shows all in API order
+	tblOrderOf   int       // len(lastRows) when tblOrder was built
+	tblFind      tableFind // '/' search and '&' filter in the results table
+	mode         uiMode
+	returnMode   uiMode // stores the authoring mode before opening table view
+	cfg          config.Config
 
 	// chat content
 	content string
+	// short notice for the status line (e.g. a clipboard copy); cleared on the next key
+	statusNote string
 
 	// when true we auto-scroll to bottom on new content; toggled off when
 	// the user scrolls up, and back on when they return to bottom.
@@ -75,22 +89,58 @@ type model struct {
 	lastColumns  []appinsights.Column
 	lastRows     [][]interface{}
 	lastTable    string
+	lastTables   []resultTable // every table of the last response; lastColumns/lastRows mirror the active one
+	activeTable  int
 	lastDuration time.Duration
 	haveResults  bool
 	runningKQL   bool
+	retryStatus  string // latest automatic retry of the running query, for the status line
+	cancelKQL    context.CancelFunc
+	canceling    bool
 
 	// normalized display headers for list views (timestamp, message, customDimensions keys)
 	lastDisplayHeaders []string
 
+	// load-more paging over the current results ('more', or End in the table)
+	paging pagingState
+	// live tail ('tail'): polls for newer rows and appends them
+	tail tailState
+
 	// editor (Step 6)
 	editorDesiredHeight int
 	origPrompt          string
+	editorErr           *editorErrorPos   // location of the last API error, highlighted in the editor
+	completion          completionState   // Tab/Ctrl+Space popup in the editor
+	editorTop           int               // first visible display line of the highlighted editor
+	runLineOffset       int               // buffer lines before the text the last editor run sent
+	runColumnOffset     int               // indentation trimmed from the first line the last editor run sent
+	editorParams        map[string]string // stored defaults of the saved query loaded into the editor
+	// columns and customDimensions keys seen in results, by app id, for completion
+	completionVocab map[string]*completionVocab
 
 	// details view (Step 9)
 	detailsVP      viewport.Model
 	detailsStart   time.Time
 	detailsContent string
 	detailsRow     int
+	details        detailsTree // customDimensions tree of the shown row
+
+	// query history (persisted; Up/Down recall, 'history' panel)
+	history   *queryHistory
+	histPos   int    // 0 when not browsing; k = k-th most recent entry
+	histDraft string // input text saved when browsing starts
+
+	// saved-query library ('save', 'run', 'queries')
+	savedQueries *savedQueryStore
+	paramPrompt  *paramPrompt // non-nil while asking for placeholder values
+	lastTemplate string       // most recently run query before placeholder expansion
+
+	// column chooser ('C' in the table, 'columns') and the saved per-eventId layouts
+	columnLayouts *columnLayoutStore
+	colPicker     *columnPicker
+
+	// time window applied to every query ('time' command; persisted as queryTimespan)
+	timespan appinsights.Timespan
 }
 
 type uiMode int
@@ -107,6 +157,10 @@ const (
 	modeListInsightsResources
 	modeTableResults
 	modeDetails
+	modeListHistory
+	modeListSavedQueries
+	modeListTimespan
+	modeListColumns
 )
 
 // config keys used in TUI (mirror of config.settingAzureSubscriptionID)
@@ -116,12 +170,15 @@ const keyAzureSubscriptionID = "azure.subscriptionId"
 const (
 	titleSelectSubscription = "Select Azure Subscription"
 	titleSelectInsights     = "Select Application Insights Resource"
+	titleQueryHistory       = "Query History (type / to filter)"
+	titleSavedQueries       = "Saved Queries (type / to filter)"
+	titleSelectTimespan     = "Select Query Time Range"
 	// Prompts and hints
 	promptDefault = "> "
 	promptEditor  = "KQL> "
 	// Keep Ctrl+Enter in the hint to satisfy existing tests, but also advertise
 	// reliable keys (F5/Ctrl+R) that work across terminals on Windows.
-	hintEditor = "Ctrl+Enter (Ctrl+M) to run · F5 or Ctrl+R to run · Esc to cancel"
+	hintEditor = "Ctrl+Enter (Ctrl+M) to run · F5 or Ctrl+R to run · F9 or Alt+R to run the block at the cursor · Tab or Ctrl+Space to complete · Esc to cancel"
 	// Layout minimums
 	minViewportHeight = 3
 	minEditorHeight   = 3
@@ -130,7 +187,7 @@ const (
 // Minimal interface to App Insights KQL used by the UI (for tests and DI)
 type KQLClient interface {
 	ValidateQuery(query string) error
-	ExecuteQuery(ctx context.Context, query string) (*appinsights.QueryResponse, error)
+	ExecuteQuery(ctx context.Context, query string, timespan appinsights.Timespan) (*appinsights.QueryResponse, error)
 }
 
 // Run starts the Bubble Tea program with the chat-first model.
@@ -148,6 +205,7 @@ func initialModel(cfg config.Config) model {
 	ta.Focus()
 	ta.Prompt = promptDefault
 	ta.CharLimit = 0
+	ta.MaxHeight = 0 // no line limit; queries from an external editor can be long
 	ta.SetHeight(3)
 	ta.SetWidth(80)
 	ta.CursorEnd()
@@ -186,6 +244,10 @@ func initialModel(cfg config.Config) model {
 		editorDesiredHeight: 8,
 		origPrompt:          promptDefault,
 		detailsVP:           viewport.New(80, 20),
+		history:             loadQueryHistory(resolveHistoryPath(cfg.QueryHistoryFile), cfg.QueryHistoryMaxEntries),
+		savedQueries:        loadSavedQueries(resolveSavedQueriesPath(cfg.SavedQueriesFile)),
+		columnLayouts:       loadColumnLayouts(resolveColumnLayoutsPath(cfg.ColumnLayoutsFile)),
+		timespan:            timespanFromConfig(cfg.QueryTimespan),
 	}
 	m.append("Welcome to bc-insights-tui (chat-first).")
 	m.append("Step 1: Login using Azure Device Flow.")
@@ -199,6 +261,16 @@ func initialModel(cfg config.Config) model {
 	return m
 }
 
+// resolveHistoryPath places a relative history file next to the config file.
+func resolveHistoryPath(name string) string {
+	p, err := config.ResolveDataFilePath(name)
+	if err != nil {
+		logging.Warn("Query history path unavailable; history kept in memory", "error", err.Error())
+		return ""
+	}
+	return p
+}
+
 // append adds a line to the scrollback and moves viewport to bottom.
 func (m *model) append(line string) {
 	if m.content == "" {
@@ -240,7 +312,13 @@ func (m *model) showConfig() {
 	m.appendSetting(settings, "queryHistoryMaxEntries", "Max History Entries")
 	m.appendSetting(settings, "queryTimeoutSeconds", "Query Timeout (seconds)")
 	m.appendSetting(settings, "queryHistoryFile", "History File")
+	m.appendSetting(settings, "savedQueriesFile", "Saved Queries File")
+	m.appendSetting(settings, "columnLayoutsFile", "Column Layouts File")
 	m.appendSetting(settings, "editorPanelRatio", "Editor Panel Ratio")
+	m.appendSetting(settings, "queryRetryMaxAttempts", "Retry Max Attempts")
+	m.appendSetting(settings, "queryRetryBaseDelayMs", "Retry Base Delay (ms)")
+	m.appendSetting(settings, "queryRetryMaxDelayMs", "Retry Max Delay (ms)")
+	m.appendSetting(settings, "tailIntervalSeconds", "Tail Interval (s)")
 
 	m.append("  Debug / Raw Capture:")
 	m.appendSetting(settings, "debug.appInsightsRawEnable", "Enabled")
@@ -261,18 +339,64 @@ func (m *model) showKeys() {
 	m.append("  Global:")
 	// spacing aligned for readability
 	m.append("    Esc / Ctrl+C    — Quit (or close panel)")
+	m.append("    Esc (running)   — Cancel the running query (also: 'cancel')")
+	m.append("    Esc (tailing)   — Stop the live tail (also: 'tail stop')")
 	m.append("    F6              — Open last results interactively (in Chat/Editor)")
 	m.append("  Chat mode:")
 	m.append("    Enter            — Submit command (e.g., 'edit', 'subs', 'resources', 'config')")
+	m.append("    Up/Down          — Recall previous/next query from history")
 	m.append("  Editor mode:")
 	m.append("    Enter            — Insert newline")
 	m.append("    F5 or Ctrl+R     — Run query")
 	m.append("    Ctrl+Enter       — Run (may arrive as Ctrl+M in some terminals)")
+	m.append("    F9 or Alt+R      — Run only the block at the cursor (queries separated by blank lines)")
+	m.append("    Ctrl+O           — Edit the query in $VISUAL/$EDITOR (also: 'edit-external [run]')")
+	m.append("    Up/Down          — Recall history (on first/last line)")
+	m.append("    Tab / Ctrl+Space — Complete tables, operators, functions, columns and customDimensions keys")
+	m.append("                       (popup: Up/Down select · Tab/Enter insert · Esc close)")
+	m.append("    Shift+Alt+F / F4 — Format the query (one pipe operator per line)")
 	m.append("    Esc              — Cancel edit")
-	m.append("  List panels (subscriptions/resources):")
-	m.append("    Up/Down, PgUp/PgDn — Navigate · Enter — Select · Esc — Close")
+	m.append("  List panels (subscriptions/resources/history/queries/time):")
+	m.append("    Up/Down, PgUp/PgDn — Navigate · / — Filter · Enter — Select · Esc — Close")
+	m.append("  Copy (OSC 52; works over SSH and in Windows Terminal):")
+	m.append("    Details view: c — Copy details text · y — Copy row as JSON · q — Copy query · Chat: 'copy'")
 	m.append("  Results table:")
-	m.append("    Up/Down/Left/Right — Navigate · Home/End — Jump · Esc — Close")
+	m.append("    Up/Down/Left/Right — Navigate · Home/End — Jump · Esc — Clear search/filter, then close")
+	m.append("    Tab / Shift+Tab  — Next/previous result table (multi-table results)")
+	m.append("    Left/Right       — Move the column cursor (▸), scrolling columns past the edge (‹ and (+N) mark more)")
+	m.append("    M                — Freeze message next to timestamp while scrolling (toggle)")
+	m.append("    c / y / q        — Copy cell · row as JSON · query")
+	m.append("    s                — Sort by the column under the cursor: ascending ▲, descending ▼, API order")
+	m.append("    / · n/N          — Search (matches marked »), next/previous match")
+	m.append("    &                — Filter rows (text, key=value, ~regexp or key~regexp)")
+	m.append("    C                — Choose columns: show/hide, pin and reorder (saved per eventId; also: 'columns')")
+	m.append("    Ctrl+S           — Export the table as shown to an .xlsx file (also: 'export <format> <path>')")
+	m.append("    Down/End on last row — Load older rows (also: 'more')")
+	m.append("  Details view (Enter on a row):")
+	m.append("    Up/Down, PgUp/PgDn, Home/End — Select a customDimensions node (path shown in the status line)")
+	m.append("    Right/Left       — Expand (or go to first child) / collapse (or go to parent) · Enter/Space — Toggle")
+	m.append("    e / E            — Expand everything below the node / collapse all · Esc — Back to the table")
+	m.append("    [ / ]            — Previous/next row in table order · { / } — First/last row")
+}
+
+// kqlRunKind tells handleKQLResult what a result is for.
+type kqlRunKind int
+
+const (
+	kqlRunQuery kqlRunKind = iota // a user query: results replace the current ones
+	kqlRunPage                    // 'more': older rows extend the current results
+	kqlRunTail                    // a 'tail' poll: newer rows extend the current results
+)
+
+func (k kqlRunKind) String() string {
+	switch k {
+	case kqlRunPage:
+		return "page"
+	case kqlRunTail:
+		return "tail"
+	default:
+		return "query"
+	}
 }
 
 // msgs used by the update loop
@@ -295,11 +419,25 @@ type (
 	}
 	// KQL messages
 	kqlResultMsg struct {
-		tableName string
-		columns   []appinsights.Column
-		rows      [][]interface{}
-		duration  time.Duration
-		err       error
+		query      string
+		appID      string
+		timespan   appinsights.Timespan
+		tableName  string
+		columns    []appinsights.Column
+		rows       [][]interface{}
+		tables     []appinsights.Table // all response tables; columns/rows are the primary one
+		duration   time.Duration
+		retries    int                            // automatic retries (429/5xx/transport) before the final outcome
+		canceled   bool                           // the user canceled the query ('cancel' or Esc while running)
+		fetchLimit appinsights.FetchLimitDecision // whether the client added "| take N"
+		kind       kqlRunKind
+		tailGen    int // tail generation of a kqlRunTail poll
+		err        error
+	}
+	// kqlRetryMsg reports an automatic retry of the running query; events is re-listened
+	kqlRetryMsg struct {
+		event  appinsights.RetryEvent
+		events <-chan appinsights.RetryEvent
 	}
 )
 
@@ -460,8 +598,30 @@ func (i insightsResourceItem) Description() string {
 	return fmt.Sprintf("RG: %s | Location: %s | App ID: %s", i.r.ResourceGroup, i.r.Location, i.r.ApplicationID)
 }
 
-// runKQLCmd validates inputs, performs timeout, executes, and returns kqlResultMsg
+// queryBusy reports, and tells the user, that a query is still running. Starting
+// another would orphan the running query's cancel and race its result.
+func (m *model) queryBusy() bool {
+	if !m.runningKQL {
+		return false
+	}
+	m.append("A query is already running. Wait for it or 'cancel' it.")
+	return true
+}
+
+// runKQLCmd validates inputs, performs timeout, executes, and returns kqlResultMsg.
+// Automatic retries are streamed as kqlRetryMsg while the query runs, and the
+// query can be canceled via m.cancelKQL until its result arrives.
 func (m *model) runKQLCmd(query string) tea.Cmd {
+	// A new query replaces the results a tail appends to
+	m.stopTail("stopped")
+	return m.kqlCmd(query, m.timespan, kqlRunQuery)
+}
+
+// kqlCmd runs query with timespan; kind is carried to the result so 'more' and
+// 'tail' rows are appended to the current results instead of replacing them.
+func (m *model) kqlCmd(query string, timespan appinsights.Timespan, kind kqlRunKind) tea.Cmd {
+	m.runningKQL = true
+	m.canceling = false
 	// capture cfg values
 	timeoutSec := m.cfg.QueryTimeoutSeconds
 	if timeoutSec <= 0 {
@@ -485,17 +645,26 @@ func (m *model) runKQLCmd(query string) tea.Cmd {
 		"first_token", firstToken,
 		"timeout_s", fmt.Sprintf("%d", timeoutSec),
 		"fetch_size", fmt.Sprintf("%d", fetch),
+		"timespan", util.FirstNonEmpty(timespan.String(), "none"),
+		"kind", kind.String(),
 	)
+	base := kqlResultMsg{query: query, appID: appID, timespan: timespan, kind: kind, tailGen: m.tail.gen}
 
 	// Perform preflight outside the closure to avoid capturing m
 	if err := m.preflightKQL(appID); err != nil {
 		logging.Error("KQL preflight failed", "error", err.Error())
-		return func() tea.Msg { return kqlResultMsg{err: err} }
+		base.err = err
+		return func() tea.Msg { return base }
 	}
 	// Construct client once and capture it immutably for the closure
 	client := m.getKQLClient(appID)
+	events := make(chan appinsights.RetryEvent, 8)
+	parent, cancelQuery := context.WithCancel(context.Background())
+	m.cancelKQL = cancelQuery
 
-	return func() tea.Msg {
+	run := func() tea.Msg {
+		defer close(events)
+		defer cancelQuery()
 		deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
 		logging.Debug("KQL command starting",
 			"appId_len", fmt.Sprintf("%d", len(strings.TrimSpace(appID))),
@@ -503,45 +672,119 @@ func (m *model) runKQLCmd(query string) tea.Cmd {
 		)
 		if err := client.ValidateQuery(query); err != nil {
 			logging.Error("KQL validation failed", "error", err.Error())
-			return kqlResultMsg{err: err}
+			res := base
+			res.err = err
+			return res
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
+		ctx, cancel := context.WithTimeout(parent, time.Duration(timeoutSec)*time.Second)
 		defer cancel()
+		retries := 0
+		ctx = appinsights.WithRetryNotifier(ctx, func(ev appinsights.RetryEvent) {
+			retries++
+			select {
+			case events <- ev:
+			default: // never block the query on a slow UI
+			}
+		})
 		start := time.Now()
-		resp, err := client.ExecuteQuery(ctx, query)
+		resp, err := client.ExecuteQuery(ctx, query, timespan)
 		dur := time.Since(start)
+		if err != nil && errors.Is(parent.Err(), context.Canceled) {
+			logging.Info("KQL execute canceled", "query_hash", qhash, "duration_ms", fmt.Sprintf("%d", dur.Milliseconds()), "retries", fmt.Sprintf("%d", retries))
+			res := base
+			res.duration, res.retries, res.canceled, res.err = dur, retries, true, errQueryCanceled
+			return res
+		}
 		if err != nil {
 			mapped := mapKQLError(err, timeoutSec, ctx.Err())
-			logging.Error("KQL execute failed", "status", "n/a", "error", err.Error())
+			if retries > 0 && !strings.Contains(mapped.Error(), "attempts)") {
+				mapped = fmt.Errorf("%s (after %d attempts)", mapped.Error(), retries+1)
+			}
+			logging.Error("KQL execute failed", "status", "n/a", "error", err.Error(), "retries", fmt.Sprintf("%d", retries))
 			if ctx.Err() != nil {
 				logging.Error("KQL context error", "ctxErr", ctx.Err().Error(), "duration_ms", fmt.Sprintf("%d", dur.Milliseconds()))
 			}
-			return kqlResultMsg{duration: dur, err: mapped}
+			res := base
+			res.duration, res.retries, res.err = dur, retries, mapped
+			return res
 		}
 		// Parse results; prefer PrimaryResult if available
 		tableName := ""
 		var cols []appinsights.Column
 		var rows [][]interface{}
+		var tables []appinsights.Table
 		if resp != nil && len(resp.Tables) > 0 {
-			idx := 0
-			for i, t := range resp.Tables {
-				if strings.EqualFold(t.Name, "PrimaryResult") {
-					idx = i
-					break
-				}
-			}
-			tableName = resp.Tables[idx].Name
-			cols = resp.Tables[idx].Columns
-			rows = resp.Tables[idx].Rows
+			tables = resp.Tables
+			idx := primaryTableIndex(tables)
+			tableName = tables[idx].Name
+			cols = tables[idx].Columns
+			rows = tables[idx].Rows
 		}
 		logging.Info("KQL execute success",
 			"duration_ms", fmt.Sprintf("%d", dur.Milliseconds()),
 			"rows", fmt.Sprintf("%d", len(rows)),
 			"cols", fmt.Sprintf("%d", len(cols)),
 			"table", util.FirstNonEmpty(tableName, "PrimaryResult"),
+			"tables", fmt.Sprintf("%d", len(tables)),
+			"retries", fmt.Sprintf("%d", retries),
 		)
-		return kqlResultMsg{tableName: tableName, columns: cols, rows: rows, duration: dur}
+		res := base
+		res.tableName, res.columns, res.rows, res.tables = tableName, cols, rows, tables
+		res.duration, res.retries = dur, retries
+		if resp != nil {
+			res.fetchLimit = resp.FetchLimit
+		}
+		return res
+	}
+	return tea.Batch(run, waitForRetryEvent(events))
+}
+
+// errQueryCanceled is the result error of a query the user canceled.
+var errQueryCanceled = errors.New("query canceled")
+
+// cancelRunningQuery cancels the in-flight query's context; the result arrives as a
+// canceled kqlResultMsg once ExecuteQuery has returned.
+func (m *model) cancelRunningQuery() {
+	if !m.runningKQL || m.cancelKQL == nil {
+		m.append("No query is running.")
+		return
+	}
+	if m.canceling {
+		return
 	}
+	logging.Info("KQL cancel requested", "retry_status", m.retryStatus)
+	m.cancelKQL()
+	m.canceling = true
+	m.append("Canceling query…")
+}
+
+// waitForRetryEvent delivers the next retry of a running query; nil once the query finished.
+func waitForRetryEvent(events <-chan appinsights.RetryEvent) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-events
+		if !ok {
+			return nil
+		}
+		return kqlRetryMsg{event: ev, events: events}
+	}
+}
+
+// describeRetry renders a retry event for the scrollback.
+func describeRetry(ev appinsights.RetryEvent) string {
+	reason := "request failed"
+	switch {
+	case ev.Status == 429:
+		reason = "throttled (429)"
+	case ev.Status > 0:
+		reason = fmt.Sprintf("service error (%d)", ev.Status)
+	case ev.Err != "":
+		reason = "request failed: " + ev.Err
+	}
+	wait := fmt.Sprintf("%.1fs", ev.Wait.Seconds())
+	if ev.RetryAfter {
+		wait += " (Retry-After)"
+	}
+	return fmt.Sprintf("Query %s; retrying in %s · attempt %d/%d", reason, wait, ev.Attempt, ev.MaxAttempts)
 }
 
 // preflightKQL ensures the user is authenticated and App Id is set
@@ -573,6 +816,11 @@ func mapKQLError(err error, timeoutSec int, ctxErr error) error {
 	if err == nil {
 		return nil
 	}
+	// Typed API errors keep their details; handleKQLResult renders position and hints
+	var qe *appinsights.QueryError
+	if errors.As(err, &qe) {
+		return qe
+	}
 	lower := strings.ToLower(err.Error())
 	switch {
 	case strings.Contains(lower, "401") || strings.Contains(lower, "unauthorized"):
@@ -611,6 +859,12 @@ func (m *model) openTableFromLastResults() (model, tea.Cmd) {
 				return logModeListSub
 			case modeListInsightsResources:
 				return logModeListAI
+			case modeListHistory:
+				return logModeHistory
+			case modeListSavedQueries:
+				return logModeSaved
+			case modeListTimespan:
+				return logModeTime
 			case modeTableResults:
 				return logModeTable
 			default:
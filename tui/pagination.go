@@ -0,0 +1,342 @@
+package tui
+
+// Load more: 'more' (or scrolling past the last row of the interactive table) fetches
+// the next batch of older rows with a timestamp cursor taken from the oldest loaded
+// row, appends them to the active table and extends its headers with new keys.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/internal/kql"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// queryLimitsRows reports whether the final query statement limits, aggregates or
+// reshapes its own rows (take/top/summarize/evaluate/…), so older rows cannot be
+// paged. Operators inside subqueries and strings do not count.
+func queryLimitsRows(query string) bool {
+	script, err := kql.Parse(query)
+	if err != nil || script.Query() == nil {
+		return true
+	}
+	stmt := script.Query()
+	if stmt.RowLimit() != "" {
+		return true
+	}
+	for _, stage := range stmt.Stages()[1:] {
+		if len(stage) > 0 && stage[0].IsWord("evaluate") {
+			return true
+		}
+	}
+	return false
+}
+
+// orderPreservingOperators keep the row order of an earlier sort.
+var orderPreservingOperators = map[string]bool{
+	"where": true, "filter": true, "extend": true, "parse": true,
+	"project": true, "project-away": true, "project-keep": true, "project-rename": true, "project-reorder": true,
+}
+
+// queryNewestFirst reports whether the final query statement returns its rows
+// newest first: its last sort is "order by timestamp" or "sort by timestamp"
+// (desc, the default), followed only by order-preserving stages and a trailing
+// render. Only then is the fetch-limited first page the newest rows, so paging
+// back from its oldest timestamp cannot skip rows.
+func queryNewestFirst(query string) bool {
+	script, err := kql.Parse(query)
+	if err != nil || script.Query() == nil {
+		return false
+	}
+	stages := script.Query().Stages()
+	for i := len(stages) - 1; i > 0; i-- {
+		stage := stages[i]
+		if len(stage) == 0 {
+			return false
+		}
+		op := strings.ToLower(stage[0].Text)
+		switch {
+		case op == "render" && i == len(stages)-1, orderPreservingOperators[op]:
+			continue
+		case op == "order" || op == "sort":
+			return len(stage) >= 3 && stage[1].IsWord("by") && stage[2].IsWord("timestamp") &&
+				(len(stage) == 3 || !stage[3].IsWord("asc"))
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// renderOffset returns the offset of the pipe before a trailing "| render" of
+// the final query statement.
+func renderOffset(query string) (int, bool) {
+	script, err := kql.Parse(query)
+	if err != nil || script.Query() == nil {
+		return 0, false
+	}
+	stmt := script.Query()
+	stages := stmt.Stages()
+	last := stages[len(stages)-1]
+	if len(stages) < 2 || len(last) == 0 || !last[0].IsWord("render") {
+		return 0, false
+	}
+	for i := len(stmt.Tokens) - 1; i > 0; i-- {
+		if stmt.Tokens[i].Pos.Offset == last[0].Pos.Offset {
+			return stmt.Tokens[i-1].Pos.Offset, true
+		}
+	}
+	return 0, false
+}
+
+// pageRequest is the in-flight 'more' request.
+type pageRequest struct {
+	cursor   time.Time
+	take     int
+	boundary map[string]struct{} // loaded rows at the cursor timestamp, to drop repeats
+}
+
+// pagingState tracks load-more for the current results.
+type pagingState struct {
+	query     string // the query that produced the results (before fetch-limit injection)
+	timespan  appinsights.Timespan
+	pageSize  int
+	exhausted bool
+	pending   *pageRequest
+}
+
+// resetPaging starts paging over for a new result set.
+func (m *model) resetPaging(res kqlResultMsg) {
+	m.paging = pagingState{query: res.query, timespan: res.timespan, pageSize: m.fetchSize()}
+	m.paging.exhausted = len(m.lastRows) < m.paging.pageSize
+}
+
+// fetchSize returns the configured page size.
+func (m model) fetchSize() int {
+	if m.cfg.LogFetchSize > 0 {
+		return m.cfg.LogFetchSize
+	}
+	return 50
+}
+
+// timestampColumn returns the index of the timestamp column, or -1.
+func timestampColumn(columns []appinsights.Column) int {
+	for i, c := range columns {
+		if strings.EqualFold(c.Name, "timestamp") {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseRowTimestamp reads a timestamp cell as returned by the API.
+func parseRowTimestamp(v interface{}) (time.Time, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s))
+	return t, err == nil
+}
+
+// oldestTimestamp returns the earliest timestamp among rows; for the usual
+// 'order by timestamp desc' results this is the last row.
+func oldestTimestamp(rows [][]interface{}, col int) (time.Time, bool) {
+	var oldest time.Time
+	found := false
+	for _, r := range rows {
+		if col >= len(r) {
+			continue
+		}
+		if t, ok := parseRowTimestamp(r[col]); ok && (!found || t.Before(oldest)) {
+			oldest, found = t, true
+		}
+	}
+	return oldest, found
+}
+
+// rowKey identifies a row for de-duplication at the cursor boundary.
+func rowKey(r []interface{}) string { return fmt.Sprint(r...) }
+
+// buildPageQuery wraps the original query to fetch up to take rows at or before
+// cursor, newest first. A trailing "| render" stays last.
+func buildPageQuery(query string, cursor time.Time, take int) string {
+	q := strings.TrimRight(strings.TrimSpace(query), "; \n")
+	page := fmt.Sprintf("| where timestamp <= datetime(%s)\n| order by timestamp desc\n| take %d", cursor.UTC().Format(time.RFC3339Nano), take)
+	if at, ok := renderOffset(q); ok {
+		return q[:at] + page + "\n" + q[at:]
+	}
+	return q + "\n" + page
+}
+
+// pagingUnsupported explains why the current results cannot be extended, or returns "".
+func (m model) pagingUnsupported() string {
+	switch {
+	case m.tail.active:
+		return "Load more is not available while tailing; 'tail stop' first."
+	case !m.haveResults || m.paging.query == "":
+		return "No results to extend. Run a query first."
+	case len(m.lastTables) > 1:
+		return "Load more works for single-table results only."
+	case timestampColumn(m.lastColumns) < 0:
+		return "Load more needs a timestamp column in the results."
+	case queryLimitsRows(m.paging.query):
+		return "Load more is not available: the query limits or aggregates its own rows (take/top/summarize/…)."
+	case !queryNewestFirst(m.paging.query):
+		return "Load more needs the newest rows first: end the query with '| order by timestamp desc' and run it again."
+	}
+	return ""
+}
+
+// startLoadMore requests the next batch of older rows, if possible.
+func (m model) startLoadMore() (tea.Model, tea.Cmd) {
+	if m.paging.pending != nil {
+		return m, nil
+	}
+	if m.queryBusy() {
+		return m, nil
+	}
+	if reason := m.pagingUnsupported(); reason != "" {
+		m.append(reason)
+		return m, nil
+	}
+	if m.paging.exhausted {
+		m.append("No older rows: the last batch was smaller than the fetch size.")
+		return m, nil
+	}
+	col := timestampColumn(m.lastColumns)
+	cursor, ok := oldestTimestamp(m.lastRows, col)
+	if !ok {
+		m.append("Load more needs parseable timestamps in the results.")
+		return m, nil
+	}
+	boundary := map[string]struct{}{}
+	for _, r := range m.lastRows {
+		if t, ok := parseRowTimestamp(r[col]); ok && t.Equal(cursor) {
+			boundary[rowKey(r)] = struct{}{}
+		}
+	}
+	req := &pageRequest{cursor: cursor, take: m.paging.pageSize + len(boundary), boundary: boundary}
+	m.paging.pending = req
+	logging.Info("Load more requested",
+		"cursor", cursor.UTC().Format(time.RFC3339Nano),
+		"take", fmt.Sprintf("%d", req.take),
+		"loaded", fmt.Sprintf("%d", len(m.lastRows)),
+	)
+	m.append(fmt.Sprintf("Loading up to %d older rows before %s…", m.paging.pageSize, cursor.UTC().Format(time.RFC3339)))
+	cmd := m.kqlCmd(buildPageQuery(m.paging.query, cursor, req.take), m.paging.timespan, kqlRunPage)
+	return m, cmd
+}
+
+// handleKQLPage appends a 'more' batch to the active table.
+func (m model) handleKQLPage(res kqlResultMsg) (tea.Model, tea.Cmd) {
+	req := m.paging.pending
+	m.paging.pending = nil
+	switch {
+	case req == nil:
+		return m, nil
+	case res.canceled:
+		m.append("Load more canceled.")
+		return m, nil
+	case res.err != nil:
+		logging.Error("Load more failed", "error", res.err.Error())
+		m.append("Load more failed; the loaded rows are kept.")
+		m.showKQLError(res)
+		return m, nil
+	}
+	if !sameColumns(res.columns, m.lastColumns) {
+		m.append("Load more returned different columns; run the query again instead.")
+		return m, nil
+	}
+	fresh := make([][]interface{}, 0, len(res.rows))
+	for _, r := range res.rows {
+		if _, dup := req.boundary[rowKey(r)]; dup {
+			continue
+		}
+		fresh = append(fresh, r)
+	}
+	m.paging.exhausted = len(res.rows) < req.take
+	if len(fresh) == 0 {
+		m.paging.exhausted = true
+		m.append("No older rows.")
+		return m, nil
+	}
+	m.appendRows(fresh)
+	m.rememberCompletionNames(res.appID)
+	logging.Info("Load more appended",
+		"rows", fmt.Sprintf("%d", len(fresh)),
+		"total", fmt.Sprintf("%d", len(m.lastRows)),
+		"exhausted", fmt.Sprintf("%t", m.paging.exhausted),
+		"duration_ms", fmt.Sprintf("%d", res.duration.Milliseconds()),
+	)
+	msg := fmt.Sprintf("Loaded %d older rows in %.3fs · %d rows total", len(fresh), res.duration.Seconds(), len(m.lastRows))
+	if m.paging.exhausted {
+		msg += " · no more rows"
+	}
+	m.append(msg)
+	if m.followTail || m.vp.AtBottom() {
+		m.vp.GotoBottom()
+	}
+	return m, nil
+}
+
+// appendRows adds rows to the active table, extends its headers with keys first
+// seen in the new rows (existing columns keep their order) and refreshes the table view.
+func (m *model) appendRows(rows [][]interface{}) {
+	selected := m.rowIndex(m.tbl.Cursor())
+	m.lastRows = append(m.lastRows, rows...)
+	added := m.withoutHiddenKeys(newHeaderKeys(m.lastDisplayHeaders, computeRankedHeaders(m.lastColumns, rows, m.cfg)))
+	if len(added) > 0 {
+		m.lastDisplayHeaders = append(append([]string(nil), m.lastDisplayHeaders...), added...)
+	}
+	if m.activeTable >= 0 && m.activeTable < len(m.lastTables) {
+		m.lastTables[m.activeTable].rows = m.lastRows
+		m.lastTables[m.activeTable].headers = m.lastDisplayHeaders
+	}
+	if m.mode == modeTableResults || m.mode == modeDetails {
+		m.refreshTable(selected)
+	}
+}
+
+// newHeaderKeys returns the entries of ranked that are not yet in headers (case-insensitive).
+func newHeaderKeys(headers, ranked []string) []string {
+	seen := make(map[string]struct{}, len(headers))
+	for _, h := range headers {
+		seen[strings.ToLower(h)] = struct{}{}
+	}
+	var out []string
+	for _, h := range ranked {
+		if _, ok := seen[strings.ToLower(h)]; !ok {
+			seen[strings.ToLower(h)] = struct{}{}
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+func sameColumns(a, b []appinsights.Column) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i].Name, b[i].Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// canLoadMore reports whether End/Down on the last row should fetch older rows.
+func (m model) canLoadMore() bool {
+	return !m.runningKQL && !m.paging.exhausted && m.pagingUnsupported() == ""
+}
+
+// atTableEnd reports whether the interactive table cursor is on the last row.
+func (m model) atTableEnd() bool {
+	n := len(m.tbl.Rows())
+	return n > 0 && m.tbl.Cursor() >= n-1
+}
@@ -0,0 +1,209 @@
+package tui
+
+// Query API errors: appinsights.QueryError is rendered with its position in the
+// query, a hint for the error kind, and (in the editor) a highlight on the failing line.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/internal/kql"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// editorErrorLineStyle marks the line an API error points at while the cursor sits on it.
+var editorErrorLineStyle = lipgloss.NewStyle().
+	Background(lipgloss.AdaptiveColor{Light: "224", Dark: "52"}).
+	Foreground(lipgloss.AdaptiveColor{Light: "1", Dark: "217"})
+
+// editorErrorPos is the 1-based location of the last API error in the editor buffer.
+type editorErrorPos struct {
+	line int
+	col  int
+}
+
+// queryErrorHeadline names the error kind with status, detail and location.
+func queryErrorHeadline(qe *appinsights.QueryError) string {
+	label := ""
+	switch qe.Kind() {
+	case appinsights.QueryErrorSyntax:
+		label = "Syntax error"
+	case appinsights.QueryErrorSemantic:
+		label = "Semantic error"
+	case appinsights.QueryErrorBadRequest:
+		label = "Bad request"
+	case appinsights.QueryErrorUnauthorized:
+		label = "Unauthorized"
+	case appinsights.QueryErrorForbidden:
+		label = "Forbidden"
+	case appinsights.QueryErrorNotFound:
+		label = "Not found"
+	case appinsights.QueryErrorThrottled:
+		label = "Throttled"
+	case appinsights.QueryErrorLimits:
+		label = "Query limits exceeded"
+	case appinsights.QueryErrorServer:
+		label = "Service error"
+	default:
+		return qe.Error()
+	}
+	s := fmt.Sprintf("%s (%d)", label, qe.StatusCode)
+	if qe.Attempts > 1 {
+		s += fmt.Sprintf(" after %d attempts", qe.Attempts)
+	}
+	if detail := qe.Detail(); detail != "" {
+		s += ": " + detail
+	}
+	if qe.HasPosition() {
+		s += fmt.Sprintf(" (line %d, column %d)", qe.Line, qe.Column)
+	}
+	return s
+}
+
+// queryErrorHint returns the next step for the error kind; appID feeds the 404 hint.
+func queryErrorHint(qe *appinsights.QueryError, appID string) string {
+	switch qe.Kind() {
+	case appinsights.QueryErrorSyntax:
+		return "check pipes '|', quotes and brackets at the marked position. KQL reference: https://learn.microsoft.com/azure/data-explorer/kusto/query/"
+	case appinsights.QueryErrorSemantic:
+		return "a table, column or function is unknown or has the wrong type. names are case-sensitive; BC fields live in customDimensions, e.g. tostring(customDimensions.eventId)."
+	case appinsights.QueryErrorBadRequest:
+		return "check table names and syntax. next: validate the query in the portal (Logs) or KQL docs: https://learn.microsoft.com/azure/azure-monitor/logs/get-started-queries"
+	case appinsights.QueryErrorUnauthorized:
+		return "token scope/tenant mismatch or missing access. run 'login' again and confirm access to the Application Insights resource in the Azure portal."
+	case appinsights.QueryErrorForbidden:
+		return "your account cannot read this resource. ask for the Reader or Log Analytics Reader role on it (Access control (IAM) in https://portal.azure.com), then run 'login' again."
+	case appinsights.QueryErrorNotFound:
+		return fmt.Sprintf("no Application Insights app with ID '%s'. applicationInsightsAppId must be the Application ID from the resource's API Access blade (not the instrumentation key); pick one with 'resources'.", appID)
+	case appinsights.QueryErrorThrottled:
+		return "retry later or reduce result size (e.g., add '| take N'). consider narrowing the time range."
+	case appinsights.QueryErrorLimits:
+		return "the query hit service limits (rows, memory or time). narrow the time range ('time 1h'), filter earlier, summarize or add '| take N', and project fewer columns."
+	case appinsights.QueryErrorServer:
+		return "the service had a transient problem; try again in a moment."
+	default:
+		return ""
+	}
+}
+
+// errorLocationLines renders the failing query line with a caret under column col.
+func errorLocationLines(query string, line, col int) []string {
+	lines := strings.Split(strings.ReplaceAll(query, "\r\n", "\n"), "\n")
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+	text := strings.ReplaceAll(lines[line-1], "\t", " ")
+	runes := []rune(text)
+	if col < 1 {
+		col = 1
+	}
+	if col > len(runes)+1 {
+		col = len(runes) + 1
+	}
+	prefix := fmt.Sprintf("  %d | ", line)
+	return []string{
+		prefix + text,
+		strings.Repeat(" ", len(prefix)+col-1) + "^",
+	}
+}
+
+// showKQLError appends the error of a failed result, with detail for API and
+// local syntax errors.
+func (m *model) showKQLError(res kqlResultMsg) {
+	var qe *appinsights.QueryError
+	if errors.As(res.err, &qe) {
+		m.showQueryError(qe, res.query, res.appID)
+		return
+	}
+	var se *kql.SyntaxError
+	if errors.As(res.err, &se) {
+		m.showSyntaxError(se, res.query)
+		return
+	}
+	m.append(res.err.Error())
+}
+
+// showSyntaxError appends a local validation error with its location; nothing was sent.
+func (m *model) showSyntaxError(se *kql.SyntaxError, query string) {
+	m.append(fmt.Sprintf("Syntax error: %s (line %d, column %d)", se.Msg, se.Pos.Line, se.Pos.Column))
+	for _, l := range errorLocationLines(query, se.Pos.Line, se.Pos.Column) {
+		m.append(l)
+	}
+	if m.mode == modeKQLEditor {
+		m.markRunError(se.Pos.Line, se.Pos.Column)
+	}
+	m.append("The query was not sent; fix it and run again.")
+}
+
+// showQueryError appends the headline, location, hint and request ids for qe.
+func (m *model) showQueryError(qe *appinsights.QueryError, query, appID string) {
+	m.append(queryErrorHeadline(qe))
+	if qe.HasPosition() {
+		for _, l := range errorLocationLines(query, qe.Line, qe.Column) {
+			m.append(l)
+		}
+		if m.mode == modeKQLEditor {
+			m.markRunError(qe.Line, qe.Column)
+		}
+	}
+	if hint := queryErrorHint(qe, appID); hint != "" {
+		m.append("Hint: " + hint)
+	}
+	if qe.RequestID != "" || qe.CorrelationID != "" {
+		m.append(fmt.Sprintf("Request ID: %s · Correlation ID: %s", qe.RequestID, qe.CorrelationID))
+	}
+}
+
+// markRunError marks an error at line and column of the text the last editor
+// run sent, shifted to where that text sits in the buffer.
+func (m *model) markRunError(line, col int) {
+	if line == 1 {
+		col += m.runColumnOffset
+	}
+	m.markEditorError(line+m.runLineOffset, col)
+}
+
+// markEditorError moves the editor cursor to the error and highlights that line
+// until the buffer changes or the next run.
+func (m *model) markEditorError(line, col int) {
+	if line < 1 || line > m.ta.LineCount() {
+		return
+	}
+	moveTextareaCursor(&m.ta, line-1, col-1)
+	m.ta.FocusedStyle.CursorLine = editorErrorLineStyle
+	// Focus re-points the textarea's active style at the updated FocusedStyle
+	m.ta.Focus()
+	m.editorErr = &editorErrorPos{line: line, col: col}
+	logging.Debug("Editor error marked", "line", fmt.Sprintf("%d", line), "column", fmt.Sprintf("%d", col))
+}
+
+// clearEditorError removes the error highlight.
+func (m *model) clearEditorError() {
+	if m.editorErr == nil {
+		return
+	}
+	m.editorErr = nil
+	focused, _ := textarea.DefaultStyles()
+	m.ta.FocusedStyle.CursorLine = focused.CursorLine
+	if m.ta.Focused() {
+		m.ta.Focus()
+	}
+}
+
+// moveTextareaCursor places the cursor at 0-based row/col of the textarea value.
+func moveTextareaCursor(ta *textarea.Model, row, col int) {
+	// Soft-wrapped lines need several CursorUp/Down steps; bound the loops by rune count
+	limit := ta.Length() + ta.LineCount() + 1
+	for i := 0; i < limit && (ta.Line() > 0 || ta.LineInfo().RowOffset > 0); i++ {
+		ta.CursorUp()
+	}
+	for i := 0; i < limit && ta.Line() < row; i++ {
+		ta.CursorDown()
+	}
+	ta.SetCursor(col)
+}
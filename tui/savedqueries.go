@@ -0,0 +1,467 @@
+package tui
+
+// Saved-query library: named KQL templates stored next to the config file
+// ('save <name>', 'run <name> [param=value ...]', 'queries'). Templates may contain
+// {{param}} or {{param=default}} placeholders that are filled in before running.
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+
+	"github.com/FBakkensen/bc-insights-tui/config"
+	"github.com/FBakkensen/bc-insights-tui/internal/util"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// savedQueriesFileVersion is bumped when the on-disk layout changes incompatibly.
+const savedQueriesFileVersion = 1
+
+// savedQuery is one named query template. Params holds defaults for placeholders
+// that have no inline default; inline defaults ({{name=value}}) take precedence.
+type savedQuery struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description,omitempty"`
+	Query       string            `yaml:"query"`
+	Params      map[string]string `yaml:"params,omitempty"`
+	SavedAt     time.Time         `yaml:"savedAt"`
+}
+
+// savedQueriesFile is the YAML document stored at cfg.SavedQueriesFile.
+type savedQueriesFile struct {
+	Version int          `yaml:"version"`
+	Queries []savedQuery `yaml:"queries"`
+}
+
+// savedQueryStore holds saved queries sorted by name. An empty path keeps it in memory only.
+type savedQueryStore struct {
+	path    string
+	queries []savedQuery
+}
+
+var (
+	savedQueryNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	placeholderRe    = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*(?:=([^}]*))?\}\}`)
+)
+
+// loadSavedQueries reads the saved-query file if present. Read or parse failures are
+// logged and yield an empty library so the UI never fails to start because of it.
+func loadSavedQueries(path string) *savedQueryStore {
+	s := &savedQueryStore{path: path}
+	if strings.TrimSpace(path) == "" {
+		return s
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Warn("Saved queries read failed", "path", path, "error", err.Error())
+		}
+		return s
+	}
+	var doc savedQueriesFile
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		logging.Warn("Saved queries parse failed; starting empty", "path", path, "error", err.Error())
+		return s
+	}
+	for _, q := range doc.Queries {
+		if savedQueryNameRe.MatchString(q.Name) && strings.TrimSpace(q.Query) != "" {
+			s.queries = append(s.queries, q)
+		}
+	}
+	s.sort()
+	logging.Debug("Saved queries loaded", "path", path, "count", fmt.Sprintf("%d", len(s.queries)))
+	return s
+}
+
+// validateSavedQueryName reports whether name is usable as a saved-query key.
+func validateSavedQueryName(name string) error {
+	if !savedQueryNameRe.MatchString(name) {
+		return fmt.Errorf("invalid name %q: use letters, digits, '.', '_' or '-' (no spaces)", name)
+	}
+	return nil
+}
+
+func (s *savedQueryStore) sort() {
+	sort.SliceStable(s.queries, func(i, j int) bool {
+		return strings.ToLower(s.queries[i].Name) < strings.ToLower(s.queries[j].Name)
+	})
+}
+
+// len returns the number of saved queries.
+func (s *savedQueryStore) len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.queries)
+}
+
+// get finds a saved query by name (case-insensitive).
+func (s *savedQueryStore) get(name string) (savedQuery, bool) {
+	if s == nil {
+		return savedQuery{}, false
+	}
+	for _, q := range s.queries {
+		if strings.EqualFold(q.Name, name) {
+			return q, true
+		}
+	}
+	return savedQuery{}, false
+}
+
+// put inserts or replaces q (matched by name, case-insensitive) and persists.
+// It returns true when an existing query was replaced.
+func (s *savedQueryStore) put(q savedQuery) (bool, error) {
+	if err := validateSavedQueryName(q.Name); err != nil {
+		return false, err
+	}
+	replaced := false
+	for i := range s.queries {
+		if strings.EqualFold(s.queries[i].Name, q.Name) {
+			// Keep hand-edited metadata when overwriting only the query text
+			if q.Description == "" {
+				q.Description = s.queries[i].Description
+			}
+			if q.Params == nil {
+				q.Params = s.queries[i].Params
+			}
+			s.queries[i] = q
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		s.queries = append(s.queries, q)
+		s.sort()
+	}
+	return replaced, s.save()
+}
+
+// save writes the library atomically (temp file + rename) next to its target.
+func (s *savedQueryStore) save() error {
+	if strings.TrimSpace(s.path) == "" {
+		return nil
+	}
+	b, err := yaml.Marshal(savedQueriesFile{Version: savedQueriesFileVersion, Queries: s.queries})
+	if err != nil {
+		return fmt.Errorf("failed to encode saved queries: %w", err)
+	}
+	if err := util.WriteFileAtomic(s.path, b); err != nil {
+		return fmt.Errorf("failed to save saved queries %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// queryParam is one distinct placeholder in a query template.
+type queryParam struct {
+	Name       string
+	Default    string
+	HasDefault bool
+}
+
+// templateParams lists distinct placeholders in order of first appearance. An inline
+// default wins over a default from defaults (the saved query's params map).
+func templateParams(template string, defaults map[string]string) []queryParam {
+	var out []queryParam
+	seen := map[string]int{}
+	for _, m := range placeholderRe.FindAllStringSubmatchIndex(template, -1) {
+		name := template[m[2]:m[3]]
+		inline := m[4] >= 0
+		if i, ok := seen[name]; ok {
+			if inline && !out[i].HasDefault {
+				out[i].Default, out[i].HasDefault = strings.TrimSpace(template[m[4]:m[5]]), true
+			}
+			continue
+		}
+		p := queryParam{Name: name}
+		if inline {
+			p.Default, p.HasDefault = strings.TrimSpace(template[m[4]:m[5]]), true
+		} else if v, ok := defaults[name]; ok {
+			p.Default, p.HasDefault = v, true
+		}
+		seen[name] = len(out)
+		out = append(out, p)
+	}
+	return out
+}
+
+// expandTemplate substitutes every placeholder with values[name].
+// Values are inserted verbatim; quote them in the template where KQL needs a string.
+func expandTemplate(template string, values map[string]string) string {
+	return placeholderRe.ReplaceAllStringFunc(template, func(s string) string {
+		sub := placeholderRe.FindStringSubmatch(s)
+		if v, ok := values[sub[1]]; ok {
+			return v
+		}
+		return s
+	})
+}
+
+// parseParamArgs parses "name=value" arguments following 'run <name>'.
+// Values may be wrapped in single or double quotes to include spaces.
+func parseParamArgs(args string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, tok := range splitArgs(args) {
+		k, v, ok := strings.Cut(tok, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("expected param=value, got %q", tok)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// splitArgs splits on whitespace while keeping quoted sections together (quotes removed).
+func splitArgs(s string) []string {
+	var out []string
+	var cur strings.Builder
+	var quote rune
+	inTok := false
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inTok = r, true
+		case r == ' ' || r == '\t':
+			if inTok {
+				out = append(out, cur.String())
+				cur.Reset()
+				inTok = false
+			}
+		default:
+			cur.WriteRune(r)
+			inTok = true
+		}
+	}
+	if inTok {
+		out = append(out, cur.String())
+	}
+	return out
+}
+
+// paramPrompt tracks an in-progress chat prompt for placeholder values.
+type paramPrompt struct {
+	label    string // saved query name, or "" for an ad-hoc template
+	template string
+	values   map[string]string
+	pending  []queryParam // placeholders still to be answered, in order
+}
+
+// savedQueryItem adapts savedQuery to list.Item for the 'queries' panel.
+type savedQueryItem struct{ q savedQuery }
+
+var _ list.Item = savedQueryItem{}
+
+func (i savedQueryItem) FilterValue() string { return i.q.Name + " " + i.q.Description }
+func (i savedQueryItem) Title() string       { return i.q.Name }
+
+func (i savedQueryItem) Description() string {
+	desc := i.q.Description
+	if desc == "" {
+		desc = truncateRunes(strings.Join(strings.Fields(i.q.Query), " "), 80)
+	}
+	if params := templateParams(i.q.Query, i.q.Params); len(params) > 0 {
+		desc += " · params: " + paramNames(params)
+	}
+	return desc
+}
+
+// resolveSavedQueriesPath places a relative saved-queries file next to the config file.
+func resolveSavedQueriesPath(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return ""
+	}
+	p, err := config.ResolveDataFilePath(name)
+	if err != nil {
+		logging.Warn("Saved queries path unavailable; library kept in memory", "error", err.Error())
+		return ""
+	}
+	return p
+}
+
+// handleSaveCommand implements 'save <name> [kql]'. Without inline KQL the template of
+// the most recently run query is saved (placeholders intact, not the expanded text).
+func (m model) handleSaveCommand(args string) (tea.Model, tea.Cmd) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if name == "" {
+		m.append("Usage: save <name> [kql]")
+		return m, nil
+	}
+	template := strings.TrimSpace(rest)
+	if template == "" {
+		template = m.lastTemplate
+	}
+	if template == "" {
+		m.append("Nothing to save yet. Run a query first, or use: save <name> <kql>")
+		return m, nil
+	}
+	if m.savedQueries == nil {
+		m.savedQueries = &savedQueryStore{}
+	}
+	replaced, err := m.savedQueries.put(savedQuery{Name: name, Query: template, SavedAt: time.Now().UTC()})
+	if err != nil {
+		logging.Warn("Save query failed", "name", name, "error", err.Error())
+		m.append("Failed to save query: " + err.Error())
+		return m, nil
+	}
+	params := templateParams(template, nil)
+	logging.Info("Saved query", "name", name, "replaced", fmt.Sprintf("%t", replaced), "params", fmt.Sprintf("%d", len(params)))
+	verb := "Saved"
+	if replaced {
+		verb = "Updated"
+	}
+	msg := fmt.Sprintf("%s query '%s'.", verb, name)
+	if len(params) > 0 {
+		msg += fmt.Sprintf(" Parameters: %s.", paramNames(params))
+	}
+	m.append(msg + " Run it with 'run " + name + "'.")
+	return m, nil
+}
+
+// handleRunSavedCommand implements 'run <name> [param=value ...]'.
+func (m model) handleRunSavedCommand(args string) (tea.Model, tea.Cmd) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if name == "" {
+		m.append("Usage: run <name> [param=value ...]")
+		return m, nil
+	}
+	q, ok := m.savedQueries.get(name)
+	if !ok {
+		m.append(fmt.Sprintf("No saved query named '%s'. Type 'queries' to list saved queries.", name))
+		return m, nil
+	}
+	values, err := parseParamArgs(rest)
+	if err != nil {
+		m.append("Invalid parameters: " + err.Error() + ". Usage: run <name> [param=value ...]")
+		return m, nil
+	}
+	return m.startTemplateRun(q.Name, q.Query, q.Params, values)
+}
+
+// startTemplateRun fills placeholders from values and defaults, then runs the query.
+// Placeholders with neither are asked for one at a time in the chat input.
+func (m model) startTemplateRun(label, template string, defaults, values map[string]string) (tea.Model, tea.Cmd) {
+	if m.queryBusy() {
+		return m, nil
+	}
+	params := templateParams(template, defaults)
+	known := map[string]bool{}
+	resolved := map[string]string{}
+	var pending []queryParam
+	for _, p := range params {
+		known[p.Name] = true
+		if v, ok := values[p.Name]; ok {
+			resolved[p.Name] = v
+		} else if p.HasDefault {
+			resolved[p.Name] = p.Default
+		} else {
+			pending = append(pending, p)
+		}
+	}
+	var unknown []string
+	for k := range values {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		m.append("Ignoring unknown parameter(s): " + strings.Join(unknown, ", "))
+	}
+	if len(pending) == 0 {
+		return m.runTemplate(label, template, resolved)
+	}
+	m.paramPrompt = &paramPrompt{label: label, template: template, values: resolved, pending: pending}
+	m.askNextParam()
+	return m, nil
+}
+
+// askNextParam prints the prompt for the first pending placeholder.
+func (m *model) askNextParam() {
+	p := m.paramPrompt.pending[0]
+	m.append(fmt.Sprintf("Value for {{%s}} (Enter to confirm, Esc to cancel):", p.Name))
+}
+
+// handleParamInput consumes one chat input line as the value for the pending placeholder.
+func (m model) handleParamInput(input string) (tea.Model, tea.Cmd) {
+	pp := m.paramPrompt
+	p := pp.pending[0]
+	if input == "" {
+		m.append(fmt.Sprintf("A value is required for {{%s}}.", p.Name))
+		return m, nil
+	}
+	m.append(fmt.Sprintf("  %s = %s", p.Name, input))
+	pp.values[p.Name] = input
+	pp.pending = pp.pending[1:]
+	if len(pp.pending) > 0 {
+		m.askNextParam()
+		return m, nil
+	}
+	m.paramPrompt = nil
+	return m.runTemplate(pp.label, pp.template, pp.values)
+}
+
+// cancelParamPrompt abandons an in-progress placeholder prompt.
+func (m *model) cancelParamPrompt() {
+	m.paramPrompt = nil
+	m.ta.Reset()
+	m.append("Canceled parameter entry.")
+}
+
+// runTemplate expands the template and runs it from chat mode.
+func (m model) runTemplate(label, template string, values map[string]string) (tea.Model, tea.Cmd) {
+	if m.queryBusy() {
+		return m, nil
+	}
+	query := strings.TrimSpace(expandTemplate(template, values))
+	m.lastTemplate = template
+	if label != "" {
+		logging.Info("Running saved query", "name", label, "params", fmt.Sprintf("%d", len(values)))
+		m.append(fmt.Sprintf("Running saved query '%s'…", label))
+	} else {
+		m.append("Running query…")
+	}
+	cmd := m.runKQLCmd(query)
+	return m, cmd
+}
+
+// openSavedQueriesPanel lists the saved-query library in the list panel.
+func (m model) openSavedQueriesPanel() (tea.Model, tea.Cmd) {
+	if m.savedQueries.len() == 0 {
+		m.append("No saved queries yet. Run a query, then 'save <name>' (or 'save <name> <kql>').")
+		return m, nil
+	}
+	items := make([]list.Item, 0, m.savedQueries.len())
+	for _, q := range m.savedQueries.queries {
+		items = append(items, savedQueryItem{q: q})
+	}
+	logging.Debug("Opening saved queries panel", "count", fmt.Sprintf("%d", len(items)))
+	m.mode = modeListSavedQueries
+	m.list.Title = titleSavedQueries
+	m.list.ResetFilter()
+	m.list.SetItems(items)
+	m.list.Select(0)
+	m.append(fmt.Sprintf("Opened saved queries (%d). Enter runs the query, 'e' loads it into the editor.", len(items)))
+	return m, nil
+}
+
+// paramNames joins placeholder names for display.
+func paramNames(params []queryParam) string {
+	names := make([]string, 0, len(params))
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,295 @@
+package tui
+
+// Sorting: 's' in the results table sorts by the column under the column cursor,
+// cycling ascending → descending → API order. Values compare by type: the API
+// column type when the header is a raw column, otherwise inferred from the values
+// (numbers, BC durations like 00:00:01.234, timestamps, then text). Empty cells
+// always sort last. The table keeps a row order mapping back to lastRows so
+// details and copies use the right row; the '&' filter shares it.
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/internal/util"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// tableSort is the active sort of the results table; an empty column means API order.
+type tableSort struct {
+	column string // display header, matched case-insensitively
+	desc   bool
+}
+
+const (
+	sortAscMark  = "▲"
+	sortDescMark = "▼"
+)
+
+type sortKind int
+
+const (
+	sortText sortKind = iota
+	sortNumber
+	sortDuration
+	sortTime
+)
+
+// toggleSort cycles the sort of the column under the cursor and rebuilds the table
+// with the cursor kept on the same result row.
+func (m *model) toggleSort() {
+	if m.tblCol < 0 || m.tblCol >= len(m.lastDisplayHeaders) {
+		return
+	}
+	header := m.lastDisplayHeaders[m.tblCol]
+	switch {
+	case !strings.EqualFold(m.tblSort.column, header):
+		m.tblSort = tableSort{column: header}
+	case !m.tblSort.desc:
+		m.tblSort.desc = true
+	default:
+		m.tblSort = tableSort{}
+	}
+	m.refreshTable(m.rowIndex(m.tbl.Cursor()))
+	if m.tblSort.column == "" {
+		m.statusNote = "sort: API order"
+	} else {
+		m.statusNote = "sort: " + header + " " + sortMark(m.tblSort.desc)
+	}
+	logging.Info("Results table sorted",
+		"table", util.FirstNonEmpty(m.lastTable, primaryResultName),
+		"column", m.tblSort.column,
+		"desc", fmt.Sprintf("%t", m.tblSort.desc),
+	)
+}
+
+// resetTableView drops the sort, filter and search of the results table.
+func (m *model) resetTableView() {
+	m.tblSort = tableSort{}
+	m.tblOrder = nil
+	m.tblColOff = 0
+	m.tblFind = tableFind{}
+}
+
+func sortMark(desc bool) string {
+	if desc {
+		return sortDescMark
+	}
+	return sortAscMark
+}
+
+// refreshTable rebuilds the interactive table and puts the cursor on lastRows[row].
+func (m *model) refreshTable(row int) {
+	m.initInteractiveTable()
+	m.tbl.SetCursor(max(m.tableRowOf(row), 0))
+}
+
+// orderValid reports whether tblOrder describes the current lastRows; an order
+// built before lastRows changed is ignored until the table is rebuilt.
+func (m model) orderValid() bool {
+	return m.tblOrder != nil && m.tblOrderOf == len(m.lastRows)
+}
+
+// rowIndex maps a table row to its index in lastRows, or -1.
+func (m model) rowIndex(tableRow int) int {
+	if !m.orderValid() {
+		return tableRow
+	}
+	if tableRow >= 0 && tableRow < len(m.tblOrder) {
+		return m.tblOrder[tableRow]
+	}
+	return -1
+}
+
+// shownRows returns the lastRows indexes in table order.
+func (m model) shownRows() []int {
+	if m.orderValid() {
+		return m.tblOrder
+	}
+	return allRows(len(m.lastRows))
+}
+
+func allRows(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+// tableRowOf maps an index in lastRows to its table row; rows no longer present
+// map to the first row.
+func (m model) tableRowOf(row int) int {
+	if !m.orderValid() {
+		return row
+	}
+	if i := slices.Index(m.tblOrder, row); i >= 0 {
+		return i
+	}
+	return 0
+}
+
+// sortColumnIndex returns the header index of the active sort, or -1.
+func (m model) sortColumnIndex(headers []string) int {
+	if m.tblSort.column == "" {
+		return -1
+	}
+	return slices.IndexFunc(headers, func(h string) bool { return strings.EqualFold(h, m.tblSort.column) })
+}
+
+// sortedRowOrder orders the data row indexes rows by column col.
+// Ties keep API order.
+func sortedRowOrder(data [][]string, rows []int, col int, kind sortKind, desc bool) []int {
+	type sortKey struct {
+		idx   int
+		empty bool
+		num   float64
+		text  string
+	}
+	keys := make([]sortKey, len(rows))
+	for i, idx := range rows {
+		r := data[idx]
+		k := sortKey{idx: idx}
+		v := ""
+		if col < len(r) {
+			v = strings.TrimSpace(r[col])
+		}
+		k.empty = v == ""
+		if !k.empty {
+			if n, ok := sortNumericValue(v, kind); ok {
+				k.num = n
+			} else {
+				k.text = strings.ToLower(v)
+			}
+		}
+		keys[i] = k
+	}
+	slices.SortStableFunc(keys, func(a, b sortKey) int {
+		if a.empty || b.empty {
+			// empty cells last in either direction
+			if a.empty == b.empty {
+				return 0
+			}
+			if a.empty {
+				return 1
+			}
+			return -1
+		}
+		c := cmp.Compare(a.num, b.num)
+		if c == 0 {
+			c = strings.Compare(a.text, b.text)
+		}
+		if desc {
+			c = -c
+		}
+		return c
+	})
+	order := make([]int, len(keys))
+	for i, k := range keys {
+		order[i] = k.idx
+	}
+	return order
+}
+
+// sortNumericValue converts v to a comparable number for the numeric kinds.
+func sortNumericValue(v string, kind sortKind) (float64, bool) {
+	switch kind {
+	case sortNumber:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	case sortDuration:
+		d, ok := parseBCDuration(v)
+		return float64(d), ok
+	case sortTime:
+		t, ok := parseSortTime(v)
+		return float64(t.UnixNano()), ok
+	}
+	return 0, false
+}
+
+// columnSortKind picks the comparison for a display column: the API type when
+// the header names a raw column, otherwise the narrowest kind every non-empty
+// value parses as.
+func columnSortKind(header string, columns []appinsights.Column, data [][]string, col int) sortKind {
+	for _, c := range columns {
+		if !strings.EqualFold(c.Name, header) {
+			continue
+		}
+		switch strings.ToLower(c.Type) {
+		case "long", "int", "real", "decimal", "double":
+			return sortNumber
+		case "datetime":
+			return sortTime
+		case "timespan":
+			return sortDuration
+		case "string", "bool", "boolean", "guid":
+			return sortText
+		}
+	}
+	kinds := []sortKind{sortNumber, sortDuration, sortTime}
+	seen := false
+	for _, r := range data {
+		if col >= len(r) || strings.TrimSpace(r[col]) == "" {
+			continue
+		}
+		seen = true
+		v := strings.TrimSpace(r[col])
+		kinds = slices.DeleteFunc(kinds, func(k sortKind) bool {
+			_, ok := sortNumericValue(v, k)
+			return !ok
+		})
+		if len(kinds) == 0 {
+			return sortText
+		}
+	}
+	if !seen {
+		return sortText
+	}
+	return kinds[0]
+}
+
+// parseBCDuration parses timespan text as Business Central logs it:
+// [-][d.]hh:mm:ss[.fffffff], e.g. 00:00:01.234.
+func parseBCDuration(v string) (time.Duration, bool) {
+	neg := strings.HasPrefix(v, "-")
+	v = strings.TrimPrefix(v, "-")
+	parts := strings.Split(v, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	var days int64
+	if d, h, ok := strings.Cut(parts[0], "."); ok {
+		n, err := strconv.ParseInt(d, 10, 64)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		days, parts[0] = n, h
+	}
+	h, herr := strconv.ParseInt(parts[0], 10, 64)
+	mins, merr := strconv.ParseInt(parts[1], 10, 64)
+	secs, serr := strconv.ParseFloat(parts[2], 64)
+	if herr != nil || merr != nil || serr != nil || h < 0 || mins < 0 || mins > 59 || secs < 0 || secs >= 60 {
+		return 0, false
+	}
+	d := time.Duration(days)*24*time.Hour + time.Duration(h)*time.Hour + time.Duration(mins)*time.Minute + time.Duration(math.Round(secs*float64(time.Second)))
+	if neg {
+		d = -d
+	}
+	return d, true
+}
+
+// parseSortTime parses the timestamp formats seen in results.
+func parseSortTime(v string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,328 @@
+package tui
+
+// Search and filter in the results table: '/' searches and '&' filters (as in
+// less), both incrementally while typing in a prompt that replaces the input
+// line. Search marks matching cells with » and n/N jump between matching rows;
+// the filter hides rows that do not match, without re-running the query.
+//
+// Patterns match the displayed cells, the raw columns and every flattened
+// customDimensions value, case-insensitively:
+//
+//	text        any value contains text
+//	key=value   the column or customDimensions key equals value
+//	~regexp     any value matches the regular expression
+//	key~regexp  the column or key matches the regular expression
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/internal/telemetry"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// searchMark prefixes table cells that match the search.
+const searchMark = "»"
+
+// tableFind is the search and filter state of the results table.
+type tableFind struct {
+	prompt  textinput.Model
+	typing  rune   // '/' or '&' while the prompt is open
+	saved   string // pattern before the prompt opened, restored by Esc
+	start   int    // table row when the search prompt opened
+	search  *rowMatcher
+	filter  *rowMatcher
+	matches []int // table rows matching the search
+	err     string
+}
+
+// rowMatcher is a compiled search or filter pattern.
+type rowMatcher struct {
+	pattern string
+	key     string // column or customDimensions key; "" matches any field
+	value   string // lower-cased text
+	exact   bool   // key=value compares the whole value
+	re      *regexp.Regexp
+}
+
+var matcherKeyRe = regexp.MustCompile(`^[A-Za-z_][\w.\-]*$`)
+
+// parseRowMatcher compiles a search or filter pattern.
+func parseRowMatcher(pattern string) (*rowMatcher, error) {
+	rm := &rowMatcher{pattern: pattern}
+	expr := ""
+	isRegex := false
+	if i := strings.IndexAny(pattern, "=~"); i >= 0 && (i == 0 && pattern[0] == '~' || matcherKeyRe.MatchString(pattern[:i])) {
+		rm.key = pattern[:i]
+		expr = pattern[i+1:]
+		isRegex = pattern[i] == '~'
+		rm.exact = !isRegex
+	} else {
+		expr = pattern
+	}
+	if isRegex {
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		rm.re = re
+		return rm, nil
+	}
+	rm.value = strings.ToLower(strings.TrimSpace(expr))
+	return rm, nil
+}
+
+// matchField reports whether the field key/value matches.
+func (rm *rowMatcher) matchField(key, value string) bool {
+	if rm.key != "" && !strings.EqualFold(rm.key, key) {
+		return false
+	}
+	switch {
+	case rm.re != nil:
+		return rm.re.MatchString(value)
+	case rm.exact:
+		return strings.EqualFold(strings.TrimSpace(value), rm.value)
+	default:
+		return strings.Contains(strings.ToLower(value), rm.value)
+	}
+}
+
+// matchFields reports whether any field matches.
+func (rm *rowMatcher) matchFields(fields []telemetry.DetailField) bool {
+	for _, f := range fields {
+		if rm.matchField(f.Key, f.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// rowSearchFields returns what a pattern is matched against for one row: the
+// displayed cells, the raw columns and the flattened customDimensions values.
+func rowSearchFields(headers, cells []string, columns []appinsights.Column, row []interface{}) []telemetry.DetailField {
+	fields := make([]telemetry.DetailField, 0, len(headers)+len(columns))
+	for i, h := range headers {
+		if i < len(cells) && cells[i] != "" {
+			fields = append(fields, telemetry.DetailField{Key: h, Value: cells[i]})
+		}
+	}
+	for i, c := range columns {
+		if i >= len(row) || row[i] == nil || strings.EqualFold(c.Name, "customDimensions") {
+			continue
+		}
+		fields = append(fields, telemetry.DetailField{Key: c.Name, Value: fmt.Sprint(row[i])})
+	}
+	_, _, custom := telemetry.BuildDetails(columns, row)
+	return append(fields, custom...)
+}
+
+// filterRows returns the data rows the filter keeps, or nil without a filter.
+func (m *model) filterRows(headers []string, data [][]string) []int {
+	if m.tblFind.filter == nil {
+		return nil
+	}
+	kept := make([]int, 0, len(data))
+	for i := range data {
+		if m.tblFind.filter.matchFields(rowSearchFields(headers, data[i], m.lastColumns, m.lastRows[i])) {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// markSearchMatches prefixes the matching visible cells of a table row and
+// records the row as a match; tr[k] shows headers[visible[k]]. A row matching
+// only in hidden values gets its first cell marked.
+func (m *model) markSearchMatches(tableRow int, tr []string, visible []int, headers, cells []string, row []interface{}) {
+	rm := m.tblFind.search
+	if rm == nil {
+		return
+	}
+	if !rm.matchFields(rowSearchFields(headers, cells, m.lastColumns, row)) {
+		return
+	}
+	m.tblFind.matches = append(m.tblFind.matches, tableRow)
+	marked := false
+	for k, j := range visible {
+		if k < len(tr) && j < len(cells) && cells[j] != "" && rm.matchField(headers[j], cells[j]) {
+			tr[k] = searchMark + tr[k]
+			marked = true
+		}
+	}
+	if !marked && len(tr) > 0 {
+		tr[0] = searchMark + tr[0]
+	}
+}
+
+// openFindPrompt opens the search ('/') or filter ('&') prompt.
+func (m *model) openFindPrompt(kind rune) tea.Cmd {
+	ti := textinput.New()
+	ti.Prompt = string(kind)
+	ti.Placeholder = "text, key=value, ~regexp or key~regexp"
+	if w := m.ta.Width(); w > 2 {
+		ti.Width = w - 2
+	}
+	m.tblFind.saved = ""
+	switch {
+	case kind == '/' && m.tblFind.search != nil:
+		m.tblFind.saved = m.tblFind.search.pattern
+	case kind == '&' && m.tblFind.filter != nil:
+		m.tblFind.saved = m.tblFind.filter.pattern
+	}
+	ti.SetValue(m.tblFind.saved)
+	ti.CursorEnd()
+	m.tblFind.prompt = ti
+	m.tblFind.typing = kind
+	m.tblFind.start = m.tbl.Cursor()
+	m.tblFind.err = ""
+	return m.tblFind.prompt.Focus()
+}
+
+// handleFindPromptKey edits the search or filter prompt, applying the pattern as it is typed.
+func (m model) handleFindPromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	kind := m.tblFind.typing
+	switch msg.String() {
+	case keyEsc:
+		m.tblFind.typing = 0
+		m.applyFind(kind, m.tblFind.saved)
+		m.tblFind.err = ""
+		return m, nil
+	case keyEnter:
+		m.tblFind.typing = 0
+		m.tblFind.prompt.Blur()
+		if m.tblFind.err != "" {
+			m.statusNote = m.tblFind.err
+			m.applyFind(kind, m.tblFind.saved)
+			m.tblFind.err = ""
+			return m, nil
+		}
+		m.logFind(kind)
+		return m, nil
+	}
+	before := m.tblFind.prompt.Value()
+	var cmd tea.Cmd
+	m.tblFind.prompt, cmd = m.tblFind.prompt.Update(msg)
+	if v := m.tblFind.prompt.Value(); v != before {
+		m.applyFind(kind, v)
+	}
+	return m, cmd
+}
+
+// applyFind sets the search or filter pattern and rebuilds the table. An invalid
+// pattern leaves the previous one in place and is reported in the status line.
+func (m *model) applyFind(kind rune, pattern string) {
+	var rm *rowMatcher
+	if strings.TrimSpace(pattern) != "" {
+		var err error
+		if rm, err = parseRowMatcher(pattern); err != nil {
+			m.tblFind.err = err.Error()
+			return
+		}
+	}
+	m.tblFind.err = ""
+	selected := m.rowIndex(m.tbl.Cursor())
+	if kind == '&' {
+		m.tblFind.filter = rm
+		m.refreshTable(selected)
+		return
+	}
+	m.tblFind.search = rm
+	m.refreshTable(selected)
+	if rm != nil {
+		m.tbl.SetCursor(m.tblFind.start)
+		m.jumpToMatch(0)
+	}
+}
+
+// jumpToMatch moves the cursor to the next (dir > 0) or previous (dir < 0)
+// matching row, wrapping around; dir 0 stays on a matching cursor row.
+func (m *model) jumpToMatch(dir int) {
+	matches := m.tblFind.matches
+	if m.tblFind.search == nil {
+		m.statusNote = "no search; press / to search"
+		return
+	}
+	if len(matches) == 0 {
+		m.statusNote = "no match for " + m.tblFind.search.pattern
+		return
+	}
+	cur := m.tbl.Cursor()
+	next := -1
+	switch {
+	case dir < 0:
+		next = matches[len(matches)-1]
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i] < cur {
+				next = matches[i]
+				break
+			}
+		}
+	default:
+		next = matches[0]
+		for _, r := range matches {
+			if r > cur || (dir == 0 && r == cur) {
+				next = r
+				break
+			}
+		}
+	}
+	m.tbl.SetCursor(next)
+}
+
+// findStatus describes the active search and filter for the status line.
+func (m model) findStatus() string {
+	var parts []string
+	if f := m.tblFind.filter; f != nil {
+		parts = append(parts, fmt.Sprintf("filter %s: %d of %d rows", f.pattern, len(m.tbl.Rows()), len(m.lastRows)))
+	}
+	if s := m.tblFind.search; s != nil {
+		pos := 0
+		for i, r := range m.tblFind.matches {
+			if r == m.tbl.Cursor() {
+				pos = i + 1
+			}
+		}
+		if pos > 0 {
+			parts = append(parts, fmt.Sprintf("/%s: match %d of %d · n/N", s.pattern, pos, len(m.tblFind.matches)))
+		} else {
+			parts = append(parts, fmt.Sprintf("/%s: %d matches · n/N", s.pattern, len(m.tblFind.matches)))
+		}
+	}
+	if m.tblFind.err != "" {
+		parts = append(parts, m.tblFind.err)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// clearFind drops the search and filter; it reports whether either was active.
+func (m *model) clearFind() bool {
+	if m.tblFind.search == nil && m.tblFind.filter == nil {
+		return false
+	}
+	selected := m.rowIndex(m.tbl.Cursor())
+	m.tblFind = tableFind{}
+	m.refreshTable(selected)
+	m.statusNote = "search and filter cleared"
+	return true
+}
+
+func (m model) logFind(kind rune) {
+	if kind == '&' {
+		pattern := ""
+		if m.tblFind.filter != nil {
+			pattern = m.tblFind.filter.pattern
+		}
+		logging.Info("Results table filtered", "pattern", pattern, "rows", fmt.Sprintf("%d", len(m.tbl.Rows())), "of", fmt.Sprintf("%d", len(m.lastRows)))
+		return
+	}
+	pattern := ""
+	if m.tblFind.search != nil {
+		pattern = m.tblFind.search.pattern
+	}
+	logging.Info("Results table searched", "pattern", pattern, "matches", fmt.Sprintf("%d", len(m.tblFind.matches)))
+}
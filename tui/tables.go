@@ -0,0 +1,146 @@
+package tui
+
+// Multiple result tables: a response may carry several tables (';'-separated
+// statements, fork, 'as' named results). All are kept; one is active at a time and
+// mirrored into lastColumns/lastRows/lastTable/lastDisplayHeaders.
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	util "github.com/FBakkensen/bc-insights-tui/internal/util"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+const primaryResultName = "PrimaryResult"
+
+// resultTable is one table of the last response. headers holds its ranked display
+// headers and is filled the first time the table becomes active.
+type resultTable struct {
+	name    string
+	columns []appinsights.Column
+	rows    [][]interface{}
+	headers []string
+}
+
+// primaryTableIndex prefers PrimaryResult, then the first table with columns.
+func primaryTableIndex(tables []appinsights.Table) int {
+	for i, t := range tables {
+		if strings.EqualFold(t.Name, primaryResultName) {
+			return i
+		}
+	}
+	for i, t := range tables {
+		if len(t.Columns) > 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// tablesFromResult returns every table of res; results built without a table list
+// (older call sites and tests) are treated as a single table.
+func tablesFromResult(res kqlResultMsg) []appinsights.Table {
+	if len(res.tables) > 0 {
+		return res.tables
+	}
+	if len(res.columns) == 0 && len(res.rows) == 0 {
+		return nil
+	}
+	return []appinsights.Table{{Name: res.tableName, Columns: res.columns, Rows: res.rows}}
+}
+
+// setResultTables replaces the stored tables and activates index active.
+func (m *model) setResultTables(tables []appinsights.Table, active int) {
+	m.lastTables = make([]resultTable, 0, len(tables))
+	for _, t := range tables {
+		m.lastTables = append(m.lastTables, resultTable{name: t.Name, columns: t.Columns, rows: t.Rows})
+	}
+	m.activeTable = -1
+	m.resetTableView()
+	m.activateTable(active)
+}
+
+// activateTable makes table i current, computing its ranked headers on first use.
+func (m *model) activateTable(i int) {
+	if i < 0 || i >= len(m.lastTables) {
+		return
+	}
+	if m.activeTable >= 0 && m.activeTable < len(m.lastTables) {
+		m.lastTables[m.activeTable].headers = m.lastDisplayHeaders
+	}
+	t := &m.lastTables[i]
+	if t.headers == nil {
+		t.headers = m.layoutHeaders(t.columns, t.rows)
+	}
+	m.activeTable = i
+	m.lastColumns = t.columns
+	m.lastRows = t.rows
+	m.lastTable = t.name
+	m.lastDisplayHeaders = t.headers
+}
+
+// renderTableSnapshots renders a heading line and snapshot for every table,
+// leaving the active table unchanged.
+func (m *model) renderTableSnapshots() []string {
+	active := m.activeTable
+	var out []string
+	for i := range m.lastTables {
+		m.activateTable(i)
+		t := m.lastTables[i]
+		out = append(out, fmt.Sprintf("── %s · %d rows ──", util.FirstNonEmpty(t.name, primaryResultName), len(t.rows)))
+		if s := m.renderSnapshot(t.columns, t.rows); s != "" {
+			out = append(out, s)
+		}
+	}
+	m.activateTable(active)
+	return out
+}
+
+// tablesSummary lists all tables with row counts for the scrollback.
+func (m *model) tablesSummary() string {
+	parts := make([]string, 0, len(m.lastTables))
+	for _, t := range m.lastTables {
+		parts = append(parts, fmt.Sprintf("%s (%d rows)", util.FirstNonEmpty(t.name, primaryResultName), len(t.rows)))
+	}
+	return fmt.Sprintf("Result tables (%d): %s. In the table view (F6) press Tab / Shift+Tab to switch.", len(m.lastTables), strings.Join(parts, ", "))
+}
+
+// switchTable cycles the interactive view to the next (delta > 0) or previous table.
+func (m model) switchTable(delta int) (tea.Model, tea.Cmd) {
+	n := len(m.lastTables)
+	if n < 2 {
+		return m, nil
+	}
+	next := ((m.activeTable+delta)%n + n) % n
+	m.activateTable(next)
+	m.resetTableView()
+	m.initInteractiveTable()
+	logging.Info("Result table switched",
+		"table", util.FirstNonEmpty(m.lastTable, primaryResultName),
+		"index", fmt.Sprintf("%d", next),
+		"rows", fmt.Sprintf("%d", len(m.lastRows)),
+	)
+	return m, nil
+}
+
+// tableStatus describes the active table when more than one is available.
+func (m model) tableStatus() string {
+	if len(m.lastTables) < 2 || m.activeTable < 0 {
+		return ""
+	}
+	return fmt.Sprintf("table %d/%d: %s · Tab/Shift+Tab to switch", m.activeTable+1, len(m.lastTables), util.FirstNonEmpty(m.lastTable, primaryResultName))
+}
+
+// anyTableHasData reports whether at least one table has columns or rows.
+func anyTableHasData(tables []appinsights.Table) bool {
+	for _, t := range tables {
+		if len(t.Columns) > 0 || len(t.Rows) > 0 {
+			return true
+		}
+	}
+	return false
+}
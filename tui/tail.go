@@ -0,0 +1,373 @@
+package tui
+
+// Live tail: 'tail [filter | saved-query [param=value ...]]' re-polls App Insights
+// every tailIntervalSeconds for rows newer than the newest timestamp seen so far,
+// drops repeats by itemId and appends the rest to the interactive table.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+const (
+	tailDefaultSource = "traces"
+	// tailMaxRows caps the rows kept while tailing; the oldest rows are dropped first.
+	tailMaxRows = 5000
+	// tailIngestionColumn is added to every poll to measure ingestion lag.
+	tailIngestionColumn = "tailIngestionTime"
+)
+
+// tailState tracks a running 'tail'.
+type tailState struct {
+	active   bool
+	gen      int    // bumped on every start/stop; stale ticks and results are dropped
+	label    string // what is tailed, for messages
+	query    string // base query without the cursor
+	maxSeen  time.Time
+	seenIDs  map[string]struct{} // row ids at maxSeen; the next poll repeats that timestamp
+	polls    int
+	total    int
+	lag      time.Duration // ingestion lag of the newest row
+	hasLag   bool
+	inflight bool
+}
+
+// tailTickMsg starts the next poll of tail generation gen.
+type tailTickMsg struct{ gen int }
+
+// tailBaseQuery turns the 'tail' argument into the base query: empty tails traces,
+// an expression starting with '|' is appended as-is, anything else becomes a where clause.
+func tailBaseQuery(filter string) string {
+	filter = strings.TrimSpace(filter)
+	switch {
+	case filter == "":
+		return tailDefaultSource
+	case strings.HasPrefix(filter, "|"):
+		return tailDefaultSource + "\n" + filter
+	default:
+		return tailDefaultSource + "\n| where " + filter
+	}
+}
+
+// buildTailQuery returns the poll query: the newest take rows on the first poll,
+// then rows at or after the newest timestamp seen, oldest first.
+func buildTailQuery(base string, since time.Time, take int) string {
+	q := strings.TrimRight(strings.TrimSpace(base), "; \n")
+	q += "\n| extend " + tailIngestionColumn + " = ingestion_time()"
+	if since.IsZero() {
+		return fmt.Sprintf("%s\n| order by timestamp desc\n| take %d", q, take)
+	}
+	return fmt.Sprintf("%s\n| where timestamp >= datetime(%s)\n| order by timestamp asc\n| take %d", q, since.UTC().Format(time.RFC3339Nano), take)
+}
+
+// tailInterval returns the configured poll interval.
+func (m model) tailInterval() time.Duration {
+	if m.cfg.TailIntervalSeconds > 0 {
+		return time.Duration(m.cfg.TailIntervalSeconds) * time.Second
+	}
+	return 5 * time.Second
+}
+
+// handleTailCommand implements 'tail', 'tail <filter>', 'tail <saved> [param=value ...]' and 'tail stop'.
+func (m model) handleTailCommand(args string) (tea.Model, tea.Cmd) {
+	args = strings.TrimSpace(args)
+	if args == "stop" || args == "off" {
+		if !m.tail.active {
+			m.append("Tail is not running.")
+			return m, nil
+		}
+		m.stopTail("stopped")
+		return m, nil
+	}
+	label, base := "traces", tailBaseQuery(args)
+	name, rest, _ := strings.Cut(args, " ")
+	if q, ok := m.savedQueries.get(name); ok {
+		values, err := parseParamArgs(rest)
+		if err != nil {
+			m.append("Invalid parameters: " + err.Error() + ". Usage: tail <saved-query> [param=value ...]")
+			return m, nil
+		}
+		var missing []string
+		for _, p := range templateParams(q.Query, q.Params) {
+			if _, ok := values[p.Name]; ok {
+				continue
+			}
+			if p.HasDefault {
+				values[p.Name] = p.Default
+			} else {
+				missing = append(missing, p.Name)
+			}
+		}
+		if len(missing) > 0 {
+			m.append(fmt.Sprintf("Saved query '%s' needs values for: %s. Usage: tail %s param=value ...", q.Name, strings.Join(missing, ", "), q.Name))
+			return m, nil
+		}
+		label, base = "saved query '"+q.Name+"'", expandTemplate(q.Query, values)
+	} else if args != "" {
+		label = "traces where " + strings.TrimPrefix(args, "|")
+	}
+	if m.runningKQL {
+		m.append("A query is already running. Wait for it or 'cancel' it, then start tail.")
+		return m, nil
+	}
+	if m.tail.active {
+		m.stopTail("restarted")
+	}
+	m.tail = tailState{active: true, gen: m.tail.gen + 1, label: label, query: base, seenIDs: map[string]struct{}{}}
+	logging.Info("Tail started",
+		"label", label,
+		"interval_s", fmt.Sprintf("%d", int(m.tailInterval().Seconds())),
+	)
+	m.append(fmt.Sprintf("Tailing %s every %s. 'tail stop' (or Esc in chat) stops.", label, m.tailInterval()))
+	cmd := m.tailPollCmd()
+	return m, cmd
+}
+
+// tailPollCmd runs the next poll of the active tail.
+func (m *model) tailPollCmd() tea.Cmd {
+	m.tail.inflight = true
+	query := buildTailQuery(m.tail.query, m.tail.maxSeen, m.fetchSize()+len(m.tail.seenIDs))
+	// The cursor bounds every poll after the first, so no API timespan is sent
+	return m.kqlCmd(query, appinsights.Timespan{}, kqlRunTail)
+}
+
+// tailTickCmd schedules the next poll.
+func (m model) tailTickCmd() tea.Cmd {
+	gen := m.tail.gen
+	return tea.Tick(m.tailInterval(), func(time.Time) tea.Msg { return tailTickMsg{gen: gen} })
+}
+
+// handleTailTick starts a poll unless the tail was stopped or another query is running.
+func (m model) handleTailTick(msg tailTickMsg) (tea.Model, tea.Cmd) {
+	if !m.tail.active || msg.gen != m.tail.gen {
+		return m, nil
+	}
+	if m.runningKQL {
+		return m, m.tailTickCmd()
+	}
+	cmd := m.tailPollCmd()
+	return m, cmd
+}
+
+// stopTail ends the tail; an in-flight poll is canceled and its result ignored.
+func (m *model) stopTail(reason string) {
+	if !m.tail.active {
+		return
+	}
+	if m.tail.inflight && m.runningKQL {
+		if m.cancelKQL != nil {
+			m.cancelKQL()
+		}
+		m.runningKQL = false
+		m.cancelKQL = nil
+		m.canceling = false
+		m.retryStatus = ""
+	}
+	logging.Info("Tail stopped",
+		"reason", reason,
+		"polls", fmt.Sprintf("%d", m.tail.polls),
+		"rows", fmt.Sprintf("%d", m.tail.total),
+	)
+	m.append(fmt.Sprintf("Tail %s after %d polls · %d rows.", reason, m.tail.polls, m.tail.total))
+	m.tail.active = false
+	m.tail.inflight = false
+	m.tail.gen++
+}
+
+// handleKQLTail merges one poll into the results and schedules the next.
+func (m model) handleKQLTail(res kqlResultMsg) (tea.Model, tea.Cmd) {
+	m.tail.inflight = false
+	if res.canceled {
+		m.stopTail("canceled")
+		return m, nil
+	}
+	if res.err != nil {
+		logging.Error("Tail poll failed", "error", res.err.Error())
+		var qe *appinsights.QueryError
+		if errors.As(res.err, &qe) && !tailRecoverable(qe) {
+			m.showKQLError(res)
+			m.stopTail("stopped on error")
+			return m, nil
+		}
+		m.append(fmt.Sprintf("Tail poll failed: %s; trying again in %s.", res.err.Error(), m.tailInterval()))
+		return m, m.tailTickCmd()
+	}
+	tsCol := timestampColumn(res.columns)
+	if tsCol < 0 {
+		m.append("Tail needs a timestamp column; the query returned none.")
+		m.stopTail("stopped")
+		return m, nil
+	}
+	first := m.tail.polls == 0
+	m.tail.polls++
+	rows := m.newTailRows(res.columns, res.rows, tsCol)
+	if first {
+		m.startTailResults(res, rows)
+	} else if len(rows) > 0 && len(m.lastRows) == 0 {
+		// Nothing was there on the first poll; these rows start the table
+		m.startTailResults(res, rows)
+	} else if len(rows) > 0 {
+		if !sameColumns(res.columns, m.lastColumns) {
+			m.append("Tail query returned different columns; restart tail.")
+			m.stopTail("stopped")
+			return m, nil
+		}
+		follow := m.mode != modeTableResults || m.atTableEnd()
+		m.appendRows(rows)
+		m.trimTailRows()
+		if follow && m.mode == modeTableResults {
+			m.tbl.GotoBottom()
+		}
+	}
+	m.tail.total += len(rows)
+	if len(rows) > 0 {
+		m.rememberCompletionNames(res.appID)
+	}
+	if lag, ok := tailLag(res.columns, rows, tsCol); ok {
+		m.tail.lag, m.tail.hasLag = lag, true
+	}
+	logging.Debug("Tail poll",
+		"poll", fmt.Sprintf("%d", m.tail.polls),
+		"new_rows", fmt.Sprintf("%d", len(rows)),
+		"duration_ms", fmt.Sprintf("%d", res.duration.Milliseconds()),
+	)
+	if len(rows) > 0 && m.mode != modeTableResults && m.mode != modeDetails {
+		m.append(fmt.Sprintf("tail: +%d rows · %d rows total%s", len(rows), len(m.lastRows), m.tailLagText()))
+		if m.followTail || m.vp.AtBottom() {
+			m.vp.GotoBottom()
+		}
+	}
+	return m, m.tailTickCmd()
+}
+
+// newTailRows returns rows not seen before, oldest first, and advances the cursor.
+func (m *model) newTailRows(columns []appinsights.Column, rows [][]interface{}, tsCol int) [][]interface{} {
+	if m.tail.maxSeen.IsZero() {
+		// Polls without a cursor are newest-first; the table shows the tail oldest-first
+		rev := make([][]interface{}, len(rows))
+		for i, r := range rows {
+			rev[len(rows)-1-i] = r
+		}
+		rows = rev
+	}
+	idCol := -1
+	for i, c := range columns {
+		if strings.EqualFold(c.Name, "itemId") {
+			idCol = i
+		}
+	}
+	fresh := make([][]interface{}, 0, len(rows))
+	for _, r := range rows {
+		id := rowKey(r)
+		if idCol >= 0 && idCol < len(r) {
+			id = fmt.Sprint(r[idCol])
+		}
+		if _, dup := m.tail.seenIDs[id]; dup {
+			continue
+		}
+		ts, ok := parseRowTimestamp(r[tsCol])
+		if ok && ts.After(m.tail.maxSeen) {
+			m.tail.maxSeen = ts
+			m.tail.seenIDs = map[string]struct{}{}
+		}
+		if ok && ts.Equal(m.tail.maxSeen) {
+			m.tail.seenIDs[id] = struct{}{}
+		}
+		fresh = append(fresh, r)
+	}
+	return fresh
+}
+
+// startTailResults replaces the current results with the first poll and opens the table.
+func (m *model) startTailResults(res kqlResultMsg, rows [][]interface{}) {
+	m.setResultTables([]appinsights.Table{{Name: res.tableName, Columns: res.columns, Rows: rows}}, 0)
+	m.haveResults = true
+	m.lastDuration = res.duration
+	m.paging = pagingState{}
+	m.append(fmt.Sprintf("tail: %d recent rows%s", len(rows), m.tailLagText()))
+	if len(rows) == 0 {
+		return
+	}
+	if m.mode == modeChat {
+		*m, _ = m.openTableFromLastResults()
+	} else if m.mode == modeTableResults {
+		m.initInteractiveTable()
+	}
+	if m.mode == modeTableResults {
+		m.tbl.GotoBottom()
+	}
+}
+
+// trimTailRows drops the oldest rows beyond tailMaxRows. The open details keep
+// pointing at their row; a dropped row leaves detailsRow at -1.
+func (m *model) trimTailRows() {
+	over := len(m.lastRows) - tailMaxRows
+	if over <= 0 {
+		return
+	}
+	selected := m.rowIndex(m.tbl.Cursor()) - over
+	m.lastRows = m.lastRows[over:]
+	m.detailsRow = max(m.detailsRow-over, -1)
+	if m.activeTable >= 0 && m.activeTable < len(m.lastTables) {
+		m.lastTables[m.activeTable].rows = m.lastRows
+	}
+	if m.mode == modeTableResults || m.mode == modeDetails {
+		m.refreshTable(selected)
+	}
+}
+
+// tailLag returns ingestion time minus event time for the newest row of the batch.
+func tailLag(columns []appinsights.Column, rows [][]interface{}, tsCol int) (time.Duration, bool) {
+	ingCol := -1
+	for i, c := range columns {
+		if c.Name == tailIngestionColumn {
+			ingCol = i
+		}
+	}
+	if ingCol < 0 || len(rows) == 0 {
+		return 0, false
+	}
+	last := rows[len(rows)-1]
+	if ingCol >= len(last) {
+		return 0, false
+	}
+	ts, ok1 := parseRowTimestamp(last[tsCol])
+	ing, ok2 := parseRowTimestamp(last[ingCol])
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	return max(ing.Sub(ts), 0), true
+}
+
+// tailRecoverable reports whether polling should continue after qe.
+func tailRecoverable(qe *appinsights.QueryError) bool {
+	switch qe.Kind() {
+	case appinsights.QueryErrorThrottled, appinsights.QueryErrorServer, appinsights.QueryErrorLimits:
+		return true
+	}
+	return false
+}
+
+func (m model) tailLagText() string {
+	if !m.tail.hasLag {
+		return ""
+	}
+	return " · ingestion lag " + m.tail.lag.Round(time.Second).String()
+}
+
+// tailStatus describes the running tail for the status line.
+func (m model) tailStatus() string {
+	s := fmt.Sprintf("tail: %d rows", len(m.lastRows))
+	s += m.tailLagText()
+	if m.tail.inflight {
+		s += " · polling…"
+	}
+	return s
+}
@@ -0,0 +1,96 @@
+package tui
+
+// Global query time range: the 'time' command and picker set the window sent with
+// every query as the API "timespan" (persisted in config as queryTimespan).
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/config"
+	"github.com/FBakkensen/bc-insights-tui/logging"
+)
+
+// timespanPresets are offered by the 'time' picker; value is parsed by appinsights.ParseTimespan.
+var timespanPresets = []timespanItem{
+	{label: "Query-defined", value: "none", desc: "No timespan; only the query's own filters apply"},
+	{label: "Last 15 minutes", value: "PT15M"},
+	{label: "Last hour", value: "PT1H"},
+	{label: "Last 4 hours", value: "PT4H"},
+	{label: "Last 12 hours", value: "PT12H"},
+	{label: "Last 24 hours", value: "P1D"},
+	{label: "Last 3 days", value: "P3D"},
+	{label: "Last 7 days", value: "P7D"},
+	{label: "Last 30 days", value: "P30D"},
+}
+
+// timespanItem adapts a preset to list.Item for the time range picker.
+type timespanItem struct {
+	label string
+	value string
+	desc  string
+}
+
+func (i timespanItem) FilterValue() string { return i.label + " " + i.value }
+func (i timespanItem) Title() string       { return i.label }
+func (i timespanItem) Description() string {
+	if i.desc != "" {
+		return i.desc
+	}
+	return "timespan " + i.value
+}
+
+// timespanFromConfig parses the persisted window; an invalid value is logged and ignored.
+func timespanFromConfig(value string) appinsights.Timespan {
+	ts, err := appinsights.ParseTimespan(value)
+	if err != nil {
+		logging.Warn("Ignoring invalid queryTimespan from config", "value", value, "error", err.Error())
+		return appinsights.Timespan{}
+	}
+	return ts
+}
+
+// openTimespanPanel shows the preset picker with the current window preselected.
+func (m model) openTimespanPanel() (tea.Model, tea.Cmd) {
+	items := make([]list.Item, 0, len(timespanPresets))
+	selected := 0
+	for i, p := range timespanPresets {
+		items = append(items, p)
+		ts, _ := appinsights.ParseTimespan(p.value)
+		if ts == m.timespan {
+			selected = i
+		}
+	}
+	m.mode = modeListTimespan
+	m.list.Title = titleSelectTimespan
+	m.list.ResetFilter()
+	m.list.SetItems(items)
+	m.list.Select(selected)
+	m.append(fmt.Sprintf("Current time range: %s. Pick a preset, or type 'time <range>' (e.g. 2h, P1D, 2024-05-01/2024-05-02, off).", m.timespan.Describe()))
+	return m, nil
+}
+
+// handleTimeCommand implements 'time <range>': validates, applies to future queries and persists.
+func (m model) handleTimeCommand(value string) (tea.Model, tea.Cmd) {
+	ts, err := appinsights.ParseTimespan(value)
+	if err != nil {
+		m.append("Invalid time range: " + err.Error())
+		return m, nil
+	}
+	m.timespan = ts
+	logging.Info("Query timespan changed", "timespan", ts.String())
+	if err := m.cfg.ValidateAndUpdateSetting(config.SettingQueryTimespan, ts.String()); err != nil {
+		logging.Warn("Failed to persist query timespan", "error", err.Error())
+		m.append("Time range applied for this session, but saving it failed: " + err.Error())
+		return m, nil
+	}
+	if ts.IsZero() {
+		m.append("Time range cleared. Queries use only their own time filters.")
+	} else {
+		m.append(fmt.Sprintf("Time range set to %s (timespan %s). It applies to every query, including -run=kql.", ts.Describe(), ts.String()))
+	}
+	return m, nil
+}
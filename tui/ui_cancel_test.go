@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newRunningModel returns a model with an in-flight query whose context is ctx.
+func newRunningModel(t *testing.T) (model, context.Context) {
+	t.Helper()
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m.runningKQL = true
+	m.cancelKQL = cancel
+	return m, ctx
+}
+
+func TestCancel_EscCancelsRunningQueryInsteadOfQuitting(t *testing.T) {
+	m, ctx := newRunningModel(t)
+	m2Any, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEsc})
+	m2 := m2Any.(model)
+	if m2.quitting || cmd != nil {
+		t.Fatalf("expected Esc to cancel, not quit")
+	}
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("expected query context to be canceled")
+	}
+	if !m2.canceling || !strings.Contains(m2.statusLine(), "canceling…") || !strings.Contains(m2.content, "Canceling query…") {
+		t.Fatalf("expected canceling state; status=%q content=%q", m2.statusLine(), m2.content)
+	}
+
+	m3Any, _ := m2.Update(kqlResultMsg{query: "traces | take 5", canceled: true, err: errQueryCanceled, duration: 1500 * time.Millisecond})
+	m3 := m3Any.(model)
+	if m3.runningKQL || m3.cancelKQL != nil || m3.canceling {
+		t.Fatalf("expected running state cleared after canceled result")
+	}
+	if !strings.Contains(m3.content, "Query canceled after 1.500s.") {
+		t.Fatalf("expected cancel notice; got %q", m3.content)
+	}
+	if m3.ta.Value() != "kql: traces | take 5" {
+		t.Fatalf("expected query restored to the input; got %q", m3.ta.Value())
+	}
+}
+
+func TestCancel_CommandWithoutRunningQuery(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m, _ = submitChat(t, m, "cancel")
+	if !strings.Contains(m.content, "No query is running.") {
+		t.Fatalf("expected no-op message; got %q", m.content)
+	}
+}
+
+func TestCancel_CommandAndMultiLineRestoreToEditor(t *testing.T) {
+	m, ctx := newRunningModel(t)
+	m, _ = submitChat(t, m, "cancel")
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("expected 'cancel' to cancel the query context")
+	}
+	m.lastTemplate = "traces\n| where timestamp > ago({{window=1h}})"
+	m2Any, _ := m.Update(kqlResultMsg{query: "traces\n| where timestamp > ago(1h)", canceled: true, err: errQueryCanceled})
+	m2 := m2Any.(model)
+	if m2.mode != modeKQLEditor || m2.ta.Value() != m.lastTemplate {
+		t.Fatalf("expected multi-line query restored to the editor; mode=%v value=%q", m2.mode, m2.ta.Value())
+	}
+}
+
+func TestCancel_EscInEditorKeepsBuffer(t *testing.T) {
+	m, ctx := newRunningModel(t)
+	m2Any, _ := m.enterEditor("traces\n| take 10", false)
+	m2 := m2Any.(model)
+	m3Any, _ := m2.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEsc})
+	m3 := m3Any.(model)
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("expected Esc in editor to cancel the running query")
+	}
+	if m3.mode != modeKQLEditor || m3.ta.Value() != "traces\n| take 10" {
+		t.Fatalf("expected to stay in the editor with the buffer intact; mode=%v value=%q", m3.mode, m3.ta.Value())
+	}
+	m4Any, _ := m3.Update(kqlResultMsg{query: "traces\n| take 10", canceled: true, err: errQueryCanceled})
+	if v := m4Any.(model).ta.Value(); v != "traces\n| take 10" {
+		t.Fatalf("expected editor buffer untouched by canceled result; got %q", v)
+	}
+}
+
+func TestCancel_SecondRunRefusedWhileAQueryRuns(t *testing.T) {
+	m, _ := newRunningModel(t)
+	m, second := submitChat(t, m, "kql: requests | take 1")
+	if second != nil || !strings.Contains(m.content, "A query is already running. Wait for it or 'cancel' it.") {
+		t.Fatalf("expected the second run refused; content=%q", m.content)
+	}
+	m = newEditorModel(t, "requests")
+	m.runningKQL, m.cancelKQL = true, func() {}
+	if mAny, cmd := m.Update(submitEditorMsg{}); cmd != nil || !strings.Contains(mAny.(model).content, "already running") {
+		t.Fatalf("expected the editor run refused too")
+	}
+
+	// Esc still cancels the first query instead of quitting
+	m, ctx := newRunningModel(t)
+	m, _ = submitChat(t, m, "kql: requests | take 1")
+	mAny, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEsc})
+	m = mAny.(model)
+	if m.quitting || cmd != nil || ctx.Err() != context.Canceled {
+		t.Fatalf("expected Esc to cancel the running query")
+	}
+	mAny, _ = m.Update(kqlResultMsg{query: "traces | take 1", canceled: true, err: errQueryCanceled})
+	m = mAny.(model)
+	if _, second = submitChat(t, m, "kql: requests | take 1"); second == nil {
+		t.Fatalf("expected a new query once the first has finished")
+	}
+
+	// A query started while 'more' loads would get the late page appended
+	m = newPagedModel(t, "traces | order by timestamp desc")
+	m, _ = submitChat(t, m, "more")
+	m, cmd = submitChat(t, m, "kql: requests | take 1")
+	if cmd != nil || m.paging.pending == nil || !strings.Contains(m.content, "already running") {
+		t.Fatalf("expected a query refused while a page loads")
+	}
+}
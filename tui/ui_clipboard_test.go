@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// captureClipboard redirects OSC 52 output and returns a func decoding the last copy.
+func captureClipboard(t *testing.T) func() string {
+	t.Helper()
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+	var buf bytes.Buffer
+	orig := clipboardOut
+	clipboardOut = &buf
+	t.Cleanup(func() { clipboardOut = orig })
+	return func() string {
+		s := buf.String()
+		i := strings.LastIndex(s, "\x1b]52;c;")
+		if i < 0 {
+			t.Fatalf("no OSC 52 sequence written: %q", s)
+		}
+		payload := strings.TrimSuffix(s[i+len("\x1b]52;c;"):], "\x07")
+		b, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			t.Fatalf("bad payload %q: %v", payload, err)
+		}
+		return string(b)
+	}
+}
+
+func runCmd(cmd tea.Cmd) {
+	if cmd != nil {
+		cmd()
+	}
+}
+
+var copyTestRows = [][]interface{}{{"2025-01-01T10:00:00Z", "Long SQL statement: SELECT * FROM \"CRONUS$Customer\"", `{"eventId":"RT0005","alObjectId":"50100"}`}}
+
+func TestClipboard_TableCopiesCellRowAndQuery(t *testing.T) {
+	last := captureClipboard(t)
+	m := newTableModel(t, tracesColumns, copyTestRows, 160)
+	if !strings.HasPrefix(m.tbl.Columns()[0].Title, columnCursorMark) {
+		t.Fatalf("expected the first column marked")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRight})
+	if !strings.Contains(m.statusLine(), "col: message") || !strings.HasPrefix(m.tbl.Columns()[1].Title, columnCursorMark) {
+		t.Fatalf("expected the column cursor on message; status %q", m.statusLine())
+	}
+	mAny, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = mAny.(model)
+	runCmd(cmd)
+	if got := last(); got != "Long SQL statement: SELECT * FROM \"CRONUS$Customer\"" {
+		t.Fatalf("unexpected cell copy %q", got)
+	}
+	if !strings.Contains(m.statusLine(), "copied message") {
+		t.Fatalf("expected a status note; got %q", m.statusLine())
+	}
+
+	mAny, cmd = m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = mAny.(model)
+	runCmd(cmd)
+	want := "{\n  \"timestamp\": \"2025-01-01T10:00:00Z\",\n  \"message\": \"Long SQL statement: SELECT * FROM \\\"CRONUS$Customer\\\"\",\n  \"customDimensions\": {\n    \"eventId\": \"RT0005\",\n    \"alObjectId\": \"50100\"\n  }\n}"
+	if got := last(); got != want {
+		t.Fatalf("unexpected row JSON:\n%s", got)
+	}
+
+	mAny, cmd = m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	m = mAny.(model)
+	runCmd(cmd)
+	if got := last(); got != "traces" {
+		t.Fatalf("unexpected query copy %q", got)
+	}
+	// The next key clears the note
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	if strings.Contains(m.statusLine(), "copied") {
+		t.Fatalf("expected the note cleared")
+	}
+}
+
+func TestClipboard_DetailsAndChat(t *testing.T) {
+	last := captureClipboard(t)
+	m := newTableModel(t, tracesColumns, copyTestRows, 160)
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != modeDetails {
+		t.Fatalf("expected details mode")
+	}
+	mAny, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = mAny.(model)
+	runCmd(cmd)
+	if got := last(); !strings.HasPrefix(got, "Details — PrimaryResult · row 0") || !strings.Contains(got, "  eventId: RT0005") {
+		t.Fatalf("unexpected details copy %q", got)
+	}
+
+	chat := newPostAuthModelWithKQL(&kqlOK{})
+	chat.lastTemplate = "requests | take 1"
+	chat, cmd = submitChat(t, chat, "copy")
+	runCmd(cmd)
+	if got := last(); got != "requests | take 1" || !strings.Contains(chat.content, "Copied query") {
+		t.Fatalf("unexpected chat copy %q", got)
+	}
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	if seq := osc52Sequence("x").String(); !strings.HasPrefix(seq, "\x1bPtmux;") {
+		t.Fatalf("expected tmux passthrough; got %q", seq)
+	}
+}
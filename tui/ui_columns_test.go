@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func columnsTestRows(eventID string) [][]interface{} {
+	return [][]interface{}{
+		{"2025-01-01T10:00:00Z", "Report run", `{"eventId":"` + eventID + `","alObjectId":"50100","alObjectName":"Customer List","extensionName":"Base"}`},
+		{"2025-01-01T10:00:01Z", "Report run", `{"eventId":"` + eventID + `","alObjectId":"50101","alObjectName":"Vendor List","extensionName":"Base"}`},
+	}
+}
+
+// newModelWithLayouts returns a model whose layouts are saved under a temp dir.
+func newModelWithLayouts(t *testing.T) (model, string) {
+	t.Helper()
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	path := filepath.Join(t.TempDir(), "layouts.yaml")
+	m.columnLayouts = loadColumnLayouts(path)
+	mAny, _ := m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	return mAny.(model), path
+}
+
+// selectColumnItem moves the picker selection to key.
+func selectColumnItem(t *testing.T, m model, key string) model {
+	t.Helper()
+	for i, it := range m.list.Items() {
+		if it.(columnItem).key == key {
+			m.list.Select(i)
+			return m
+		}
+	}
+	t.Fatalf("key %q not in the column chooser", key)
+	return m
+}
+
+func pickerKey(t *testing.T, m model, key string) model {
+	t.Helper()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case " ":
+		msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	}
+	mAny, _ := m.handleKeyMessage(msg)
+	return mAny.(model)
+}
+
+func TestColumnPicker_SavesLayoutPerEventID(t *testing.T) {
+	m, path := newModelWithLayouts(t)
+	m = showResults(t, m, tracesColumns, columnsTestRows("RT0006"))
+	mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyF6})
+	m = mAny.(model)
+	m = pickerKey(t, m, "C")
+	if m.mode != modeListColumns || !strings.Contains(m.list.Title, "eventId RT0006") {
+		t.Fatalf("expected the column chooser for RT0006, mode=%v title=%q", m.mode, m.list.Title)
+	}
+	if !strings.Contains(m.statusLine(), "Space show/hide") {
+		t.Fatalf("expected the picker hint in the status line, got %q", m.statusLine())
+	}
+
+	m = selectColumnItem(t, m, "extensionName")
+	m = pickerKey(t, m, " ") // hide
+	m = selectColumnItem(t, m, "alObjectName")
+	m = pickerKey(t, m, "p") // pin
+	m = selectColumnItem(t, m, "alObjectId")
+	m = pickerKey(t, m, "K") // pins, then moves above alObjectName
+	if got := m.colPicker.pinned; !slices.Equal(got, []string{"alObjectId", "alObjectName"}) {
+		t.Fatalf("pinned = %v", got)
+	}
+	if sel := m.list.SelectedItem().(columnItem); sel.key != "alObjectId" {
+		t.Fatalf("expected the selection to follow the moved key, got %q", sel.key)
+	}
+
+	m = pickerKey(t, m, "enter")
+	if m.mode != modeTableResults {
+		t.Fatalf("expected to return to the table, got %v", m.mode)
+	}
+	want := []string{"timestamp", "message", "alObjectId", "alObjectName", "eventId"}
+	if !slices.Equal(m.lastDisplayHeaders, want) {
+		t.Fatalf("headers = %v, want %v", m.lastDisplayHeaders, want)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(b), "eventId: RT0006") || !strings.Contains(string(b), "- extensionName") {
+		t.Fatalf("expected the layout saved, err=%v file=%s", err, b)
+	}
+
+	// A new result of the same event type reopens with the layout; another does not
+	m.columnLayouts = loadColumnLayouts(path)
+	m = showResults(t, m, tracesColumns, columnsTestRows("RT0006"))
+	if !slices.Equal(m.lastDisplayHeaders, want) {
+		t.Fatalf("same eventId: headers = %v", m.lastDisplayHeaders)
+	}
+	m = showResults(t, m, tracesColumns, columnsTestRows("RT0005"))
+	if !slices.Contains(m.lastDisplayHeaders, "extensionName") {
+		t.Fatalf("other eventId should use the ranking, got %v", m.lastDisplayHeaders)
+	}
+}
+
+func TestColumnPicker_EscKeepsLayoutAndResetRemovesIt(t *testing.T) {
+	m, path := newModelWithLayouts(t)
+	m = showResults(t, m, tracesColumns, columnsTestRows("RT0006"))
+	before := slices.Clone(m.lastDisplayHeaders)
+	mAny, _ := m.handlePostAuthCommand("columns")
+	m = mAny.(model)
+	m = selectColumnItem(t, m, "eventId")
+	m = pickerKey(t, m, " ")
+	m = pickerKey(t, m, "esc")
+	if m.mode != modeChat || !slices.Equal(m.lastDisplayHeaders, before) {
+		t.Fatalf("expected Esc to leave headers unchanged, mode=%v headers=%v", m.mode, m.lastDisplayHeaders)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no layouts file after Esc, err=%v", err)
+	}
+
+	_ = m.columnLayouts.put(savedLayout{EventID: "RT0006", Hidden: []string{"eventId"}})
+	mAny, _ = m.handlePostAuthCommand("columns")
+	m = mAny.(model)
+	m = pickerKey(t, m, "r")
+	m = pickerKey(t, m, "enter")
+	if m.columnLayouts.find("RT0006") >= 0 || !slices.Contains(m.lastDisplayHeaders, "eventId") {
+		t.Fatalf("expected reset to remove the layout, headers=%v", m.lastDisplayHeaders)
+	}
+}
+
+func TestApplyColumnLayoutAndResultEventID(t *testing.T) {
+	ranked := []string{"timestamp", "message", "eventId", "a", "B", "c"}
+	got := applyColumnLayout(ranked, savedLayout{Pinned: []string{"c", "b", "missing"}, Hidden: []string{"A"}})
+	if want := []string{"timestamp", "message", "c", "B", "eventId"}; !slices.Equal(got, want) {
+		t.Fatalf("applyColumnLayout = %v, want %v", got, want)
+	}
+	mixed := append(columnsTestRows("RT0005"), columnsTestRows("RT0006")...)
+	if id := resultEventID(tracesColumns, mixed); id != "" {
+		t.Fatalf("mixed eventIds should give no eventId, got %q", id)
+	}
+	if id := resultEventID(tracesColumns, columnsTestRows("RT0006")); id != "RT0006" {
+		t.Fatalf("got %q", id)
+	}
+}
@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+)
+
+// newEditorModel returns a model in editor mode with text typed into the buffer.
+func newEditorModel(t *testing.T, text string) model {
+	t.Helper()
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.cfg.ApplicationInsightsID = "app-1"
+	m, _ = submitChat(t, m, "edit")
+	if m.mode != modeKQLEditor {
+		t.Fatalf("expected editor mode")
+	}
+	m.ta.Focus()
+	m.ta.InsertString(text)
+	return m
+}
+
+func pressKey(t *testing.T, m model, key tea.KeyMsg) model {
+	t.Helper()
+	mAny, _ := m.handleKeyMessage(key)
+	return mAny.(model)
+}
+
+func labels(items []completionItem) []string {
+	out := make([]string, 0, len(items))
+	for _, it := range items {
+		out = append(out, it.label)
+	}
+	return out
+}
+
+func TestCompletion_ScopeFollowsPipeStage(t *testing.T) {
+	tests := []struct {
+		before string
+		scope  completionScope
+		prefix string
+		op     string
+		source string
+	}{
+		{"tra", scopeSource, "tra", "", ""},
+		{"traces\n| wh", scopeOperator, "wh", "", "traces"},
+		{"traces | where sev", scopeExpression, "sev", "where", "traces"},
+		{"let t = exceptions;\nt | summarize c", scopeExpression, "c", "summarize", "t"},
+		{"let since = ago(1h);\nre", scopeSource, "re", "", ""},
+		{"traces | join kind=inner (req", scopeSource, "req", "", ""},
+		{"traces | join kind=inner (requests | pro", scopeOperator, "pro", "", "requests"},
+		{"traces | join kind=inner (requests) on ope", scopeExpression, "ope", "join", "traces"},
+		{"union traces, exc", scopeSource, "exc", "", ""},
+		{"traces | where customDimensions.ev", scopeKey, "customDimensions.ev", "", "traces"},
+		{"traces | where message == 'abc", scopeNone, "abc", "", ""},
+		{"traces // all tr", scopeNone, "tr", "", ""},
+		{"traces | take 10", scopeNone, "10", "", ""},
+	}
+	for _, tt := range tests {
+		ctx := analyzeCompletion(tt.before)
+		if ctx.scope != tt.scope || ctx.prefix != tt.prefix || ctx.op != tt.op || (tt.source != "" && ctx.source != tt.source) {
+			t.Errorf("analyzeCompletion(%q) = scope %v prefix %q op %q source %q", tt.before, ctx.scope, ctx.prefix, ctx.op, ctx.source)
+		}
+	}
+}
+
+func TestCompletion_TabInsertsSingleMatch(t *testing.T) {
+	m := newEditorModel(t, "traces\n| where severityLevel > 2\n| summ")
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	if got := m.ta.Value(); got != "traces\n| where severityLevel > 2\n| summarize " {
+		t.Fatalf("expected operator completed; got %q", got)
+	}
+	if m.completion.active {
+		t.Fatalf("did not expect a popup for a single match")
+	}
+}
+
+func TestCompletion_TabIndentsWhereNothingCompletes(t *testing.T) {
+	m := newEditorModel(t, "traces\n")
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	if got := m.ta.Value(); got != "traces\n"+editorIndent || m.completion.active {
+		t.Fatalf("expected Tab at the start of a line to indent; got %q", got)
+	}
+	m.ta.SetValue("traces | where message == 'abc")
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	if got := m.ta.Value(); got != "traces | where message == 'abc"+editorIndent {
+		t.Fatalf("expected Tab inside a string to indent; got %q", got)
+	}
+	// Ctrl+Space still completes at the start of a line
+	m.ta.SetValue("")
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlAt})
+	if m.ta.Value() == editorIndent || (!m.completion.active && m.ta.Value() == "") {
+		t.Fatalf("expected Ctrl+Space to complete; got %q", m.ta.Value())
+	}
+}
+
+func TestCompletion_PopupNavigateAndAccept(t *testing.T) {
+	m := newEditorModel(t, "exc")
+	m.ta.SetValue("traces | where t")
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlAt})
+	if !m.completion.active || len(m.completion.items) < 2 || m.completion.items[0].label != "timestamp" {
+		t.Fatalf("expected a popup starting with timestamp; got %v", labels(m.completion.items))
+	}
+	m.width, m.height = 100, 30
+	mAny, _ := m.handleResize(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = mAny.(model)
+	if view := m.View(); !strings.Contains(view, "timestamp") || !strings.Contains(view, "Tab/Enter insert") {
+		t.Fatalf("expected the popup in the view")
+	}
+	// Typing narrows the list; Down moves the selection; Enter accepts instead of a newline
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	if got := labels(m.completion.items); len(got) < 2 || got[0] != "todatetime()" {
+		t.Fatalf("expected items narrowed to 'to'; got %v", got)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	want := m.completion.items[1].insert
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.ta.Value(); got != "traces | where "+want || m.completion.active {
+		t.Fatalf("expected %q inserted; got %q", want, got)
+	}
+	// Esc closes only the popup
+	m.ta.SetValue("traces | where t")
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.completion.active || m.mode != modeKQLEditor {
+		t.Fatalf("expected Esc to close the popup and stay in the editor")
+	}
+}
+
+func TestCompletion_OffersKeysFromEarlierResults(t *testing.T) {
+	m := newEditorModel(t, "")
+	cols := []appinsights.Column{{Name: "timestamp", Type: "datetime"}, {Name: "message", Type: "string"}, {Name: "customDimensions", Type: "dynamic"}}
+	rows := [][]interface{}{{"2025-01-01T10:00:00Z", "m", `{"eventId":"RT0005","al Object Id":"50100"}`}}
+	mAny, _ := m.Update(kqlResultMsg{appID: "app-1", columns: cols, rows: rows})
+	m = mAny.(model)
+
+	m.ta.SetValue("traces | where customDimensions.ev")
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	if got := m.ta.Value(); got != "traces | where customDimensions.eventId" {
+		t.Fatalf("expected the remembered key completed; got %q", got)
+	}
+	items := m.completionItems(analyzeCompletion("traces | where custom"))
+	if got := strings.Join(labels(items), ","); !strings.Contains(got, "customDimensions['al Object Id']") {
+		t.Fatalf("expected bracket form for keys with spaces; got %s", got)
+	}
+
+	m.cfg.ApplicationInsightsID = "other-app"
+	if items := m.completionItems(analyzeCompletion("traces | where customDimensions.")); len(items) != 0 {
+		t.Fatalf("expected no keys for another app; got %v", labels(items))
+	}
+}
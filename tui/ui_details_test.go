@@ -61,3 +61,128 @@ func TestDetails_OpenAndClose_FromTable(t *testing.T) {
 		t.Fatalf("expected to return to table after Esc; got %v", m4.mode)
 	}
 }
+
+func detailsKey(t *testing.T, m model, key string) model {
+	t.Helper()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	switch key {
+	case "right":
+		msg = tea.KeyMsg{Type: tea.KeyRight}
+	case "left":
+		msg = tea.KeyMsg{Type: tea.KeyLeft}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	}
+	mAny, _ := m.handleKeyMessage(msg)
+	return mAny.(model)
+}
+
+func TestDetails_TreeExpandsNestedAndEmbeddedJSON(t *testing.T) {
+	custom := `{"eventId":"RT0005","lines":"[{\"no\":10000,\"item\":{\"id\":\"1896-S\"}}]","a":{"b":{"c":{"d":"deep"}}}}`
+	m := newTableModel(t, tracesColumns, [][]interface{}{{"2025-03-03T00:00:00Z", "hello", custom}}, 80)
+	m = detailsKey(t, m, "enter")
+	if m.mode != modeDetails {
+		t.Fatalf("expected details, got %v", m.mode)
+	}
+	if !strings.Contains(m.detailsContent, `▸ a: {"b":{"c":{"d":"deep"}}}`) || !strings.Contains(m.detailsContent, "  eventId: RT0005") {
+		t.Fatalf("expected collapsed a and leaf eventId, got %q", m.detailsContent)
+	}
+
+	// Right expands, and on an open node moves to its first child
+	for range 6 {
+		m = detailsKey(t, m, "right")
+	}
+	if !strings.Contains(m.detailsContent, "        d: deep") || !strings.Contains(m.statusLine(), "path: customDimensions.a.b.c.d") {
+		t.Fatalf("expected the deep leaf selected; status %q content %q", m.statusLine(), m.detailsContent)
+	}
+	// Left goes to the parent, then collapses it
+	m = detailsKey(t, m, "left")
+	m = detailsKey(t, m, "left")
+	if strings.Contains(m.detailsContent, "d: deep") || !strings.Contains(m.statusLine(), "path: customDimensions.a.b.c") {
+		t.Fatalf("expected c collapsed and selected; status %q", m.statusLine())
+	}
+
+	// JSON held in a string expands like an array; e expands everything below
+	m = detailsKey(t, m, "E")
+	m = detailsKey(t, m, "down")
+	m = detailsKey(t, m, "down")
+	m = detailsKey(t, m, "e")
+	for _, want := range []string{"▾ lines: [1 item] in a string", "      id: 1896-S", "      no: 10000"} {
+		if !strings.Contains(m.detailsContent, want) {
+			t.Fatalf("expected %q in %q", want, m.detailsContent)
+		}
+	}
+
+	// The expanded paths stay when details open again
+	mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEsc})
+	m = mAny.(model)
+	m = detailsKey(t, m, "enter")
+	if !strings.Contains(m.detailsContent, "id: 1896-S") {
+		t.Fatalf("expected lines still expanded, got %q", m.detailsContent)
+	}
+}
+
+func TestDetails_CopyKeepsCollapsedValuesWhole(t *testing.T) {
+	last := captureClipboard(t)
+	long := strings.Repeat("x", 200)
+	rows := [][]interface{}{{"2025-03-03T00:00:00Z", "hello", map[string]interface{}{"payload": map[string]interface{}{"text": long}}}}
+	m := detailsKey(t, newTableModel(t, tracesColumns, rows, 80), "enter")
+	if view := m.detailsVP.View(); strings.Contains(view, long) || !strings.Contains(view, "…") {
+		t.Fatalf("expected the collapsed payload cut to the view width; got %q", view)
+	}
+
+	mAny, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	runCmd(cmd)
+	want := `▸ payload: {"text":"` + long + `"}`
+	if got := last(); !strings.Contains(got, want) || strings.Contains(got, "…") {
+		t.Fatalf("expected the whole collapsed payload copied; got %q", got)
+	}
+	if m = mAny.(model); !strings.Contains(m.detailsVP.View(), "…") {
+		t.Fatalf("expected the view to stay cut after copying")
+	}
+}
+
+func TestDetails_StepsRowsInTableOrderKeepingPosition(t *testing.T) {
+	m := newTableModel(t, tracesColumns, sortTestRows, 200)
+	m = sortBy(t, m, "message", 1) // alpha, beta, Gamma
+	m.tbl.GotoTop()
+	m = detailsKey(t, m, "enter")
+	if !strings.Contains(m.detailsContent, "message: alpha") || !strings.Contains(m.statusLine(), "row 1 of 3") {
+		t.Fatalf("expected alpha first; status %q", m.statusLine())
+	}
+	m = detailsKey(t, m, "down") // rows
+	m = detailsKey(t, m, "]")
+	if !strings.Contains(m.detailsContent, "message: beta") || m.detailsRow != 0 {
+		t.Fatalf("expected beta (row 0) next, got row %d: %q", m.detailsRow, m.detailsContent)
+	}
+	if !strings.Contains(m.statusLine(), "path: customDimensions.rows") || !strings.Contains(m.statusLine(), "row 2 of 3") {
+		t.Fatalf("expected the selection kept on rows; status %q", m.statusLine())
+	}
+	m = detailsKey(t, m, "}")
+	if !strings.Contains(m.detailsContent, "message: Gamma") {
+		t.Fatalf("expected Gamma last, got %q", m.detailsContent)
+	}
+	// Gamma has no rows key: the selection stays at the same position
+	if !strings.Contains(m.statusLine(), "path: customDimensions.executionTime") {
+		t.Fatalf("expected the selection clamped; status %q", m.statusLine())
+	}
+	m = detailsKey(t, m, "]")
+	if !strings.Contains(m.statusLine(), "last row") {
+		t.Fatalf("expected a last row note; status %q", m.statusLine())
+	}
+	m = detailsKey(t, m, "{")
+	m = detailsKey(t, m, "[")
+	if !strings.Contains(m.detailsContent, "message: alpha") || !strings.Contains(m.statusLine(), "first row") {
+		t.Fatalf("expected to stay on alpha; status %q", m.statusLine())
+	}
+
+	// The table cursor follows, so Esc returns to the row shown
+	m = detailsKey(t, m, "]")
+	mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEsc})
+	m = mAny.(model)
+	if m.mode != modeTableResults || cursorMessage(m) != "beta" {
+		t.Fatalf("expected the table cursor on beta, got %q", cursorMessage(m))
+	}
+}
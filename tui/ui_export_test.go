@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+)
+
+var (
+	exportTestCols = append(slices.Clone(tracesColumns), appinsights.Column{Name: "duration", Type: "real"})
+	exportTestRows = [][]interface{}{
+		{"2025-01-01T10:00:00Z", "first", `{"eventId":"RT0005","alObjectId":"50100"}`, 12.5},
+		{"2025-01-01T10:00:01Z", "second, with comma", `{"eventId":"RT0018"}`, nil},
+	}
+)
+
+func TestExport_RawAndFlattenedCSV(t *testing.T) {
+	dir := t.TempDir()
+	m := showResults(t, newPostAuthModelWithKQL(&kqlOK{}), exportTestCols, exportTestRows)
+	raw := filepath.Join(dir, "raw.csv")
+	m, _ = submitChat(t, m, "export csv "+raw)
+	b, err := os.ReadFile(raw)
+	if err != nil {
+		t.Fatalf("expected export file: %v (%s)", err, m.content)
+	}
+	if !strings.HasPrefix(string(b), "timestamp,message,customDimensions,duration\n") || !strings.Contains(m.content, "Exported 2 rows × 4 columns (csv)") {
+		t.Fatalf("unexpected raw export %q / %q", b, m.content)
+	}
+
+	flat := filepath.Join(dir, "with space", "flat.csv")
+	if err := os.MkdirAll(filepath.Dir(flat), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m, _ = submitChat(t, m, `export csv "`+flat+`" flatten`)
+	b, _ = os.ReadFile(flat)
+	want := "timestamp,message,duration,customDimensions.alObjectId,customDimensions.eventId\n" +
+		"2025-01-01T10:00:00Z,first,12.5,50100,RT0005\n" +
+		"2025-01-01T10:00:01Z,\"second, with comma\",,,RT0018\n"
+	if string(b) != want {
+		t.Fatalf("unexpected flattened export:\n%s", b)
+	}
+}
+
+func TestExport_DisplayHeadersAndErrors(t *testing.T) {
+	dir := t.TempDir()
+	m := showResults(t, newPostAuthModelWithKQL(&kqlOK{}), exportTestCols, exportTestRows)
+	out := filepath.Join(dir, "view.ndjson")
+	m, _ = submitChat(t, m, "export ndjson "+out+" display")
+	b, _ := os.ReadFile(out)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"timestamp":"2025-01-01T10:00:00Z","message":"first"`) || !strings.Contains(lines[0], `"eventId":"RT0005"`) {
+		t.Fatalf("unexpected display export:\n%s", b)
+	}
+
+	m, _ = submitChat(t, m, "export yaml "+out)
+	if !strings.Contains(m.content, `Unknown export format "yaml"`) {
+		t.Fatalf("expected unknown format message")
+	}
+	m, _ = submitChat(t, m, "export csv "+filepath.Join(out, "x.csv"))
+	if !strings.Contains(m.content, "Export failed:") {
+		t.Fatalf("expected a write error")
+	}
+	empty := newPostAuthModelWithKQL(&kqlOK{})
+	empty, _ = submitChat(t, empty, "export csv "+out)
+	if !strings.Contains(empty.content, "No results to export") {
+		t.Fatalf("expected a note without results")
+	}
+}
+
+func TestExport_FailureKeepsExistingFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "keep.json")
+	if err := os.WriteFile(out, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// NaN cannot be encoded as JSON, so the export fails after the file would have been opened
+	cols := []appinsights.Column{{Name: "value", Type: "real"}}
+	m := showResults(t, newPostAuthModelWithKQL(&kqlOK{}), cols, [][]interface{}{{math.NaN()}})
+	m, _ = submitChat(t, m, "export json "+out)
+	if !strings.Contains(m.content, "Export failed:") {
+		t.Fatalf("expected an encoding error; got %q", m.content)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "previous" {
+		t.Fatalf("expected the existing file untouched, got %q", b)
+	}
+}
+
+func TestExport_TableKeyWritesXLSX(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	m := showResults(t, newPostAuthModelWithKQL(&kqlOK{}), exportTestCols, exportTestRows)
+	mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyF6})
+	m = mAny.(model)
+	if m.mode != modeTableResults {
+		t.Fatalf("expected table mode")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	matches, _ := filepath.Glob(filepath.Join(dir, "bc-insights-primaryresult-*.xlsx"))
+	if len(matches) != 1 || m.mode != modeTableResults || !strings.Contains(m.content, "(xlsx)") {
+		t.Fatalf("expected one xlsx file and to stay in the table; files=%v content=%q", matches, m.content)
+	}
+}
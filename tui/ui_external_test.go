@@ -0,0 +1,139 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeExternalEditor replaces the terminal handoff: it records the command and
+// writes edited into the temp file as if the user had saved it.
+func fakeExternalEditor(t *testing.T, edited string, gotArgs *[]string, gotInitial *string) {
+	t.Helper()
+	orig := execExternalEditor
+	t.Cleanup(func() { execExternalEditor = orig })
+	execExternalEditor = func(c *exec.Cmd, fn tea.ExecCallback) tea.Cmd {
+		*gotArgs = c.Args
+		path := c.Args[len(c.Args)-1]
+		b, _ := os.ReadFile(path)
+		*gotInitial = string(b)
+		if err := os.WriteFile(path, []byte(edited), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return func() tea.Msg { return fn(nil) }
+	}
+}
+
+func TestExternalEditor_CommandKeepsPathsWithSpaces(t *testing.T) {
+	editor := filepath.Join(t.TempDir(), "Program Files", "my editor")
+	if err := os.MkdirAll(filepath.Dir(editor), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(editor, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR", "")
+	for value, want := range map[string][]string{
+		editor:                    {editor},
+		`"` + editor + `" --wait`: {editor, "--wait"},
+		`'C:\Program Files\Notepad++\notepad++.exe' -multiInst`: {`C:\Program Files\Notepad++\notepad++.exe`, "-multiInst"},
+		"code  --wait": {"code", "--wait"},
+	} {
+		t.Setenv("VISUAL", value)
+		if got := externalEditorCommand(); !slices.Equal(got, want) {
+			t.Errorf("VISUAL=%q: got %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestExternalEditor_CtrlOLoadsEditedBuffer(t *testing.T) {
+	t.Setenv("VISUAL", "code --wait")
+	var args []string
+	var initial string
+	fakeExternalEditor(t, "traces\r\n| where severityLevel > 2\r\n| take 5\r\n", &args, &initial)
+
+	m := newEditorModel(t, "traces | take 1")
+	mAny, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = mAny.(model)
+	if cmd == nil || initial != "traces | take 1" {
+		t.Fatalf("expected the buffer written to the temp file; got %q", initial)
+	}
+	path := args[len(args)-1]
+	if len(args) != 3 || args[0] != "code" || args[1] != "--wait" || !strings.HasSuffix(path, ".kql") {
+		t.Fatalf("unexpected editor command %v", args)
+	}
+	mAny, _ = m.Update(cmd())
+	m = mAny.(model)
+	if m.mode != modeKQLEditor || m.ta.Value() != "traces\n| where severityLevel > 2\n| take 5" {
+		t.Fatalf("expected the edited query in the editor; got %q", m.ta.Value())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the temp file removed")
+	}
+}
+
+func TestExternalEditor_CommandFromChatRunsLastQuery(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nano")
+	var args []string
+	var initial string
+	fakeExternalEditor(t, "requests | take 3", &args, &initial)
+
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.lastTemplate = "requests | take 1"
+	m, cmd := submitChat(t, m, "edit-external run")
+	if args[0] != "nano" || initial != "requests | take 1" {
+		t.Fatalf("expected the last query opened in $EDITOR; args=%v initial=%q", args, initial)
+	}
+	mAny, runCmd := m.Update(cmd())
+	m = mAny.(model)
+	if m.mode != modeKQLEditor || !strings.Contains(m.content, "Running…") {
+		t.Fatalf("expected the edited query loaded and run; got %q", m.content)
+	}
+	if got := queryFromBatch(t, runCmd); got != "requests | take 3" {
+		t.Fatalf("expected the edited query run; got %q", got)
+	}
+}
+
+func TestExternalEditor_FailureKeepsBuffer(t *testing.T) {
+	m := newEditorModel(t, "traces")
+	f, err := os.CreateTemp(t.TempDir(), "*.kql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	mAny, _ := m.Update(externalEditorMsg{path: f.Name(), err: exec.ErrNotFound})
+	m = mAny.(model)
+	if m.ta.Value() != "traces" || !strings.Contains(m.content, "Set VISUAL or EDITOR") {
+		t.Fatalf("expected the buffer kept and a hint; got %q", m.content)
+	}
+}
+
+// queryFromBatch runs the commands in a batch and returns the query of the kqlResultMsg among them.
+func queryFromBatch(t *testing.T, cmd tea.Cmd) string {
+	t.Helper()
+	if cmd == nil {
+		t.Fatalf("expected a command")
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			if c == nil {
+				continue
+			}
+			if res, ok := c().(kqlResultMsg); ok {
+				return res.query
+			}
+		}
+	}
+	if res, ok := msg.(kqlResultMsg); ok {
+		return res.query
+	}
+	t.Fatalf("expected kqlResultMsg")
+	return ""
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFormat_EditorKeyFormatsBufferAndKeepsCursor(t *testing.T) {
+	m := newEditorModel(t, "traces | where severityLevel > 2 | take 10")
+	// Cursor on the 'w' of where
+	moveTextareaCursor(&m.ta, 0, strings.Index(m.ta.Value(), "where"))
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("F"), Alt: true})
+	if got := m.ta.Value(); got != "traces\n| where severityLevel > 2\n| take 10" {
+		t.Fatalf("unexpected formatted buffer %q", got)
+	}
+	if row, col := textareaCursor(m.ta); row != 1 || col != 2 {
+		t.Fatalf("expected cursor kept on 'where'; got row %d col %d", row, col)
+	}
+	if m.mode != modeKQLEditor || !strings.Contains(m.content, "Formatted query.") {
+		t.Fatalf("expected to stay in the editor with a note")
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyF4})
+	if !strings.Contains(m.content, "Query is already formatted.") {
+		t.Fatalf("expected F4 to format too")
+	}
+}
+
+func TestFormat_EditorReportsSyntaxError(t *testing.T) {
+	m := newEditorModel(t, "traces\n| where (a > 1")
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyF4})
+	if got := m.ta.Value(); got != "traces\n| where (a > 1" {
+		t.Fatalf("expected the buffer unchanged; got %q", got)
+	}
+	if !strings.Contains(m.content, "Cannot format: syntax error") || m.editorErr == nil || m.editorErr.line != 2 {
+		t.Fatalf("expected the error reported and marked; got %q", m.content)
+	}
+}
+
+func TestFormat_ChatCommandOpensEditor(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m, _ = submitChat(t, m, "format requests | summarize count() by resultCode | order by count_ desc")
+	if m.mode != modeKQLEditor || m.ta.Value() != "requests\n| summarize count() by resultCode\n| order by count_ desc" {
+		t.Fatalf("expected formatted query in the editor; mode=%v value=%q", m.mode, m.ta.Value())
+	}
+
+	// Without an argument the most recent query is formatted
+	m = newPostAuthModelWithKQL(&kqlOK{})
+	m.lastTemplate = "traces | take {{n=5}}"
+	m, _ = submitChat(t, m, "format")
+	if m.ta.Value() != "traces\n| take {{n=5}}" {
+		t.Fatalf("expected last query formatted; got %q", m.ta.Value())
+	}
+
+	m = newPostAuthModelWithKQL(&kqlOK{})
+	m, _ = submitChat(t, m, "format")
+	if m.mode != modeChat || !strings.Contains(m.content, "Nothing to format") {
+		t.Fatalf("expected a note when there is nothing to format")
+	}
+}
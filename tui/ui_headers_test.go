@@ -116,7 +116,7 @@ func TestInteractive_EllipsisCount_FromCanonicalHeaders(t *testing.T) {
 	m3Any, _ := m2.Update(tea.KeyMsg{Type: tea.KeyF6})
 	m3 := m3Any.(model)
 	view := m3.tbl.View()
-	// Same calculation: 7 total headers, dataCols=2 => hidden = 5
+	// timestamp (frozen) and message fit next to the indicator; the other 5 scroll off to the right
 	if !strings.Contains(view, "(+5)") {
 		t.Fatalf("expected interactive ellipsis '(+5)' reflecting hidden canonical headers; got: %q", view)
 	}
@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// classAt returns the class of the first byte of the first occurrence of sub.
+func classAt(t *testing.T, src string, classes []hlClass, sub string) hlClass {
+	t.Helper()
+	i := strings.Index(src, sub)
+	if i < 0 {
+		t.Fatalf("%q not in %q", sub, src)
+	}
+	return classes[i]
+}
+
+func TestHighlight_TokenClasses(t *testing.T) {
+	src := "traces // recent\n| where customDimensions.eventId == 'RT0005' and duration > 1.5h\n| extend d = customDimensions['al Object Id']"
+	classes := highlightClasses(src, -1)
+	tests := []struct {
+		sub  string
+		want hlClass
+	}{
+		{"traces", hlPlain},
+		{"// recent", hlComment},
+		{"| where", hlOperator},
+		{"where", hlKeyword},
+		{"customDimensions.eventId", hlDimension},
+		{"eventId", hlDimension},
+		{"==", hlOperator},
+		{"'RT0005'", hlString},
+		{"1.5h", hlNumber},
+		{"['al Object Id']", hlDimension},
+	}
+	for _, tt := range tests {
+		if got := classAt(t, src, classes, tt.sub); got != tt.want {
+			t.Errorf("class of %q = %d, want %d", tt.sub, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight_BracketsAtCursorAndUnbalanced(t *testing.T) {
+	src := "traces | where (a > ago(1h)) or b)"
+	open := strings.Index(src, "(a")
+	classes := highlightClasses(src, open)
+	if classes[open] != hlBracketMatch || classes[strings.Index(src, ") or")] != hlBracketMatch {
+		t.Fatalf("expected the pair around the cursor highlighted")
+	}
+	if classes[strings.Index(src, "(1h")] == hlBracketMatch {
+		t.Fatalf("did not expect the inner pair highlighted")
+	}
+	if classes[len(src)-1] != hlError {
+		t.Fatalf("expected the unbalanced ')' marked")
+	}
+	// Just after a closing bracket pairs it too
+	classes = highlightClasses(src, strings.Index(src, "(1h)")+4)
+	if classes[strings.Index(src, "(1h")] != hlBracketMatch {
+		t.Fatalf("expected the pair before the cursor highlighted")
+	}
+	// Unterminated strings are marked to the end of the line
+	src = "traces | where m == 'abc\n| take 1"
+	classes = highlightClasses(src, -1)
+	if classAt(t, src, classes, "'abc") != hlError || classAt(t, src, classes, "take") == hlError {
+		t.Fatalf("expected only the unterminated string marked")
+	}
+}
+
+func TestHighlight_EditorViewFollowsCursor(t *testing.T) {
+	m := newEditorModel(t, "")
+	mAny, _ := m.handleResize(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m = mAny.(model)
+	var lines []string
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, "| where n != "+strings.Repeat("x", i%3)+"line"+string(rune('A'+i%26)))
+	}
+	m.ta.SetValue("traces\n" + strings.Join(lines, "\n"))
+	view := m.editorView()
+	if got := strings.Count(view, "\n") + 1; got != m.ta.Height() {
+		t.Fatalf("expected %d editor rows, got %d", m.ta.Height(), got)
+	}
+	if !strings.Contains(view, "lineE") || strings.Contains(view, "traces") {
+		t.Fatalf("expected the end of the buffer (cursor) visible; got:\n%s", view)
+	}
+	for i := 0; i < 40; i++ {
+		m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyUp})
+	}
+	if view := m.editorView(); !strings.Contains(view, "traces") || !strings.HasPrefix(view, promptEditor) {
+		t.Fatalf("expected the top of the buffer after moving up; got:\n%s", view)
+	}
+}
+
+var sgrSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestHighlight_EditorWrapsLikeTextarea(t *testing.T) {
+	m := newEditorModel(t, "")
+	mAny, _ := m.handleResize(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m = mAny.(model)
+	m.ta.ShowLineNumbers = false
+	m.ta.SetWidth(24)
+	m.ta.SetHeight(12)
+	m.ta.SetValue("traces\n| where message has '日本語のテキストです' and severityLevel >= 3\n| project timestamp, customDimensions.averyveryverylongdimensionname\n")
+	plain := func(view string) []string {
+		var out []string
+		for _, l := range strings.Split(sgrSequence.ReplaceAllString(view, ""), "\n") {
+			out = append(out, strings.TrimRight(l, " "))
+		}
+		return out
+	}
+	got, want := plain(m.editorView()), plain(m.ta.View())
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected the textarea's wrapping\ngot:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+)
+
+func TestHistory_PersistRoundTripAndCap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	h := loadQueryHistory(path, 2)
+	for i := 0; i < 3; i++ {
+		if err := h.add(historyEntry{Query: fmt.Sprintf("traces | take %d", i), Timestamp: time.Now(), Success: true}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	// Re-running the newest query replaces it rather than appending
+	if err := h.add(historyEntry{Query: "traces | take 2", RowCount: 7, Success: true}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	reloaded := loadQueryHistory(path, 2)
+	if reloaded.len() != 2 {
+		t.Fatalf("expected 2 entries after cap, got %d", reloaded.len())
+	}
+	newest, _ := reloaded.recent(0)
+	if newest.Query != "traces | take 2" || newest.RowCount != 7 {
+		t.Fatalf("unexpected newest entry: %+v", newest)
+	}
+	oldest, _ := reloaded.recent(1)
+	if oldest.Query != "traces | take 1" {
+		t.Fatalf("expected oldest retained entry 'traces | take 1', got %q", oldest.Query)
+	}
+}
+
+func TestHistory_RecordedFromKQLResult(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m2Any, _ := m.Update(kqlResultMsg{
+		query:    "traces | take 1",
+		appID:    "app-1",
+		columns:  []appinsights.Column{{Name: "timestamp"}},
+		rows:     [][]interface{}{{"2025-01-01T00:00:00Z"}},
+		duration: 250 * time.Millisecond,
+	})
+	m2 := m2Any.(model)
+	m3Any, _ := m2.Update(kqlResultMsg{query: "bad", appID: "app-1", err: fmt.Errorf("bad kql (400)")})
+	m3 := m3Any.(model)
+	if m3.history.len() != 2 {
+		t.Fatalf("expected 2 history entries, got %d", m3.history.len())
+	}
+	failed, _ := m3.history.recent(0)
+	if failed.Success || !strings.Contains(failed.Error, "400") {
+		t.Fatalf("expected failed entry with error, got %+v", failed)
+	}
+	ok, _ := m3.history.recent(1)
+	if !ok.Success || ok.RowCount != 1 || ok.AppID != "app-1" || ok.DurationMs != 250 {
+		t.Fatalf("unexpected success entry: %+v", ok)
+	}
+}
+
+func TestHistory_ChatRecallUpDownRestoresDraft(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.history = &queryHistory{max: 10}
+	_ = m.history.add(historyEntry{Query: "traces // all\n| take 1"})
+	_ = m.history.add(historyEntry{Query: "requests | take 2"})
+	m.ta.SetValue("draft")
+
+	m2Any, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyUp})
+	m2 := m2Any.(model)
+	if got := m2.ta.Value(); got != "kql: requests | take 2" {
+		t.Fatalf("expected newest recall, got %q", got)
+	}
+	m3Any, _ := m2.handleKeyMessage(tea.KeyMsg{Type: tea.KeyUp})
+	m3 := m3Any.(model)
+	if m3.mode != modeKQLEditor || m3.ta.Value() != "traces // all\n| take 1" {
+		t.Fatalf("expected multi-line query opened in the editor unchanged, got mode %v %q", m3.mode, m3.ta.Value())
+	}
+	m4Any, _ := m3.handleKeyMessage(tea.KeyMsg{Type: tea.KeyDown})
+	if got := m4Any.(model).ta.Value(); got != "requests | take 2" {
+		t.Fatalf("expected newer recall in the editor, got %q", got)
+	}
+	m5Any, _ := m4Any.(model).handleKeyMessage(tea.KeyMsg{Type: tea.KeyDown})
+	if got := m5Any.(model).ta.Value(); got != "draft" {
+		t.Fatalf("expected draft restored, got %q", got)
+	}
+}
+
+func TestHistory_EditorRecallKeepsNewlines(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.history = &queryHistory{max: 10}
+	_ = m.history.add(historyEntry{Query: "traces\n| take 1"})
+	m.ta.SetValue("edit")
+	m2Any, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 := m2Any.(model)
+	m3Any, _ := m2.handleKeyMessage(tea.KeyMsg{Type: tea.KeyUp})
+	if got := m3Any.(model).ta.Value(); got != "traces\n| take 1" {
+		t.Fatalf("expected multi-line recall in editor, got %q", got)
+	}
+}
+
+func TestHistory_PanelSelectLoadsEditor(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.history = &queryHistory{max: 10}
+	_ = m.history.add(historyEntry{Query: "traces | take 1", Success: true})
+	_ = m.history.add(historyEntry{Query: "exceptions | take 3", Success: true})
+	m.ta.SetValue("history")
+	m2Any, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 := m2Any.(model)
+	if m2.mode != modeListHistory {
+		t.Fatalf("expected history panel mode, got %v", m2.mode)
+	}
+	if len(m2.list.Items()) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(m2.list.Items()))
+	}
+	m3Any, _ := m2.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEnter})
+	m3 := m3Any.(model)
+	if m3.mode != modeKQLEditor {
+		t.Fatalf("expected editor mode after selection, got %v", m3.mode)
+	}
+	if m3.ta.Value() != "exceptions | take 3" {
+		t.Fatalf("expected newest query loaded, got %q", m3.ta.Value())
+	}
+}
+
+func TestHistory_EmptyPanelMessage(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.ta.SetValue("history")
+	m2Any, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m2 := m2Any.(model)
+	if m2.mode != modeChat || !strings.Contains(m2.content, "No query history yet") {
+		t.Fatalf("expected empty-history hint in chat; mode=%v content=%q", m2.mode, m2.content)
+	}
+}
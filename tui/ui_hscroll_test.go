@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// wideTestRows are rows with twelve customDimensions keys, wider than an 80 column table.
+func wideTestRows() [][]interface{} {
+	dims := make([]string, 0, 12)
+	for i := range 12 {
+		dims = append(dims, fmt.Sprintf(`"key%02d":"value %02d"`, i, i))
+	}
+	custom := "{" + strings.Join(dims, ",") + "}"
+	return [][]interface{}{
+		{"2025-01-01T10:00:00Z", "Report run", custom},
+		{"2025-01-01T10:00:01Z", "Report run", custom},
+	}
+}
+
+func columnTitles(m model) []string {
+	var out []string
+	for _, c := range m.tbl.Columns() {
+		out = append(out, c.Title)
+	}
+	return out
+}
+
+func TestHScroll_RightScrollsToEveryHeaderWithTimestampFrozen(t *testing.T) {
+	m := newTableModel(t, tracesColumns, wideTestRows(), 80)
+	headers := m.lastDisplayHeaders
+	if len(headers) != 14 {
+		t.Fatalf("expected 14 headers, got %v", headers)
+	}
+	titles := columnTitles(m)
+	if !strings.HasPrefix(titles[len(titles)-1], "(+") {
+		t.Fatalf("expected a (+N) column before scrolling, got %v", titles)
+	}
+	for i := 1; i < len(headers); i++ {
+		m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRight})
+		if m.tblCol != i || !slices.Contains(m.tblVisible, i) {
+			t.Fatalf("step %d: cursor %d not shown in %v", i, m.tblCol, m.tblVisible)
+		}
+		if m.tblVisible[0] != 0 || !strings.Contains(m.tbl.Columns()[0].Title, "timestamp") {
+			t.Fatalf("step %d: expected timestamp frozen first, got %v", i, columnTitles(m))
+		}
+		k := slices.Index(m.tblVisible, i)
+		if !strings.HasPrefix(m.tbl.Columns()[k].Title, columnCursorMark) {
+			t.Fatalf("step %d: expected the cursor mark on %s, got %v", i, headers[i], columnTitles(m))
+		}
+	}
+	titles = columnTitles(m)
+	if strings.HasPrefix(titles[len(titles)-1], "(+") || !strings.Contains(titles[1], scrolledLeftMark) {
+		t.Fatalf("expected no (+N) and a ‹ mark at the right end, got %v", titles)
+	}
+	last := headers[len(headers)-1]
+	if !strings.Contains(m.statusLine(), fmt.Sprintf("col: %s (14 of 14)", last)) {
+		t.Fatalf("expected the column position in status, got %q", m.statusLine())
+	}
+	if h, v := m.selectedCell(0); h != last || v != "value 11" {
+		t.Fatalf("expected the scrolled cell selected, got %s=%q", h, v)
+	}
+
+	// Moving back into the frozen column keeps the scroll position
+	for range len(headers) {
+		m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyLeft})
+	}
+	if m.tblCol != 0 || !strings.HasPrefix(m.tbl.Columns()[0].Title, columnCursorMark) {
+		t.Fatalf("expected the cursor on timestamp, got %d", m.tblCol)
+	}
+}
+
+func TestHScroll_FreezeMessageAndResetOnNewResults(t *testing.T) {
+	m := newTableModel(t, tracesColumns, wideTestRows(), 80)
+	for range 6 {
+		m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRight})
+	}
+	if slices.Contains(m.tblVisible, 1) {
+		t.Fatalf("expected message scrolled off, visible %v", m.tblVisible)
+	}
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")})
+	if !slices.Equal(m.tblVisible[:2], []int{0, 1}) || !slices.Contains(m.tblVisible, 6) {
+		t.Fatalf("expected timestamp and message frozen with the cursor shown, visible %v", m.tblVisible)
+	}
+	if !strings.Contains(m.statusLine(), "message column frozen") {
+		t.Fatalf("expected a status note, got %q", m.statusLine())
+	}
+
+	mAny, _ := m.Update(kqlResultMsg{tableName: "PrimaryResult", query: "traces", columns: m.lastColumns, rows: m.lastRows})
+	m = mAny.(model)
+	if m.tblColOff != 0 {
+		t.Fatalf("expected new results to scroll back, offset %d", m.tblColOff)
+	}
+}
+
+func TestContentColumnWidthsAndScrollLayout(t *testing.T) {
+	headers := []string{"timestamp", "id", "text"}
+	data := [][]string{{"2025-01-01T10:00:00Z", "7", strings.Repeat("x", 100)}}
+	if got, want := contentColumnWidths(headers, data), []int{20, minScrollColWidth, maxScrollColWidth}; !slices.Equal(got, want) {
+		t.Fatalf("widths = %v, want %v", got, want)
+	}
+
+	widths := []int{20, 10, 10, 10, 10}
+	l := computeScrollLayout(widths, 1, 1, 60) // 22 + 12 + 12 + (+N) 7 fits; a third scrolled column does not
+	if !slices.Equal(l.visible, []int{0, 1, 2}) || l.hiddenRight != 2 || l.hiddenLeft != 0 {
+		t.Fatalf("offset 1: %+v", l)
+	}
+	l = computeScrollLayout(widths, 1, 3, 60) // the last columns need no (+N)
+	if !slices.Equal(l.visible, []int{0, 3, 4}) || l.hiddenRight != 0 || l.hiddenLeft != 2 {
+		t.Fatalf("offset 3: %+v", l)
+	}
+	l = computeScrollLayout([]int{20, 40, 10}, 1, 1, 40) // a column wider than the room is cut
+	if !slices.Equal(l.visible, []int{0, 1}) || l.widths[1] != 40-22-2-7 {
+		t.Fatalf("narrow: %+v", l)
+	}
+}
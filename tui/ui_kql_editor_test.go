@@ -12,11 +12,15 @@ import (
 )
 
 // fake KQL client for editor tests
-type kqlCapture struct{ last string }
+type kqlCapture struct {
+	last         string
+	lastTimespan appinsights.Timespan
+}
 
 func (k *kqlCapture) ValidateQuery(query string) error { return nil }
-func (k *kqlCapture) ExecuteQuery(ctx context.Context, query string) (*appinsights.QueryResponse, error) {
+func (k *kqlCapture) ExecuteQuery(ctx context.Context, query string, timespan appinsights.Timespan) (*appinsights.QueryResponse, error) {
 	k.last = query
+	k.lastTimespan = timespan
 	// Minimal success response
 	return &appinsights.QueryResponse{Tables: []appinsights.Table{{
 		Name:    "PrimaryResult",
@@ -315,3 +319,62 @@ func TestEditorMode_F6_WithoutResults(t *testing.T) {
 		t.Fatalf("expected no results message; got: %q", m3.content)
 	}
 }
+
+func TestEditor_RunBlockAtCursor(t *testing.T) {
+	buffer := "traces\n| take 1\n\n  \nrequests\n| where success == false\n| wher x\n\nexceptions"
+	m := newEditorModel(t, buffer)
+	moveTextareaCursor(&m.ta, 5, 3)
+	mAny, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyF9})
+	m = mAny.(model)
+	if cmd == nil {
+		t.Fatalf("expected a submit command")
+	}
+	msg, ok := cmd().(submitEditorMsg)
+	if !ok || !msg.block {
+		t.Fatalf("expected a block submit; got %#v", msg)
+	}
+	mAny, cmd = m.Update(msg)
+	m = mAny.(model)
+	if got := queryFromCmd(t, cmd); got != "requests\n| where success == false\n| wher x" {
+		t.Fatalf("expected only the block at the cursor; got %q", got)
+	}
+	if !strings.Contains(m.content, "> requests … (lines 5-7)") || m.ta.Value() != buffer {
+		t.Fatalf("expected block echo and the buffer kept; got %q", m.content)
+	}
+	// Error locations are relative to the block; the editor marks the buffer line
+	qe := &appinsights.QueryError{StatusCode: 400, InnerMessage: "Query could not be parsed at 'wher'", Line: 3, Column: 3}
+	mAny, _ = m.Update(kqlResultMsg{query: "requests\n| where success == false\n| wher x", err: qe})
+	m = mAny.(model)
+	if m.editorErr == nil || m.editorErr.line != 7 || m.ta.Line() != 6 {
+		t.Fatalf("expected buffer line 7 marked; err=%v line=%d", m.editorErr, m.ta.Line())
+	}
+
+	// Alt+R does the same; a blank line runs nothing
+	moveTextareaCursor(&m.ta, 3, 0)
+	mAny, cmd = m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r"), Alt: true})
+	m = mAny.(model)
+	mAny, cmd = m.Update(cmd())
+	m = mAny.(model)
+	if cmd != nil || !strings.Contains(m.content, "The cursor is on a blank line") {
+		t.Fatalf("expected nothing run from a blank line")
+	}
+}
+
+func TestEditor_ErrorColumnOnIndentedFirstLine(t *testing.T) {
+	buffer := "traces\n\n    requests | wher x\n    | take 1"
+	m := newEditorModel(t, buffer)
+	moveTextareaCursor(&m.ta, 2, 6)
+	mAny, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyF9})
+	mAny, cmd = mAny.(model).Update(cmd())
+	m = mAny.(model)
+	if got := queryFromCmd(t, cmd); got != "requests | wher x\n    | take 1" {
+		t.Fatalf("unexpected block %q", got)
+	}
+	// Column 12 of the sent text is 'wher'; in the buffer it sits after the indent
+	qe := &appinsights.QueryError{StatusCode: 400, InnerMessage: "Query could not be parsed at 'wher'", Line: 1, Column: 12}
+	mAny, _ = m.Update(kqlResultMsg{query: "requests | wher x\n    | take 1", err: qe})
+	m = mAny.(model)
+	if _, col := textareaCursor(m.ta); m.editorErr == nil || m.editorErr.line != 3 || col != 15 {
+		t.Fatalf("expected buffer line 3 column 16 marked; err=%v col=%d", m.editorErr, col)
+	}
+}
@@ -18,14 +18,14 @@ import (
 type kqlOK struct{ resp *appinsights.QueryResponse }
 
 func (k *kqlOK) ValidateQuery(query string) error { return nil }
-func (k *kqlOK) ExecuteQuery(ctx context.Context, query string) (*appinsights.QueryResponse, error) {
+func (k *kqlOK) ExecuteQuery(ctx context.Context, query string, timespan appinsights.Timespan) (*appinsights.QueryResponse, error) {
 	return k.resp, nil
 }
 
 type kqlValidateErr struct{ err error }
 
 func (k *kqlValidateErr) ValidateQuery(query string) error { return k.err }
-func (k *kqlValidateErr) ExecuteQuery(ctx context.Context, query string) (*appinsights.QueryResponse, error) {
+func (k *kqlValidateErr) ExecuteQuery(ctx context.Context, query string, timespan appinsights.Timespan) (*appinsights.QueryResponse, error) {
 	return nil, nil
 }
 
@@ -91,6 +91,27 @@ func TestKQL_ZeroTables_NoResults(t *testing.T) {
 	}
 }
 
+func TestKQL_SummaryReportsFetchLimit(t *testing.T) {
+	cols := []appinsights.Column{{Name: "a"}}
+	rows := [][]interface{}{{"1"}}
+	m := newPostAuthModelWithKQL(&kqlOK{resp: &appinsights.QueryResponse{}})
+	applied := appinsights.FetchLimitDecision{Applied: true, Limit: 50, Reason: "applied_no_user_limit"}
+	m2Any, _ := m.Update(kqlResultMsg{columns: cols, rows: rows, fetchLimit: applied})
+	if m2 := m2Any.(model); !strings.Contains(m2.content, "· fetch limit: take 50 added") {
+		t.Fatalf("expected injected take in summary; got: %q", m2.content)
+	}
+	explicit := appinsights.FetchLimitDecision{Limit: 50, Reason: "user_explicit"}
+	m3Any, _ := m.Update(kqlResultMsg{columns: cols, rows: rows, fetchLimit: explicit})
+	if m3 := m3Any.(model); !strings.Contains(m3.content, "· fetch limit: not added (query limits its rows)") {
+		t.Fatalf("expected explicit limit in summary; got: %q", m3.content)
+	}
+	later := appinsights.FetchLimitDecision{Applied: true, Limit: 50, Reason: "applied_no_user_limit", Statements: []int{2, 3}}
+	m4Any, _ := m.Update(kqlResultMsg{columns: cols, rows: rows, fetchLimit: later})
+	if m4 := m4Any.(model); !strings.Contains(m4.content, "· fetch limit: take 50 added to query 2, 3") {
+		t.Fatalf("expected capped statements in summary; got: %q", m4.content)
+	}
+}
+
 func TestKQL_SnapshotAndOpenInteractively(t *testing.T) {
 	cols := []appinsights.Column{{Name: "a"}, {Name: "b"}}
 	rows := [][]interface{}{{"1", "x"}, {"2", "y"}}
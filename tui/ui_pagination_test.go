@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+)
+
+var pageColumns = []appinsights.Column{{Name: "timestamp", Type: "datetime"}, {Name: "message", Type: "string"}, {Name: "customDimensions", Type: "dynamic"}}
+
+// newPagedModel returns a model holding a full first page of 2 rows for query.
+func newPagedModel(t *testing.T, query string) model {
+	t.Helper()
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.cfg.LogFetchSize = 2
+	rows := [][]interface{}{
+		{"2025-01-01T10:00:02Z", "b", `{"eventId":"RT0005"}`},
+		{"2025-01-01T10:00:01Z", "a", `{"eventId":"RT0005"}`},
+	}
+	mAny, _ := m.Update(kqlResultMsg{query: query, columns: pageColumns, rows: rows, duration: time.Second})
+	return mAny.(model)
+}
+
+func TestPagination_BuildPageQuery(t *testing.T) {
+	cursor := time.Date(2025, 1, 1, 10, 0, 1, 500_000_000, time.UTC)
+	got := buildPageQuery("traces\n| where severityLevel > 1\n| order by timestamp desc;", cursor, 51)
+	want := "traces\n| where severityLevel > 1\n| order by timestamp desc\n| where timestamp <= datetime(2025-01-01T10:00:01.5Z)\n| order by timestamp desc\n| take 51"
+	if got != want {
+		t.Fatalf("unexpected page query:\n%s", got)
+	}
+
+	// A trailing render stays the last stage
+	got = buildPageQuery("traces | order by timestamp desc | render timechart", cursor, 51)
+	want = "traces | order by timestamp desc | where timestamp <= datetime(2025-01-01T10:00:01.5Z)\n| order by timestamp desc\n| take 51\n| render timechart"
+	if got != want {
+		t.Fatalf("unexpected page query with render:\n%s", got)
+	}
+}
+
+func TestPagination_UnorderedQueryCannotSkipRows(t *testing.T) {
+	// The first page of an unordered query is an arbitrary fetch-limited sample,
+	// so paging back from its oldest row would skip newer rows never shown.
+	m := newPagedModel(t, "traces")
+	m2, cmd := submitChat(t, m, "more")
+	if cmd != nil || m2.paging.pending != nil || !strings.Contains(m2.content, "order by timestamp desc") {
+		t.Fatalf("expected paging an unordered query to be refused; got %q", m2.content)
+	}
+	m2.mode = modeTableResults
+	m2.initInteractiveTable()
+	m2.tbl.GotoBottom()
+	if _, cmd = m2.handleTableKey(tea.KeyMsg{Type: tea.KeyDown}); cmd != nil {
+		t.Fatalf("expected Down on the last row not to load more for an unordered query")
+	}
+
+	for q, want := range map[string]bool{
+		"traces | order by timestamp desc":                             true,
+		"traces | sort by timestamp | project timestamp, message":      true,
+		"traces | order by timestamp desc | render timechart":          true,
+		"traces | order by timestamp asc":                              false,
+		"traces | order by severityLevel desc":                         false,
+		"traces | order by timestamp desc | extend x = 1 | distinct x": false,
+		"traces | where message has 'x'":                               false,
+	} {
+		if got := queryNewestFirst(q); got != want {
+			t.Errorf("queryNewestFirst(%q) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestPagination_MoreRequestsOlderRowsFromCursor(t *testing.T) {
+	m := newPagedModel(t, "traces | order by timestamp desc")
+	m2, cmd := submitChat(t, m, "more")
+	if m2.paging.pending == nil || !strings.Contains(m2.statusLine(), "loading more…") {
+		t.Fatalf("expected a pending page request; status=%q", m2.statusLine())
+	}
+	res, ok := cmd().(kqlResultMsg)
+	if !ok || res.kind != kqlRunPage {
+		t.Fatalf("expected a page result message")
+	}
+	// One loaded row sits on the cursor timestamp, so one extra row is requested
+	if !strings.Contains(res.query, "| where timestamp <= datetime(2025-01-01T10:00:01Z)") || !strings.HasSuffix(res.query, "| take 3") {
+		t.Fatalf("unexpected page query %q", res.query)
+	}
+}
+
+func TestPagination_AppendsRowsDropsBoundaryRepeatsAndExtendsHeaders(t *testing.T) {
+	m := newPagedModel(t, "traces | order by timestamp desc")
+	m, _ = submitChat(t, m, "more")
+	headers := append([]string(nil), m.lastDisplayHeaders...)
+	page := [][]interface{}{
+		{"2025-01-01T10:00:01Z", "a", `{"eventId":"RT0005"}`}, // already loaded
+		{"2025-01-01T10:00:00Z", "c", `{"eventId":"RT0012","alObjectId":"50100"}`},
+		{"2025-01-01T09:59:59Z", "d", `{"eventId":"RT0012"}`},
+	}
+	mAny, _ := m.Update(kqlResultMsg{query: "page", kind: kqlRunPage, columns: pageColumns, rows: page, duration: time.Second})
+	m2 := mAny.(model)
+	if len(m2.lastRows) != 4 || m2.lastRows[2][1] != "c" {
+		t.Fatalf("expected 2 new rows appended after de-duplication; got %v", m2.lastRows)
+	}
+	if len(m2.lastDisplayHeaders) != len(headers)+1 || m2.lastDisplayHeaders[len(headers)] != "alObjectId" {
+		t.Fatalf("expected existing headers kept and alObjectId appended; before=%v after=%v", headers, m2.lastDisplayHeaders)
+	}
+	for i, h := range headers {
+		if m2.lastDisplayHeaders[i] != h {
+			t.Fatalf("expected header order kept; before=%v after=%v", headers, m2.lastDisplayHeaders)
+		}
+	}
+	if !strings.Contains(m2.content, "Loaded 2 older rows") || !strings.Contains(m2.content, "4 rows total") {
+		t.Fatalf("expected load summary; got %q", m2.content)
+	}
+	if m2.paging.exhausted || len(m2.history.entries) != 1 {
+		t.Fatalf("expected more pages and no history entry for the page query")
+	}
+}
+
+func TestPagination_ShortPageExhausts(t *testing.T) {
+	m := newPagedModel(t, "traces | order by timestamp desc")
+	m, _ = submitChat(t, m, "more")
+	mAny, _ := m.Update(kqlResultMsg{kind: kqlRunPage, columns: pageColumns, rows: [][]interface{}{{"2025-01-01T09:00:00Z", "z", "{}"}}})
+	m2, cmd := submitChat(t, mAny.(model), "more")
+	if cmd != nil || !strings.Contains(m2.content, "No older rows") {
+		t.Fatalf("expected paging to stop after a short page; got %q", m2.content)
+	}
+}
+
+func TestPagination_UnsupportedQueries(t *testing.T) {
+	m := newPagedModel(t, "traces | summarize count() by bin(timestamp, 1h)")
+	m2, cmd := submitChat(t, m, "more")
+	if cmd != nil || !strings.Contains(m2.content, "limits or aggregates") {
+		t.Fatalf("expected summarize to block paging; got %q", m2.content)
+	}
+
+	if queryLimitsRows("traces | join kind=leftouter (requests | take 5) on operation_Id") {
+		t.Fatalf("a take inside a subquery should not block paging")
+	}
+	if !queryLimitsRows("let t = traces;\nt | TOP 10 by timestamp") {
+		t.Fatalf("expected top after a let preamble to block paging")
+	}
+
+	m = newPostAuthModelWithKQL(&kqlOK{})
+	m2, cmd = submitChat(t, m, "more")
+	if cmd != nil || !strings.Contains(m2.content, "Run a query first") {
+		t.Fatalf("expected no-results message; got %q", m2.content)
+	}
+}
+
+func TestPagination_TableEndTriggersLoadMore(t *testing.T) {
+	m := newPagedModel(t, "traces | order by timestamp desc")
+	m.mode = modeTableResults
+	m.initInteractiveTable()
+	m.tbl.GotoBottom()
+	mAny, cmd := m.handleTableKey(tea.KeyMsg{Type: tea.KeyDown})
+	if m2 := mAny.(model); cmd == nil || m2.paging.pending == nil {
+		t.Fatalf("expected Down on the last row to load more")
+	}
+}
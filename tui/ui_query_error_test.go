@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+	"github.com/FBakkensen/bc-insights-tui/internal/kql"
+)
+
+func TestQueryError_EditorShowsLocationAndHighlightsLine(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	query := "traces\n| wher severityLevel > 2\n| take 10"
+	mAny, _ := m.enterEditor(query, false)
+	m = mAny.(model)
+	qe := &appinsights.QueryError{StatusCode: 400, Code: "BadArgumentError", InnerCode: "SYN0002",
+		InnerMessage: "Query could not be parsed at 'wher'", Line: 2, Column: 3, RequestID: "rid-1"}
+
+	m2Any, _ := m.Update(kqlResultMsg{query: query, err: qe})
+	m2 := m2Any.(model)
+	for _, want := range []string{
+		"Syntax error (400): Query could not be parsed at 'wher' (line 2, column 3)",
+		"  2 | | wher severityLevel > 2",
+		"Hint: check pipes",
+		"Request ID: rid-1",
+	} {
+		if !strings.Contains(m2.content, want) {
+			t.Fatalf("expected %q in scrollback; got %q", want, m2.content)
+		}
+	}
+	if !strings.Contains(m2.content, "\n        ^") {
+		t.Fatalf("expected caret under column 3; got %q", m2.content)
+	}
+	if m2.editorErr == nil || m2.ta.Line() != 1 || m2.ta.LineInfo().ColumnOffset != 2 {
+		t.Fatalf("expected editor cursor on the error; err=%v line=%d col=%d", m2.editorErr, m2.ta.Line(), m2.ta.LineInfo().ColumnOffset)
+	}
+	if m2.ta.FocusedStyle.CursorLine.GetBackground() != editorErrorLineStyle.GetBackground() {
+		t.Fatalf("expected error highlight on the cursor line")
+	}
+	if !strings.Contains(m2.statusLine(), "error at line 2, col 3") {
+		t.Fatalf("expected error location in status line; got %q", m2.statusLine())
+	}
+
+	m3Any, _ := m2.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	m3 := m3Any.(model)
+	if m3.editorErr != nil || strings.Contains(m3.statusLine(), "error at line") {
+		t.Fatalf("expected highlight cleared after editing")
+	}
+}
+
+func TestQueryError_HintsByKind(t *testing.T) {
+	tests := []struct {
+		qe   *appinsights.QueryError
+		want string
+	}{
+		{&appinsights.QueryError{StatusCode: 403, Message: "insufficient access"}, "Reader or Log Analytics Reader role"},
+		{&appinsights.QueryError{StatusCode: 404, Message: "path does not exist"}, "no Application Insights app with ID 'app-42'"},
+		{&appinsights.QueryError{StatusCode: 400, InnerCode: "LimitsExceeded", InnerMessage: "result set exceeded the limit"}, "hit service limits"},
+		{&appinsights.QueryError{StatusCode: 400, InnerCode: "SEM0100", InnerMessage: "Failed to resolve column"}, "case-sensitive"},
+	}
+	for _, tt := range tests {
+		m := newPostAuthModelWithKQL(&kqlOK{})
+		m2Any, _ := m.Update(kqlResultMsg{query: "traces", appID: "app-42", err: tt.qe})
+		if c := m2Any.(model).content; !strings.Contains(c, tt.want) {
+			t.Fatalf("status %d: expected hint %q; got %q", tt.qe.StatusCode, tt.want, c)
+		}
+	}
+}
+
+func TestQueryError_MapKQLErrorKeepsType(t *testing.T) {
+	qe := &appinsights.QueryError{StatusCode: 401}
+	if got := mapKQLError(qe, 30, nil); got != qe {
+		t.Fatalf("expected typed error to pass through, got %v", got)
+	}
+}
+
+func TestQueryError_LocalSyntaxErrorMarksEditor(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	query := "traces\n| where (severityLevel > 2"
+	mAny, _ := m.enterEditor(query, false)
+	m = mAny.(model)
+	m2Any, _ := m.Update(kqlResultMsg{query: query, err: kql.Validate(query)})
+	m2 := m2Any.(model)
+	if !strings.Contains(m2.content, "Syntax error: '(' is never closed (line 2, column 9)") || !strings.Contains(m2.content, "was not sent") {
+		t.Fatalf("expected local syntax error with location; got %q", m2.content)
+	}
+	if m2.editorErr == nil || m2.editorErr.line != 2 || m2.editorErr.col != 9 {
+		t.Fatalf("expected editor error mark at 2:9, got %+v", m2.editorErr)
+	}
+}
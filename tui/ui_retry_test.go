@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+)
+
+func TestKQLRetry_ShownInStatusAndScrollback(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.runningKQL = true
+	events := make(chan appinsights.RetryEvent)
+	close(events)
+	ev := appinsights.RetryEvent{Attempt: 2, MaxAttempts: 4, Status: 429, Wait: 3 * time.Second, RetryAfter: true}
+	m2Any, cmd := m.Update(kqlRetryMsg{event: ev, events: events})
+	m2 := m2Any.(model)
+	if !strings.Contains(m2.content, "Query throttled (429); retrying in 3.0s (Retry-After) · attempt 2/4") {
+		t.Fatalf("expected retry notice in scrollback; got %q", m2.content)
+	}
+	if !strings.Contains(m2.statusLine(), "running… (retry 2/4)") {
+		t.Fatalf("expected retry in status line; got %q", m2.statusLine())
+	}
+	if cmd == nil || cmd() != nil {
+		t.Fatalf("expected a listener cmd that ends once the channel is closed")
+	}
+
+	res := kqlResultMsg{
+		query:    "traces",
+		columns:  []appinsights.Column{{Name: "timestamp"}},
+		rows:     [][]interface{}{{"2025-01-01T00:00:00Z"}},
+		duration: 10 * time.Millisecond,
+		retries:  1,
+	}
+	m3Any, _ := m2.Update(res)
+	m3 := m3Any.(model)
+	if m3.retryStatus != "" || strings.Contains(m3.statusLine(), "retry") {
+		t.Fatalf("expected retry status cleared; got %q", m3.statusLine())
+	}
+	if !strings.Contains(m3.content, "· attempts: 2") {
+		t.Fatalf("expected summary to include attempts; got %q", m3.content)
+	}
+}
+
+func TestKQLRetry_IgnoredAfterResult(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	events := make(chan appinsights.RetryEvent)
+	close(events)
+	m2Any, _ := m.Update(kqlRetryMsg{event: appinsights.RetryEvent{Attempt: 2, MaxAttempts: 4, Status: 503}, events: events})
+	m2 := m2Any.(model)
+	if m2.retryStatus != "" || strings.Contains(m2.content, "retrying") {
+		t.Fatalf("expected late retry event to be dropped; content=%q", m2.content)
+	}
+}
+
+func TestDescribeRetry_TransportAndServerErrors(t *testing.T) {
+	if got := describeRetry(appinsights.RetryEvent{Attempt: 3, MaxAttempts: 4, Status: 503, Wait: time.Second}); got != "Query service error (503); retrying in 1.0s · attempt 3/4" {
+		t.Fatalf("unexpected 503 description: %q", got)
+	}
+	if got := describeRetry(appinsights.RetryEvent{Attempt: 2, MaxAttempts: 2, Err: "connection reset", Wait: 500 * time.Millisecond}); !strings.Contains(got, "request failed: connection reset; retrying in 0.5s") {
+		t.Fatalf("unexpected transport description: %q", got)
+	}
+}
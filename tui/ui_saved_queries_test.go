@@ -0,0 +1,199 @@
+package tui
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// submitChat types input into the chat box and presses Enter.
+func submitChat(t *testing.T, m model, input string) (model, tea.Cmd) {
+	t.Helper()
+	m.ta.SetValue(input)
+	mAny, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyEnter})
+	return mAny.(model), cmd
+}
+
+// queryFromCmd runs cmd and returns the query text carried by the resulting kqlResultMsg.
+func queryFromCmd(t *testing.T, cmd tea.Cmd) string {
+	t.Helper()
+	if cmd == nil {
+		t.Fatalf("expected a command")
+	}
+	res, ok := cmd().(kqlResultMsg)
+	if !ok {
+		t.Fatalf("expected kqlResultMsg")
+	}
+	return res.query
+}
+
+func TestTemplateParams_DefaultsAndOrder(t *testing.T) {
+	tpl := "traces | where timestamp > ago({{window=1h}}) | where customDimensions.companyName == '{{company}}' | where x == '{{company}}' | take {{n}}"
+	got := templateParams(tpl, map[string]string{"n": "10", "window": "ignored"})
+	want := []queryParam{
+		{Name: "window", Default: "1h", HasDefault: true},
+		{Name: "company"},
+		{Name: "n", Default: "10", HasDefault: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("templateParams = %+v; want %+v", got, want)
+	}
+	out := expandTemplate(tpl, map[string]string{"window": "2d", "company": "CRONUS", "n": "5"})
+	if out != "traces | where timestamp > ago(2d) | where customDimensions.companyName == 'CRONUS' | where x == 'CRONUS' | take 5" {
+		t.Fatalf("unexpected expansion: %q", out)
+	}
+}
+
+func TestParseParamArgs_Quoted(t *testing.T) {
+	got, err := parseParamArgs(`company="CRONUS International" window=1d`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["company"] != "CRONUS International" || got["window"] != "1d" {
+		t.Fatalf("unexpected args: %v", got)
+	}
+	if _, err := parseParamArgs("oops"); err == nil {
+		t.Fatalf("expected error for argument without '='")
+	}
+}
+
+func TestSavedQueries_PersistRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saved.yaml")
+	s := loadSavedQueries(path)
+	if _, err := s.put(savedQuery{Name: "slow-sql", Query: "traces\n| where x > {{ms=500}}"}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := s.put(savedQuery{Name: "a-first", Query: "requests"}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := s.put(savedQuery{Name: "bad name", Query: "x"}); err == nil {
+		t.Fatalf("expected invalid name error")
+	}
+	reloaded := loadSavedQueries(path)
+	if reloaded.len() != 2 || reloaded.queries[0].Name != "a-first" {
+		t.Fatalf("expected 2 queries sorted by name, got %+v", reloaded.queries)
+	}
+	q, ok := reloaded.get("SLOW-SQL")
+	if !ok || q.Query != "traces\n| where x > {{ms=500}}" {
+		t.Fatalf("expected case-insensitive lookup with multi-line query intact, got %+v", q)
+	}
+}
+
+func TestSaveAndRun_LastTemplateWithPrompt(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.savedQueries = &savedQueryStore{}
+
+	m, _ = submitChat(t, m, "save nothing")
+	if !strings.Contains(m.content, "Nothing to save yet") {
+		t.Fatalf("expected hint when no query has run; got %q", m.content)
+	}
+
+	m, cmd := submitChat(t, m, "kql: traces | where company == '{{company}}' | take {{n=5}}")
+	if cmd != nil || m.paramPrompt == nil {
+		t.Fatalf("expected prompt for missing parameter before running")
+	}
+	m, cmd = submitChat(t, m, "CRONUS")
+	if got := queryFromCmd(t, cmd); got != "traces | where company == 'CRONUS' | take 5" {
+		t.Fatalf("unexpected expanded query: %q", got)
+	}
+	mAny, _ := m.Update(cmd())
+	m = mAny.(model)
+
+	m, _ = submitChat(t, m, "save by-company")
+	if !strings.Contains(m.content, "Saved query 'by-company'. Parameters: company, n.") {
+		t.Fatalf("expected save confirmation; got %q", m.content)
+	}
+	saved, ok := m.savedQueries.get("by-company")
+	if !ok || !strings.Contains(saved.Query, "{{company}}") {
+		t.Fatalf("expected template (not expanded text) to be saved; got %+v", saved)
+	}
+
+	_, cmd = submitChat(t, m, "run by-company company=Fabrikam n=1")
+	if got := queryFromCmd(t, cmd); got != "traces | where company == 'Fabrikam' | take 1" {
+		t.Fatalf("unexpected query for run with args: %q", got)
+	}
+}
+
+func TestRunSaved_EscCancelsPromptWithoutQuitting(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.savedQueries = &savedQueryStore{}
+	_, _ = m.savedQueries.put(savedQuery{Name: "q", Query: "traces | where c == '{{company}}'"})
+
+	m, _ = submitChat(t, m, "run q")
+	if m.paramPrompt == nil || !strings.Contains(m.content, "Value for {{company}}") {
+		t.Fatalf("expected parameter prompt; got %q", m.content)
+	}
+	m2Any, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyEsc})
+	m2 := m2Any.(model)
+	if m2.quitting || m2.paramPrompt != nil {
+		t.Fatalf("expected Esc to cancel the prompt only; quitting=%v", m2.quitting)
+	}
+
+	m3, _ := submitChat(t, m2, "run missing")
+	if !strings.Contains(m3.content, "No saved query named 'missing'") {
+		t.Fatalf("expected not-found message; got %q", m3.content)
+	}
+}
+
+func TestQueriesPanel_EnterRunsAndEditLoads(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.savedQueries = &savedQueryStore{}
+	_, _ = m.savedQueries.put(savedQuery{Name: "errors", Query: "traces | take {{n=3}}"})
+
+	m, _ = submitChat(t, m, "queries")
+	if m.mode != modeListSavedQueries || len(m.list.Items()) != 1 {
+		t.Fatalf("expected saved queries panel with 1 item; mode=%v", m.mode)
+	}
+	m2Any, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	m2 := m2Any.(model)
+	if m2.mode != modeKQLEditor || m2.ta.Value() != "traces | take {{n=3}}" {
+		t.Fatalf("expected template in editor; mode=%v value=%q", m2.mode, m2.ta.Value())
+	}
+	_, cmd := m2.handleEditorSubmit()
+	if got := queryFromCmd(t, cmd); got != "traces | take 3" {
+		t.Fatalf("expected editor to apply inline defaults; got %q", got)
+	}
+
+	m3Any, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEnter})
+	if m3Any.(model).mode != modeChat {
+		t.Fatalf("expected chat mode after running from panel")
+	}
+	if got := queryFromCmd(t, cmd); got != "traces | take 3" {
+		t.Fatalf("unexpected query from panel: %q", got)
+	}
+}
+
+func TestQueriesPanel_EditorUsesStoredDefaultsAndResaveKeepsThem(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.savedQueries = &savedQueryStore{}
+	_, _ = m.savedQueries.put(savedQuery{Name: "by-company", Query: "traces | where company == '{{company}}'", Params: map[string]string{"company": "CRONUS"}})
+
+	m, _ = submitChat(t, m, "queries")
+	mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	m = mAny.(model)
+	mAny, cmd := m.handleEditorSubmit()
+	if got := queryFromCmd(t, cmd); got != "traces | where company == 'CRONUS'" {
+		t.Fatalf("expected the stored default applied in the editor; got %q", got)
+	}
+	m = mAny.(model)
+	mAny, _ = m.Update(cmd())
+	m = mAny.(model)
+
+	// Re-saving the query keeps its hand-edited defaults
+	mAny, _ = m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEsc})
+	m, _ = submitChat(t, mAny.(model), "save by-company")
+	if q, _ := m.savedQueries.get("by-company"); q.Params["company"] != "CRONUS" {
+		t.Fatalf("expected stored defaults kept on re-save; got %+v", q)
+	}
+
+	// Another buffer does not inherit them
+	m, _ = submitChat(t, m, "edit")
+	m.ta.SetValue("traces | where company == '{{company}}'")
+	mAny, cmd = m.handleEditorSubmit()
+	if cmd != nil || !strings.Contains(mAny.(model).content, "placeholders without defaults: company") {
+		t.Fatalf("expected placeholders without defaults to be refused")
+	}
+}
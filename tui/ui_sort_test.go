@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var sortTestRows = [][]interface{}{
+	{"2025-01-01T10:00:02Z", "beta", `{"executionTime":"00:00:10.000","rows":"9"}`},
+	{"2025-01-01T10:00:01Z", "alpha", `{"executionTime":"00:00:01.500","rows":"10"}`},
+	{"2025-01-01T10:00:03Z", "Gamma", `{"executionTime":"00:01:00.000"}`},
+}
+
+// sortBy moves the column cursor to header and presses 's' presses times.
+func sortBy(t *testing.T, m model, header string, presses int) model {
+	t.Helper()
+	col := slices.Index(m.lastDisplayHeaders, header)
+	if col < 0 {
+		t.Fatalf("header %q not in %v", header, m.lastDisplayHeaders)
+	}
+	m.tblCol = col
+	for range presses {
+		mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+		m = mAny.(model)
+	}
+	return m
+}
+
+// tableMessages returns the message column of the table rows in display order.
+func tableMessages(t *testing.T, m model) []string {
+	t.Helper()
+	col := slices.Index(m.lastDisplayHeaders, "message")
+	var out []string
+	for _, r := range m.tbl.Rows() {
+		out = append(out, r[col])
+	}
+	return out
+}
+
+func TestSort_CyclesAscDescAndAPIOrder(t *testing.T) {
+	m := newTableModel(t, tracesColumns, sortTestRows, 200)
+	m = sortBy(t, m, "message", 1)
+	if got := tableMessages(t, m); !slices.Equal(got, []string{"alpha", "beta", "Gamma"}) {
+		t.Fatalf("ascending: got %v", got)
+	}
+	title := m.tbl.Columns()[slices.Index(m.lastDisplayHeaders, "message")].Title
+	if !strings.Contains(title, sortAscMark+" message") {
+		t.Fatalf("expected ascending mark in header, got %q", title)
+	}
+	if !strings.Contains(m.statusLine(), "sort: message "+sortAscMark) {
+		t.Fatalf("expected sort note in status line, got %q", m.statusLine())
+	}
+	m = sortBy(t, m, "message", 1)
+	if got := tableMessages(t, m); !slices.Equal(got, []string{"Gamma", "beta", "alpha"}) {
+		t.Fatalf("descending: got %v", got)
+	}
+	m = sortBy(t, m, "message", 1)
+	if got := tableMessages(t, m); !slices.Equal(got, []string{"beta", "alpha", "Gamma"}) {
+		t.Fatalf("API order: got %v", got)
+	}
+	for _, c := range m.tbl.Columns() {
+		if strings.Contains(c.Title, sortAscMark) || strings.Contains(c.Title, sortDescMark) {
+			t.Fatalf("expected no sort mark in API order, got %q", c.Title)
+		}
+	}
+}
+
+func TestSort_TypeAwareColumns(t *testing.T) {
+	m := newTableModel(t, tracesColumns, sortTestRows, 200)
+	cases := []struct {
+		header string
+		want   []string
+	}{
+		{"timestamp", []string{"alpha", "beta", "Gamma"}},
+		{"executionTime", []string{"alpha", "beta", "Gamma"}}, // durations, not text
+		{"rows", []string{"beta", "alpha", "Gamma"}},          // 9 < 10, empty last
+	}
+	for _, c := range cases {
+		m = sortBy(t, m, c.header, 1)
+		if got := tableMessages(t, m); !slices.Equal(got, c.want) {
+			t.Fatalf("%s ascending: got %v, want %v", c.header, got, c.want)
+		}
+	}
+	// Descending keeps empty cells last
+	m = sortBy(t, m, "rows", 1)
+	if got := tableMessages(t, m); !slices.Equal(got, []string{"alpha", "beta", "Gamma"}) {
+		t.Fatalf("rows descending: got %v", got)
+	}
+}
+
+func TestSort_DetailsAndCopyUseOriginalRow(t *testing.T) {
+	last := captureClipboard(t)
+	m := newTableModel(t, tracesColumns, sortTestRows, 200)
+	m = sortBy(t, m, "message", 2) // Gamma, beta, alpha
+	if got := m.lastRows[m.rowIndex(m.tbl.Cursor())][1]; got != "beta" {
+		t.Fatalf("expected the cursor to stay on beta after sorting, got %v", got)
+	}
+	m.tbl.GotoTop()
+	mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEnter})
+	m = mAny.(model)
+	if m.mode != modeDetails || m.detailsRow != 2 || !strings.Contains(m.detailsContent, "message: Gamma") {
+		t.Fatalf("expected details of original row 2 (Gamma), got row %d: %q", m.detailsRow, m.detailsContent)
+	}
+	_, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	runCmd(cmd)
+	if got := last(); !strings.Contains(got, `"message": "Gamma"`) {
+		t.Fatalf("expected the Gamma row copied, got %q", got)
+	}
+}
+
+func TestSort_AppendedRowsAreSortedInAndCursorKept(t *testing.T) {
+	m := newTableModel(t, tracesColumns, sortTestRows, 200)
+	m = sortBy(t, m, "message", 1) // alpha, beta, Gamma
+	m.tbl.SetCursor(1)             // beta
+	m.appendRows([][]interface{}{{"2025-01-01T09:00:00Z", "aardvark", `{}`}})
+	if got := tableMessages(t, m); !slices.Equal(got, []string{"aardvark", "alpha", "beta", "Gamma"}) {
+		t.Fatalf("got %v", got)
+	}
+	if got := m.lastRows[m.rowIndex(m.tbl.Cursor())][1]; got != "beta" {
+		t.Fatalf("expected the cursor to stay on beta, got %v", got)
+	}
+}
+
+func TestParseBCDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"00:00:01.234":      1234 * time.Millisecond,
+		"01:02:03":          time.Hour + 2*time.Minute + 3*time.Second,
+		"1.00:00:00.5":      24*time.Hour + 500*time.Millisecond,
+		"-00:00:02":         -2 * time.Second,
+		"00:00:00.0000001":  100 * time.Nanosecond,
+		"00:00:00.00000000": 0,
+	}
+	for in, want := range cases {
+		if got, ok := parseBCDuration(in); !ok || got != want {
+			t.Errorf("parseBCDuration(%q) = %v, %v; want %v", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "12", "00:61:00", "a:b:c", "2025-01-01"} {
+		if _, ok := parseBCDuration(in); ok {
+			t.Errorf("parseBCDuration(%q) should fail", in)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeKeys sends text one rune at a time, then the given special keys.
+func typeKeys(t *testing.T, m model, text string, keys ...tea.KeyType) model {
+	t.Helper()
+	for _, r := range text {
+		mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = mAny.(model)
+	}
+	for _, k := range keys {
+		mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: k})
+		m = mAny.(model)
+	}
+	return m
+}
+
+func cursorMessage(m model) string {
+	idx := m.rowIndex(m.tbl.Cursor())
+	if idx < 0 {
+		return ""
+	}
+	return m.lastRows[idx][1].(string)
+}
+
+func TestTableSearch_MarksMatchesAndJumps(t *testing.T) {
+	m := newTableModel(t, tracesColumns, sortTestRows, 200) // beta, alpha, Gamma
+	m = typeKeys(t, m, "/a")
+	if m.tblFind.typing != '/' || !strings.Contains(m.View(), "/a") {
+		t.Fatalf("expected the search prompt open, typing=%q", m.tblFind.typing)
+	}
+	m = typeKeys(t, m, "lpha", tea.KeyEnter)
+	if cursorMessage(m) != "alpha" {
+		t.Fatalf("expected the cursor on alpha, got %q", cursorMessage(m))
+	}
+	col := slices.Index(m.lastDisplayHeaders, "message")
+	if got := m.tbl.Rows()[1][col]; got != searchMark+"alpha" {
+		t.Fatalf("expected the matching cell marked, got %q", got)
+	}
+	if got := m.tbl.Rows()[0][col]; strings.HasPrefix(got, searchMark) {
+		t.Fatalf("expected beta unmarked, got %q", got)
+	}
+	if !strings.Contains(m.statusLine(), "/alpha: match 1 of 1") {
+		t.Fatalf("expected match position in status, got %q", m.statusLine())
+	}
+
+	// key=value on a customDimensions key; n/N wrap around
+	m = typeKeys(t, m, "/")
+	m.tblFind.prompt.SetValue("")
+	m = typeKeys(t, m, "executionTime~^00:0", tea.KeyEnter)
+	if got := m.tblFind.matches; !slices.Equal(got, []int{0, 1, 2}) {
+		t.Fatalf("expected all rows to match, got %v", got)
+	}
+	m.tbl.SetCursor(2)
+	m = typeKeys(t, m, "n")
+	if m.tbl.Cursor() != 0 {
+		t.Fatalf("expected n to wrap to row 0, got %d", m.tbl.Cursor())
+	}
+	m = typeKeys(t, m, "N")
+	if m.tbl.Cursor() != 2 {
+		t.Fatalf("expected N to wrap to row 2, got %d", m.tbl.Cursor())
+	}
+
+	// Esc clears the search before closing the table
+	m = typeKeys(t, m, "", tea.KeyEsc)
+	if m.mode != modeTableResults || m.tblFind.search != nil {
+		t.Fatalf("expected search cleared and table open, mode=%v", m.mode)
+	}
+	m = typeKeys(t, m, "", tea.KeyEsc)
+	if m.mode == modeTableResults {
+		t.Fatalf("expected second Esc to close the table")
+	}
+}
+
+func TestTableFilter_HidesRowsAndKeepsRowMapping(t *testing.T) {
+	m := newTableModel(t, tracesColumns, sortTestRows, 200)
+	m = typeKeys(t, m, "&rows=10", tea.KeyEnter)
+	if got := tableMessages(t, m); !slices.Equal(got, []string{"alpha"}) {
+		t.Fatalf("expected only alpha, got %v", got)
+	}
+	if !strings.Contains(m.statusLine(), "filter rows=10: 1 of 3 rows") {
+		t.Fatalf("expected filter status, got %q", m.statusLine())
+	}
+	mAny, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEnter})
+	m = mAny.(model)
+	if m.mode != modeDetails || m.detailsRow != 1 {
+		t.Fatalf("expected details of original row 1, got mode %v row %d", m.mode, m.detailsRow)
+	}
+	m = typeKeys(t, m, "", tea.KeyEsc)
+
+	// Sorting applies to the filtered rows; Esc in the prompt restores the filter
+	m = typeKeys(t, m, "&")
+	m.tblFind.prompt.SetValue("")
+	m = typeKeys(t, m, "~^(alpha|gamma)$", tea.KeyEnter)
+	m = sortBy(t, m, "message", 2)
+	if got := tableMessages(t, m); !slices.Equal(got, []string{"Gamma", "alpha"}) {
+		t.Fatalf("expected filtered rows sorted descending, got %v", got)
+	}
+	m = typeKeys(t, m, "&x", tea.KeyEsc)
+	if m.tblFind.filter == nil || m.tblFind.filter.pattern != "~^(alpha|gamma)$" || len(m.tbl.Rows()) != 2 {
+		t.Fatalf("expected Esc to restore the filter, got %+v", m.tblFind.filter)
+	}
+
+	// An invalid regexp is reported and leaves the filter unchanged
+	m = typeKeys(t, m, "&")
+	m.tblFind.prompt.SetValue("")
+	m = typeKeys(t, m, "~(")
+	if !strings.Contains(m.statusLine(), "invalid regular expression") {
+		t.Fatalf("expected regexp error in status, got %q", m.statusLine())
+	}
+	m = typeKeys(t, m, "", tea.KeyEnter)
+	if len(m.tbl.Rows()) != 2 {
+		t.Fatalf("expected the previous filter kept, got %d rows", len(m.tbl.Rows()))
+	}
+}
+
+func TestParseRowMatcher(t *testing.T) {
+	cases := []struct {
+		pattern, key, value string
+		match               bool
+	}{
+		{"rt0005", "eventId", "RT0005", true},
+		{"eventId=rt0005", "eventId", "RT0005", true},
+		{"eventId=RT00", "eventId", "RT0005", false},
+		{"eventId=RT0005", "alObjectId", "RT0005", false},
+		{"~^rt00(05|18)$", "eventId", "RT0018", true},
+		{"alObjectId~^501", "alObjectId", "50100", true},
+		{"a = b", "message", "x a = b y", true}, // not a key: plain text
+	}
+	for _, c := range cases {
+		rm, err := parseRowMatcher(c.pattern)
+		if err != nil {
+			t.Fatalf("%q: %v", c.pattern, err)
+		}
+		if got := rm.matchField(c.key, c.value); got != c.match {
+			t.Errorf("%q on %s=%s: got %v", c.pattern, c.key, c.value, got)
+		}
+	}
+	if _, err := parseRowMatcher("key~[a-"); err == nil {
+		t.Fatal("expected an error for an invalid regexp")
+	}
+}
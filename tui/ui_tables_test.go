@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+)
+
+func multiTableResult() kqlResultMsg {
+	cols := []appinsights.Column{{Name: "timestamp"}, {Name: "message"}, {Name: "customDimensions"}}
+	primary := appinsights.Table{
+		Name:    "PrimaryResult",
+		Columns: cols,
+		Rows:    [][]interface{}{{"2025-01-01T00:00:00Z", "hello", `{"eventId":"RT0005"}`}},
+	}
+	counts := appinsights.Table{
+		Name:    "ErrorCounts",
+		Columns: cols,
+		Rows: [][]interface{}{
+			{"2025-01-01T00:00:00Z", "post failed", `{"operation":"post"}`},
+			{"2025-01-01T00:00:01Z", "get failed", `{"operation":"get"}`},
+		},
+	}
+	return kqlResultMsg{
+		query:     "traces | take 1; requests | summarize count() by operation",
+		tableName: primary.Name,
+		columns:   primary.Columns,
+		rows:      primary.Rows,
+		tables:    []appinsights.Table{counts, primary},
+		duration:  5 * time.Millisecond,
+	}
+}
+
+func TestPrimaryTableIndex(t *testing.T) {
+	tables := []appinsights.Table{{Name: "table_0"}, {Name: "x", Columns: []appinsights.Column{{Name: "a"}}}, {Name: "primaryresult"}}
+	if got := primaryTableIndex(tables); got != 2 {
+		t.Fatalf("expected PrimaryResult index 2, got %d", got)
+	}
+	if got := primaryTableIndex(tables[:2]); got != 1 {
+		t.Fatalf("expected first table with columns, got %d", got)
+	}
+}
+
+func TestKQLResult_MultipleTablesListedAndSnapshotted(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m2Any, _ := m.Update(multiTableResult())
+	m2 := m2Any.(model)
+	if len(m2.lastTables) != 2 || m2.lastTable != "PrimaryResult" {
+		t.Fatalf("expected 2 tables with PrimaryResult active; got %d active=%q", len(m2.lastTables), m2.lastTable)
+	}
+	if !strings.Contains(m2.content, "Result tables (2): ErrorCounts (2 rows), PrimaryResult (1 rows)") {
+		t.Fatalf("expected table list in scrollback; got %q", m2.content)
+	}
+	if !strings.Contains(m2.content, "── ErrorCounts · 2 rows ──") || !strings.Contains(m2.content, "── PrimaryResult · 1 rows ──") {
+		t.Fatalf("expected a snapshot heading per table; got %q", m2.content)
+	}
+}
+
+func TestTableView_TabSwitchesTablesWithOwnHeaders(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m2Any, _ := m.Update(multiTableResult())
+	m3Any, _ := m2Any.(model).handleKeyMessage(tea.KeyMsg{Type: tea.KeyF6})
+	m3 := m3Any.(model)
+	if m3.mode != modeTableResults {
+		t.Fatalf("expected table mode, got %v", m3.mode)
+	}
+	primaryHeaders := append([]string(nil), m3.lastDisplayHeaders...)
+
+	m4Any, _ := m3.handleKeyMessage(tea.KeyMsg{Type: tea.KeyTab})
+	m4 := m4Any.(model)
+	if m4.lastTable != "ErrorCounts" || len(m4.lastRows) != 2 {
+		t.Fatalf("expected ErrorCounts after Tab; got %q (%d rows)", m4.lastTable, len(m4.lastRows))
+	}
+	if strings.Join(m4.lastDisplayHeaders, ",") == strings.Join(primaryHeaders, ",") {
+		t.Fatalf("expected ErrorCounts to have its own headers; got %v", m4.lastDisplayHeaders)
+	}
+	if !strings.Contains(m4.tbl.View(), "operation") {
+		t.Fatalf("expected table view to show ErrorCounts columns; got %q", m4.tbl.View())
+	}
+	if !strings.Contains(m4.statusLine(), "table 1/2: ErrorCounts") {
+		t.Fatalf("expected status line to name the active table; got %q", m4.statusLine())
+	}
+
+	m5Any, _ := m4.handleKeyMessage(tea.KeyMsg{Type: tea.KeyShiftTab})
+	m5 := m5Any.(model)
+	if m5.lastTable != "PrimaryResult" || strings.Join(m5.lastDisplayHeaders, ",") != strings.Join(primaryHeaders, ",") {
+		t.Fatalf("expected PrimaryResult with its original headers; got %q %v", m5.lastTable, m5.lastDisplayHeaders)
+	}
+}
@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+)
+
+var tailColumns = []appinsights.Column{
+	{Name: "timestamp", Type: "datetime"}, {Name: "message", Type: "string"}, {Name: "itemId", Type: "string"},
+	{Name: "customDimensions", Type: "dynamic"}, {Name: tailIngestionColumn, Type: "datetime"},
+}
+
+func tailRow(ts, msg, id, ingested string) []interface{} {
+	return []interface{}{ts, msg, id, `{"eventId":"RT0005"}`, ingested}
+}
+
+// startTestTail runs 'tail <args>' and returns the model with its first poll pending.
+func startTestTail(t *testing.T, args string) (model, kqlResultMsg) {
+	t.Helper()
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m, cmd := submitChat(t, m, strings.TrimSpace("tail "+args))
+	if cmd == nil || !m.tail.active {
+		t.Fatalf("expected tail to start; content=%q", m.content)
+	}
+	res, ok := cmd().(kqlResultMsg)
+	if !ok || res.kind != kqlRunTail {
+		t.Fatalf("expected a tail poll")
+	}
+	return m, res
+}
+
+func TestTail_QueryBuilding(t *testing.T) {
+	if got := tailBaseQuery("severityLevel >= 3"); got != "traces\n| where severityLevel >= 3" {
+		t.Fatalf("filter: %q", got)
+	}
+	if got := tailBaseQuery("| where message has 'x'"); got != "traces\n| where message has 'x'" {
+		t.Fatalf("pipe filter: %q", got)
+	}
+	since := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	got := buildTailQuery("traces", since, 51)
+	want := "traces\n| extend tailIngestionTime = ingestion_time()\n| where timestamp >= datetime(2025-01-01T10:00:00Z)\n| order by timestamp asc\n| take 51"
+	if got != want {
+		t.Fatalf("unexpected poll query:\n%s", got)
+	}
+}
+
+func TestTail_PollsAppendNewRowsWithFollow(t *testing.T) {
+	m, first := startTestTail(t, "severityLevel >= 3")
+	if !strings.Contains(first.query, "| where severityLevel >= 3") || !strings.Contains(first.query, "| order by timestamp desc") {
+		t.Fatalf("unexpected first poll query %q", first.query)
+	}
+	first.err = nil
+	first.columns = tailColumns
+	first.rows = [][]interface{}{
+		tailRow("2025-01-01T10:00:02Z", "b", "id-b", "2025-01-01T10:00:05Z"),
+		tailRow("2025-01-01T10:00:01Z", "a", "id-a", "2025-01-01T10:00:03Z"),
+	}
+	mAny, cmd := m.Update(first)
+	m = mAny.(model)
+	if cmd == nil || m.mode != modeTableResults || len(m.lastRows) != 2 || m.lastRows[0][1] != "a" {
+		t.Fatalf("expected first poll oldest-first in the table; mode=%v rows=%v", m.mode, m.lastRows)
+	}
+	if !strings.Contains(m.statusLine(), "tail: 2 rows · ingestion lag 3s") {
+		t.Fatalf("expected tail status with lag; got %q", m.statusLine())
+	}
+
+	mAny, cmd = m.Update(tailTickMsg{gen: m.tail.gen})
+	m = mAny.(model)
+	next, ok := cmd().(kqlResultMsg)
+	if !ok || !strings.Contains(next.query, "| where timestamp >= datetime(2025-01-01T10:00:02Z)") {
+		t.Fatalf("expected poll from the newest timestamp; got %q", next.query)
+	}
+	next.err = nil
+	next.columns = tailColumns
+	next.rows = [][]interface{}{
+		tailRow("2025-01-01T10:00:02Z", "b", "id-b", "2025-01-01T10:00:05Z"), // already shown
+		tailRow("2025-01-01T10:00:02Z", "b2", "id-b2", "2025-01-01T10:00:06Z"),
+		tailRow("2025-01-01T10:00:07Z", "c", "id-c", "2025-01-01T10:00:08Z"),
+	}
+	mAny, _ = m.Update(next)
+	m = mAny.(model)
+	if len(m.lastRows) != 4 || m.lastRows[3][1] != "c" {
+		t.Fatalf("expected 2 new rows appended after itemId de-duplication; got %v", m.lastRows)
+	}
+	if m.tbl.Cursor() != 3 {
+		t.Fatalf("expected the table to follow the newest row; cursor=%d", m.tbl.Cursor())
+	}
+	if !m.tail.hasLag || m.tail.lag != time.Second {
+		t.Fatalf("expected lag from the newest row, got %v", m.tail.lag)
+	}
+}
+
+func TestTail_TrimKeepsDetailsOnTheirRow(t *testing.T) {
+	rows := make([][]interface{}, 0, tailMaxRows)
+	for i := range tailMaxRows {
+		rows = append(rows, tailRow("2025-01-01T10:00:00Z", fmt.Sprintf("m%d", i), fmt.Sprintf("id-%d", i), ""))
+	}
+	m := newTableModel(t, tailColumns, rows, 80)
+	m.tbl.SetCursor(5)
+	m = pressKey(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	m.lastRows = append(m.lastRows, tailRow("2025-01-01T10:00:01Z", "new1", "id-new1", ""), tailRow("2025-01-01T10:00:01Z", "new2", "id-new2", ""))
+	m.trimTailRows()
+	if m.detailsRow != 3 || m.lastRows[m.detailsRow][1] != "m5" || !strings.Contains(m.statusLine(), fmt.Sprintf("row 4 of %d", tailMaxRows)) {
+		t.Fatalf("expected details to follow m5 to row 3; row %d status %q", m.detailsRow, m.statusLine())
+	}
+
+	m.lastRows = append(m.lastRows, make([][]interface{}, 4)...)
+	for i := range 4 {
+		m.lastRows[tailMaxRows+i] = tailRow("2025-01-01T10:00:02Z", fmt.Sprintf("later%d", i), fmt.Sprintf("id-later%d", i), "")
+	}
+	m.trimTailRows()
+	if m.detailsRow != -1 || !strings.Contains(m.statusLine(), "row dropped by tail") {
+		t.Fatalf("expected the dropped row marked gone; row %d status %q", m.detailsRow, m.statusLine())
+	}
+	mAny, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if cmd != nil || mAny.(model).mode != modeDetails {
+		t.Fatalf("expected no copy of a dropped row")
+	}
+}
+
+func TestTail_StopDropsInFlightPoll(t *testing.T) {
+	m, res := startTestTail(t, "")
+	m.runningKQL = true // the poll is still in flight
+	m, _ = submitChat(t, m, "tail stop")
+	if m.tail.active || m.runningKQL || !strings.Contains(m.content, "Tail stopped after 0 polls") {
+		t.Fatalf("expected tail stopped and running state cleared; content=%q", m.content)
+	}
+	m.runningKQL = true // a new query started meanwhile
+	mAny, cmd := m.Update(res)
+	if m2 := mAny.(model); !m2.runningKQL || cmd != nil {
+		t.Fatalf("expected the late poll to be ignored")
+	}
+}
+
+func TestTail_EscStopsInsteadOfQuitting(t *testing.T) {
+	m, _ := startTestTail(t, "")
+	mAny, cmd := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyEsc})
+	m2 := mAny.(model)
+	if m2.quitting || cmd != nil || m2.tail.active {
+		t.Fatalf("expected Esc to stop the tail")
+	}
+}
+
+func TestTail_SavedQueryNeedsParams(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.savedQueries = &savedQueryStore{}
+	if _, err := m.savedQueries.put(savedQuery{Name: "by-company", Query: "traces | where customDimensions.companyName == '{{company}}'"}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	m2, cmd := submitChat(t, m, "tail by-company")
+	if cmd != nil || !strings.Contains(m2.content, "needs values for: company") {
+		t.Fatalf("expected missing parameter message; got %q", m2.content)
+	}
+	m3, cmd := submitChat(t, m, "tail by-company company=CRONUS")
+	if cmd == nil || !strings.Contains(m3.tail.query, "== 'CRONUS'") {
+		t.Fatalf("expected saved query tail; query=%q", m3.tail.query)
+	}
+}
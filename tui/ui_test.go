@@ -57,6 +57,26 @@ func newTestModel() model {
 	return m
 }
 
+// tracesColumns are the columns of a typical traces result.
+var tracesColumns = []appinsights.Column{{Name: "timestamp", Type: "datetime"}, {Name: "message", Type: "string"}, {Name: "customDimensions", Type: "dynamic"}}
+
+// showResults feeds cols and rows to m as the result of the query "traces".
+func showResults(t *testing.T, m model, cols []appinsights.Column, rows [][]interface{}) model {
+	t.Helper()
+	mAny, _ := m.Update(kqlResultMsg{tableName: "PrimaryResult", query: "traces", columns: cols, rows: rows})
+	return mAny.(model)
+}
+
+// newTableModel returns a signed-in model, width columns wide, showing cols and
+// rows in the interactive table (F6).
+func newTableModel(t *testing.T, cols []appinsights.Column, rows [][]interface{}, width int) model {
+	t.Helper()
+	mAny, _ := newPostAuthModelWithKQL(&kqlOK{}).Update(tea.WindowSizeMsg{Width: width, Height: 40})
+	m := showResults(t, mAny.(model), cols, rows)
+	mAny, _ = m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyF6})
+	return mAny.(model)
+}
+
 func TestUI_PreAuth_LoginFlow_SyntheticMsgs(t *testing.T) {
 	m := newTestModel()
 
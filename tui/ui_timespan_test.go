@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/FBakkensen/bc-insights-tui/appinsights"
+)
+
+func TestTimeCommand_SetsWindowForQueries(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m, _ = submitChat(t, m, "time 4h")
+	if m.timespan.String() != "PT4H" {
+		t.Fatalf("expected PT4H, got %q", m.timespan.String())
+	}
+	if m.cfg.QueryTimespan != "PT4H" {
+		t.Fatalf("expected config queryTimespan to be updated, got %q", m.cfg.QueryTimespan)
+	}
+	if !strings.Contains(m.statusLine(), "time: last PT4H") {
+		t.Fatalf("expected status indicator to show window; got %q", m.statusLine())
+	}
+	_, cmd := submitChat(t, m, "kql: traces | take 1")
+	res, ok := cmd().(kqlResultMsg)
+	if !ok || res.timespan.String() != "PT4H" {
+		t.Fatalf("expected query to carry the active timespan; got %+v", res.timespan)
+	}
+
+	m, _ = submitChat(t, m, "time off")
+	if !m.timespan.IsZero() || !strings.Contains(m.statusLine(), "query-defined") {
+		t.Fatalf("expected window cleared; status=%q", m.statusLine())
+	}
+}
+
+func TestTimeCommand_InvalidKeepsCurrent(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m.timespan = appinsights.Timespan{Duration: "P1D"}
+	m, _ = submitChat(t, m, "time soon")
+	if m.timespan.String() != "P1D" || !strings.Contains(m.content, "Invalid time range") {
+		t.Fatalf("expected invalid input to be rejected; ts=%q content=%q", m.timespan.String(), m.content)
+	}
+	m, _ = submitChat(t, m, "config set queryTimespan=whenever")
+	if m.timespan.String() != "P1D" || m.cfg.QueryTimespan != "" {
+		t.Fatalf("expected config set to validate the range; ts=%q cfg=%q", m.timespan.String(), m.cfg.QueryTimespan)
+	}
+}
+
+func TestTimePicker_SelectPreset(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	m, _ = submitChat(t, m, "time")
+	if m.mode != modeListTimespan {
+		t.Fatalf("expected time picker, got mode %v", m.mode)
+	}
+	m2Any, _ := m.handleKeyMessage(tea.KeyMsg{Type: tea.KeyDown})
+	m3Any, _ := m2Any.(model).handleKeyMessage(tea.KeyMsg{Type: tea.KeyEnter})
+	m3 := m3Any.(model)
+	if m3.mode != modeChat || m3.timespan.String() != "PT15M" {
+		t.Fatalf("expected PT15M after picking second preset; mode=%v ts=%q", m3.mode, m3.timespan.String())
+	}
+}
+
+func TestKQLResult_SummaryShowsWindow(t *testing.T) {
+	m := newPostAuthModelWithKQL(&kqlOK{})
+	res := kqlResultMsg{
+		query:    "traces",
+		timespan: appinsights.Timespan{Duration: "PT1H"},
+		columns:  []appinsights.Column{{Name: "timestamp"}},
+		rows:     [][]interface{}{{"2025-01-01T00:00:00Z"}},
+		duration: 10 * time.Millisecond,
+	}
+	m2Any, _ := m.Update(res)
+	if !strings.Contains(m2Any.(model).content, "· time: last PT1H") {
+		t.Fatalf("expected summary to include window; got %q", m2Any.(model).content)
+	}
+}
@@ -3,15 +3,20 @@ package tui
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/FBakkensen/bc-insights-tui/appinsights"
 	"github.com/FBakkensen/bc-insights-tui/auth"
+	"github.com/FBakkensen/bc-insights-tui/config"
 	"github.com/FBakkensen/bc-insights-tui/internal/telemetry"
 	"github.com/FBakkensen/bc-insights-tui/internal/util"
 	"github.com/FBakkensen/bc-insights-tui/logging"
@@ -41,7 +46,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		return m.handleKeyMessage(msg)
 	case submitEditorMsg:
-		return m.handleEditorSubmit()
+		return m.submitEditor(msg.block)
 	case deviceCodeMsg:
 		return m.handleDeviceCode(msg)
 	case authSuccessMsg:
@@ -54,12 +59,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleInsightsLoaded(msg)
 	case kqlResultMsg:
 		return m.handleKQLResult(msg)
+	case kqlRetryMsg:
+		return m.handleKQLRetry(msg)
+	case tailTickMsg:
+		return m.handleTailTick(msg)
+	case externalEditorMsg:
+		return m.handleExternalEditorResult(msg)
 	}
 	// Let child components update
 	return m.handleComponentUpdate(msg)
 }
 
 func (m model) handleKeyMessage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.statusNote = ""
 	// Handle F6 globally (from both chat and editor modes)
 	if msg.Type == tea.KeyF6 {
 		if m.mode == modeChat || m.mode == modeKQLEditor {
@@ -67,8 +79,11 @@ func (m model) handleKeyMessage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.mode == modeListColumns {
+		return m.handleColumnPickerKey(msg)
+	}
 	// When in list mode, handle Esc and selection differently
-	if m.mode == modeListSubscriptions || m.mode == modeListInsightsResources {
+	if m.mode == modeListSubscriptions || m.mode == modeListInsightsResources || m.mode == modeListHistory || m.mode == modeListSavedQueries || m.mode == modeListTimespan {
 		return m.handleListKey(msg)
 	}
 	// Editor mode key handling
@@ -79,12 +94,32 @@ func (m model) handleKeyMessage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 	// When in table mode, handle Esc to return to previous mode; delegate nav to table
 	if m.mode == modeTableResults {
+		if m.tblFind.typing != 0 {
+			return m.handleFindPromptKey(msg)
+		}
 		return m.handleTableKey(msg)
 	}
 	// When in details mode, allow scrolling and Esc to close
 	if m.mode == modeDetails {
 		return m.handleDetailsKey(msg)
 	}
+	// In chat mode: Up/Down recall query history instead of moving the cursor
+	if msg.Type == tea.KeyUp || msg.Type == tea.KeyDown {
+		dir := 1
+		if msg.Type == tea.KeyDown {
+			dir = -1
+		}
+		if m.recallHistory(dir) && strings.Contains(m.ta.Value(), "\n") {
+			// Multi-line entries open in the editor, as canceled queries do: joined onto
+			// one line, a // comment would swallow the rest of the query
+			pos, draft := m.histPos, m.histDraft
+			mm, cmd := m.enterEditor("", false)
+			em := mm.(model)
+			em.histPos, em.histDraft = pos, draft
+			return em, cmd
+		}
+		return m, nil
+	}
 	// In chat mode: Let textarea consume keys first so typing works
 	var cmd tea.Cmd
 	m.ta, cmd = m.ta.Update(msg)
@@ -97,6 +132,10 @@ func (m model) handleKeyMessage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m model) handleTableKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case keyEsc:
+		// First Esc clears an active search or filter
+		if m.clearFind() {
+			return m, nil
+		}
 		// Restore to the mode we were in before opening the table
 		previousMode := m.returnMode
 		if previousMode == modeChat || previousMode == modeKQLEditor {
@@ -128,29 +167,62 @@ func (m model) handleTableKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.vp.GotoBottom()
 		}
 		return m, nil
+	case "ctrl+s":
+		m.exportTableView()
+		return m, nil
+	case "left", "h":
+		m.moveColumnCursor(-1)
+		return m, nil
+	case "right", "l":
+		m.moveColumnCursor(1)
+		return m, nil
+	case "c", "y", "q":
+		if m2, cmd, handled := m.handleCopyKey(msg.String()); handled {
+			return m2, cmd
+		}
+		return m, nil
+	case "s":
+		m.toggleSort()
+		return m, nil
+	case "M":
+		m.toggleFreezeMessage()
+		return m, nil
+	case "C":
+		return m.openColumnPicker()
+	case "/", "&":
+		cmd := m.openFindPrompt(rune(msg.String()[0]))
+		return m, cmd
+	case "n":
+		m.jumpToMatch(1)
+		return m, nil
+	case "N":
+		m.jumpToMatch(-1)
+		return m, nil
+	case "tab":
+		return m.switchTable(1)
+	case "shift+tab":
+		return m.switchTable(-1)
 	case keyEnter:
 		// Open details for selected row
 		sel := m.tbl.SelectedRow()
 		if sel != nil {
-			idx := m.tbl.Cursor()
+			idx := m.rowIndex(m.tbl.Cursor())
 			if idx >= 0 && idx < len(m.lastRows) {
-				ts, msg, fields := buildDetailsSafe(m.lastColumns, m.lastRows[idx])
-				hasTS := ts != ""
-				logging.Info("details_opened",
-					"table", util.FirstNonEmpty(m.lastTable, "PrimaryResult"),
-					"row_index", fmt.Sprintf("%d", idx),
-					"has_timestamp", fmt.Sprintf("%v", hasTS),
-					"custom_count", fmt.Sprintf("%d", len(fields)),
-				)
+				m.openDetails(idx)
 				m.detailsStart = time.Now()
-				m.detailsRow = idx
-				m.detailsContent = renderDetails(util.FirstNonEmpty(m.lastTable, "PrimaryResult"), idx, ts, msg, fields)
-				m.detailsVP.SetContent(m.detailsContent)
 				m.mode = modeDetails
 				return m, nil
 			}
 		}
 		return m, nil
+	case "down", "j", "pgdown", "end":
+		// Moving past the last row loads older rows when the results support it
+		if m.atTableEnd() && m.canLoadMore() {
+			return m.startLoadMore()
+		}
+		var cmd tea.Cmd
+		m.tbl, cmd = m.tbl.Update(msg)
+		return m, cmd
 	default:
 		var cmd tea.Cmd
 		m.tbl, cmd = m.tbl.Update(msg)
@@ -158,7 +230,25 @@ func (m model) handleTableKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
-// handleDetailsKey processes keys in details mode (Esc to close)
+// openDetails shows the details of lastRows[idx], keeping the expanded tree nodes.
+func (m *model) openDetails(idx int) {
+	ts, msg, fields := buildDetailsSafe(m.lastColumns, m.lastRows[idx])
+	hasTS := ts != ""
+	logging.Info("details_opened",
+		"table", util.FirstNonEmpty(m.lastTable, "PrimaryResult"),
+		"row_index", fmt.Sprintf("%d", idx),
+		"has_timestamp", fmt.Sprintf("%v", hasTS),
+		"custom_count", fmt.Sprintf("%d", len(fields)),
+	)
+	m.detailsRow = idx
+	m.details = newDetailsTree(m.lastTable, idx, ts, msg, m.lastColumns, m.lastRows[idx], m.details.expanded)
+	m.detailsVP.GotoTop()
+	m.renderDetailsTree()
+}
+
+// handleDetailsKey processes keys in details mode: the customDimensions tree,
+// stepping between rows, copies and Esc to close.
+// nolint:gocyclo // One case per key keeps the bindings easy to scan.
 func (m model) handleDetailsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case keyEsc:
@@ -166,10 +256,52 @@ func (m model) handleDetailsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		logging.Info("details_closed", "duration_ms", fmt.Sprintf("%d", dur.Milliseconds()))
 		m.mode = modeTableResults
 		return m, nil
+	case "c", "y", "q":
+		if m2, cmd, handled := m.handleCopyKey(msg.String()); handled {
+			return m2, cmd
+		}
+		return m, nil
+	case "up", "k":
+		m.moveDetailsCursor(-1)
+	case "down", "j":
+		m.moveDetailsCursor(1)
+	case "pgup", "b":
+		m.moveDetailsCursor(-max(m.detailsVP.Height-1, 1))
+	case "pgdown", "f":
+		m.moveDetailsCursor(max(m.detailsVP.Height-1, 1))
+	case "home", "g":
+		m.moveDetailsCursor(-len(m.details.nodes))
+	case "end", "G":
+		m.moveDetailsCursor(len(m.details.nodes))
+	case "right", "l":
+		m.details.expand()
+	case "left", "h":
+		m.details.collapse()
+	case keyEnter, " ":
+		m.details.toggle()
+	case "e":
+		m.details.expandAll()
+	case "E":
+		m.details.collapseAll()
+	case "[":
+		m.stepDetailsRow(-1)
+		return m, nil
+	case "]":
+		m.stepDetailsRow(1)
+		return m, nil
+	case "{":
+		m.showDetailsAt(0)
+		return m, nil
+	case "}":
+		m.showDetailsAt(len(m.shownRows()) - 1)
+		return m, nil
+	default:
+		var cmd tea.Cmd
+		m.detailsVP, cmd = m.detailsVP.Update(msg)
+		return m, cmd
 	}
-	var cmd tea.Cmd
-	m.detailsVP, cmd = m.detailsVP.Update(msg)
-	return m, cmd
+	m.renderDetailsTree()
+	return m, nil
 }
 
 func (m model) handleSubsLoaded(msg subsLoadedMsg) (tea.Model, tea.Cmd) {
@@ -228,8 +360,33 @@ func (m model) handleComponentUpdate(msg tea.Msg) (tea.Model, tea.Cmd) {
 // handleEditorKey processes key events in multi-line editor mode.
 // Returns (model, cmd, handled) where handled indicates the key was consumed.
 func (m model) handleEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	if m.completion.active {
+		if m2, cmd, handled := m.handleCompletionKey(msg); handled {
+			mm := m2.(model)
+			mm.scrollEditor()
+			return mm, cmd, true
+		}
+	}
 	switch msg.Type {
+	case tea.KeyTab, tea.KeyCtrlAt:
+		// Ctrl+Space arrives as Ctrl+@ (NUL) in most terminals
+		if msg.Type == tea.KeyTab && !tabCompletes(textBeforeCursor(m.ta)) {
+			m.ta.InsertString(editorIndent)
+			if m.editorErr != nil {
+				m.clearEditorError()
+			}
+		} else {
+			m.triggerCompletion()
+		}
+		m.scrollEditor()
+		return m, nil, true
 	case tea.KeyEsc:
+		// Esc while a query runs cancels the query and keeps the buffer
+		if m.runningKQL && m.cancelKQL != nil {
+			m.cancelRunningQuery()
+			return m, nil, true
+		}
+		m.clearEditorError()
 		logging.Info("Exiting editor mode (cancel)", "insertNewline", "true=>false", "prompt", promptEditor+"=>"+promptDefault)
 		m.mode = modeChat
 		m.ta.KeyMap.InsertNewline.SetEnabled(false)
@@ -237,6 +394,7 @@ func (m model) handleEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
 		// Discard the multi-line draft and restore single-line height
 		m.ta.Reset()
 		m.ta.SetHeight(3)
+		m.resetHistoryBrowse()
 		m.append("Canceled edit.")
 		// Ensure focus remains on textarea, then recalc layout immediately
 		var focusCmd tea.Cmd
@@ -249,15 +407,47 @@ func (m model) handleEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
 		return m, tea.WindowSize(), true
 	case tea.KeyEnter:
 		// Let textarea handle newline insertion; not handled here
+	case tea.KeyUp:
+		// Recall older history only from the first line so normal cursor movement still works
+		if m.ta.Line() == 0 && m.recallHistory(1) {
+			return m, nil, true
+		}
+	case tea.KeyDown:
+		// Recall newer history from the last line while browsing
+		if m.histPos > 0 && m.ta.Line() >= m.ta.LineCount()-1 && m.recallHistory(-1) {
+			return m, nil, true
+		}
+	}
+	if msg.String() == "ctrl+o" {
+		m.completion = completionState{}
+		cmd := m.openExternalEditor(m.ta.Value(), false)
+		return m, cmd, true
+	}
+	// Shift+Alt+F formats the buffer; F4 for terminals that swallow the chord
+	if s := msg.String(); s == "alt+F" || s == "f4" {
+		m.formatEditor()
+		return m, nil, true
 	}
 	// Detect common submit chords via string (Windows terminals often map ctrl+enter to ctrl+m)
 	// Also accept F5 and Ctrl+R as reliable run keys across terminals.
 	if s := msg.String(); s == "ctrl+enter" || s == "ctrl+m" || s == "alt+enter" || s == "f5" || s == "ctrl+r" {
 		return m, func() tea.Msg { return submitEditorMsg{} }, true
 	}
+	// Run only the blank-line separated block at the cursor
+	if s := msg.String(); s == "f9" || s == "alt+r" {
+		return m, func() tea.Msg { return submitEditorMsg{block: true} }, true
+	}
 	// Forward to textarea for normal editing behavior
+	before := m.ta.Value()
 	var cmd tea.Cmd
 	m.ta, cmd = m.ta.Update(msg)
+	if m.editorErr != nil && m.ta.Value() != before {
+		m.clearEditorError()
+	}
+	if m.completion.active {
+		m.refreshCompletion()
+	}
+	m.scrollEditor()
 	return m, cmd, true
 }
 
@@ -324,6 +514,21 @@ func (m model) handleResize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c", "esc":
+		// Esc while asking for query parameters cancels the prompt instead of quitting
+		if msg.String() == keyEsc && m.paramPrompt != nil {
+			m.cancelParamPrompt()
+			return m, nil
+		}
+		// Esc while tailing stops the tail instead of quitting
+		if msg.String() == keyEsc && m.tail.active {
+			m.stopTail("stopped")
+			return m, nil
+		}
+		// Esc while a query runs cancels the query instead of quitting
+		if msg.String() == keyEsc && m.runningKQL && m.cancelKQL != nil {
+			m.cancelRunningQuery()
+			return m, nil
+		}
 		m.quitting = true
 		return m, tea.Quit
 	case keyEnter:
@@ -333,6 +538,10 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		input := strings.TrimSpace(m.ta.Value())
 		m.ta.Reset()
+		m.resetHistoryBrowse()
+		if m.paramPrompt != nil {
+			return m.handleParamInput(input)
+		}
 		if input == "" {
 			// No implicit open; F6 is the only way to open interactively
 			return m, nil
@@ -368,32 +577,43 @@ func (m model) handlePostAuthCommand(input string) (tea.Model, tea.Cmd) {
 	}())
 	switch input {
 	case "help", "?":
-		m.append("Commands: help, keys, subs, resources, config, config get <key>, config set <key>=<value>, kql: <query>, login, quit")
+		m.append("Commands: help, keys, subs, resources, config, config get <key>, config set <key>=<value>, kql: <query>, edit, edit-external [run], format [kql], export <format> <path> [display] [flatten], copy, columns, history [filter], save <name> [kql], run <name> [param=value ...], queries, time [range|off], more, tail [filter|saved-query|stop], cancel, login, quit")
 		m.append("Tip: type 'keys' for keybindings.")
 	case "quit", "exit":
 		m.quitting = true
 		return m, tea.Quit
 	case "edit":
-		// Enter multi-line editor mode
-		logging.Info("Entering editor mode", "insertNewline", "false=>true", "prompt", m.ta.Prompt+"=>"+promptEditor)
-		m.mode = modeKQLEditor
-		m.ta.KeyMap.InsertNewline.SetEnabled(true)
-		m.origPrompt = m.ta.Prompt
-		m.ta.Prompt = promptEditor
-		// Resize textarea height on next WindowSize or compute now using current height
-		// Ensure focus remains on textarea
-		var focusCmd tea.Cmd
-		if !m.ta.Focused() {
-			m.ta, focusCmd = m.ta.Update(tea.FocusMsg{})
-		}
-		// Show helper hint and keybindings
-		m.append(hintEditor)
-		m.showKeys()
-		// Trigger a resize recompute to adjust heights
-		if focusCmd != nil {
-			return m, tea.Batch(focusCmd, tea.WindowSize())
+		return m.enterEditor("", true)
+	case "format":
+		return m.handleFormatCommand("")
+	case "edit-external":
+		return m.handleEditExternalCommand("")
+	case "export":
+		m.append(exportUsage)
+		return m, nil
+	case "copy":
+		cmd := m.copyToClipboard("query", m.resultQuery())
+		m.append(strings.ToUpper(m.statusNote[:1]) + m.statusNote[1:] + ".")
+		return m, cmd
+	case "history":
+		return m.openHistoryPanel("")
+	case "queries":
+		return m.openSavedQueriesPanel()
+	case "columns":
+		return m.openColumnPicker()
+	case "time":
+		return m.openTimespanPanel()
+	case "cancel":
+		if m.tail.active {
+			m.stopTail("stopped")
+			return m, nil
 		}
-		return m, tea.WindowSize()
+		m.cancelRunningQuery()
+		return m, nil
+	case "tail":
+		return m.handleTailCommand("")
+	case "more":
+		return m.startLoadMore()
 	case "subs":
 		logging.Debug("Entering list subscriptions mode")
 		// Open subscriptions list panel and load items
@@ -427,10 +647,32 @@ func (m model) handlePostAuthCommand(input string) (tea.Model, tea.Cmd) {
 				m.append("Query cannot be empty.")
 				return m, nil
 			}
-			// Start running
-			m.append("Running query…")
-			m.runningKQL = true
-			return m, m.runKQLCmd(q)
+			// Start running (asking for any {{param}} placeholders first)
+			return m.startTemplateRun("", q, nil, nil)
+		}
+		if strings.HasPrefix(lower, "edit-external ") {
+			return m.handleEditExternalCommand(input[len("edit-external "):])
+		}
+		if strings.HasPrefix(lower, "export ") {
+			return m.handleExportCommand(input[len("export "):])
+		}
+		if strings.HasPrefix(lower, "format ") {
+			return m.handleFormatCommand(input[len("format "):])
+		}
+		if strings.HasPrefix(lower, "history ") {
+			return m.openHistoryPanel(strings.TrimSpace(input[len("history "):]))
+		}
+		if strings.HasPrefix(lower, "save ") {
+			return m.handleSaveCommand(input[len("save "):])
+		}
+		if strings.HasPrefix(lower, "tail ") {
+			return m.handleTailCommand(input[len("tail "):])
+		}
+		if strings.HasPrefix(lower, "run ") {
+			return m.handleRunSavedCommand(input[len("run "):])
+		}
+		if strings.HasPrefix(lower, "time ") {
+			return m.handleTimeCommand(strings.TrimSpace(input[len("time "):]))
 		}
 		// Handle extended config commands
 		if strings.HasPrefix(input, "config ") {
@@ -442,32 +684,156 @@ func (m model) handlePostAuthCommand(input string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// enterEditor switches to multi-line editor mode, optionally preloading text.
+// When showHelp is true the editor hint and keybindings are appended to the scrollback.
+func (m model) enterEditor(initial string, showHelp bool) (tea.Model, tea.Cmd) {
+	logging.Info("Entering editor mode", "insertNewline", "false=>true", "prompt", m.ta.Prompt+"=>"+promptEditor)
+	m.mode = modeKQLEditor
+	m.editorParams = nil
+	m.ta.KeyMap.InsertNewline.SetEnabled(true)
+	if m.ta.Prompt != promptEditor {
+		m.origPrompt = m.ta.Prompt
+	}
+	m.ta.Prompt = promptEditor
+	if initial != "" {
+		m.ta.SetValue(initial)
+		m.ta.CursorEnd()
+	}
+	m.resetHistoryBrowse()
+	// Ensure focus remains on textarea
+	var focusCmd tea.Cmd
+	if !m.ta.Focused() {
+		m.ta, focusCmd = m.ta.Update(tea.FocusMsg{})
+	}
+	if showHelp {
+		m.append(hintEditor)
+		m.showKeys()
+	}
+	// Trigger a resize recompute to adjust heights
+	if focusCmd != nil {
+		return m, tea.Batch(focusCmd, tea.WindowSize())
+	}
+	return m, tea.WindowSize()
+}
+
+// openHistoryPanel shows the query history newest-first in the list panel,
+// optionally pre-filtered with the fuzzy filter text.
+func (m model) openHistoryPanel(filter string) (tea.Model, tea.Cmd) {
+	if m.history.len() == 0 {
+		m.append("No query history yet. Run a query with 'kql: <query>' or 'edit'.")
+		return m, nil
+	}
+	items := make([]list.Item, 0, m.history.len())
+	for i := 0; i < m.history.len(); i++ {
+		e, _ := m.history.recent(i)
+		items = append(items, historyItem{e: e})
+	}
+	logging.Debug("Opening query history panel", "entries", fmt.Sprintf("%d", len(items)), "filtered", fmt.Sprintf("%t", filter != ""))
+	m.mode = modeListHistory
+	m.list.Title = titleQueryHistory
+	m.list.ResetFilter()
+	m.list.SetItems(items)
+	m.list.Select(0)
+	if filter != "" {
+		m.list.SetFilterText(filter)
+	}
+	m.append(fmt.Sprintf("Opened query history (%d entries). Enter loads the query into the editor.", len(items)))
+	return m, nil
+}
+
 // internal message and handler for editor submission
-type submitEditorMsg struct{}
+type submitEditorMsg struct {
+	block bool // run only the blank-line separated block at the cursor
+}
 
 func (m model) handleEditorSubmit() (tea.Model, tea.Cmd) {
+	return m.submitEditor(false)
+}
+
+// submitEditor runs the editor buffer, or with block set only the block at the cursor.
+func (m model) submitEditor(block bool) (tea.Model, tea.Cmd) {
+	if m.queryBusy() {
+		return m, nil
+	}
+	m.clearEditorError()
+	m.completion = completionState{}
 	// Normalize line endings and trim
 	raw := m.ta.Value()
 	// Don't mutate textarea content in place for now; we will reset after submission/cancel
 	normalized := strings.ReplaceAll(raw, "\r\n", "\n")
 	normalized = strings.ReplaceAll(normalized, "\r", "\n")
-	trimmed := strings.TrimSpace(normalized)
+	text, firstLine := normalized, 0
+	if block {
+		row, _ := textareaCursor(m.ta)
+		text, firstLine = editorBlock(normalized, row)
+		if strings.TrimSpace(text) == "" {
+			m.append("The cursor is on a blank line; move it into the query to run.")
+			return m, nil
+		}
+	}
+	trimmed := strings.TrimSpace(text)
 	if trimmed == "" {
 		m.append("Query cannot be empty.")
 		return m, nil
 	}
+	// Error locations are relative to the sent text; remember where it starts in the buffer
+	lead := text[:len(text)-len(strings.TrimLeftFunc(text, unicode.IsSpace))]
+	m.runLineOffset = firstLine + strings.Count(lead, "\n")
+	m.runColumnOffset = utf8.RuneCountInString(lead[strings.LastIndexByte(lead, '\n')+1:])
+	// Placeholders must resolve from defaults (inline, or stored with the loaded
+	// saved query); the editor cannot prompt for values
+	query := trimmed
+	if params := templateParams(trimmed, m.editorParams); len(params) > 0 {
+		values := map[string]string{}
+		var missing []queryParam
+		for _, p := range params {
+			if !p.HasDefault {
+				missing = append(missing, p)
+				continue
+			}
+			values[p.Name] = p.Default
+		}
+		if len(missing) > 0 {
+			m.append(fmt.Sprintf("Query has placeholders without defaults: %s. Use {{name=value}}, or save it and run with 'run <name> name=value'.", paramNames(missing)))
+			return m, nil
+		}
+		query = expandTemplate(trimmed, values)
+	}
+	m.lastTemplate = trimmed
 	// Log safe details and exit editor mode before running
 	logging.Info("Submitting editor query")
+	m.resetHistoryBrowse()
 	// Echo first line + ellipsis
-	firstLine := trimmed
-	if idx := strings.IndexByte(firstLine, '\n'); idx >= 0 {
-		firstLine = firstLine[:idx] + " …"
+	echo := trimmed
+	if idx := strings.IndexByte(echo, '\n'); idx >= 0 {
+		echo = echo[:idx] + " …"
 	}
-	m.append("> " + firstLine)
+	if block {
+		echo += fmt.Sprintf(" (lines %d-%d)", m.runLineOffset+1, m.runLineOffset+strings.Count(trimmed, "\n")+1)
+	}
+	m.append("> " + echo)
 	m.append("Running…")
 	// Stay in editor mode while the query runs; keep multi-line editing active
 	// Dispatch KQL pipeline
-	return m, m.runKQLCmd(trimmed)
+	cmd := m.runKQLCmd(query)
+	return m, cmd
+}
+
+// editorBlock returns the lines around row up to the nearest blank lines, and
+// the 0-based line the block starts on. A blank row yields an empty block.
+func editorBlock(text string, row int) (string, int) {
+	lines := strings.Split(text, "\n")
+	if row < 0 || row >= len(lines) || strings.TrimSpace(lines[row]) == "" {
+		return "", row
+	}
+	start, end := row, row
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	for end < len(lines)-1 && strings.TrimSpace(lines[end+1]) != "" {
+		end++
+	}
+	return strings.Join(lines[start:end+1], "\n"), start
 }
 
 // handleConfigSubcommand parses and executes `config get` and `config set` operations
@@ -506,6 +872,10 @@ func (m model) handleConfigSubcommand(sub string) (tea.Model, tea.Cmd) {
 		}
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
+		// The time range is parsed here and also applied to the running session
+		if key == config.SettingQueryTimespan {
+			return m.handleTimeCommand(value)
+		}
 
 		// Capture old value (masked where applicable)
 		oldVal, _ := m.cfg.GetSettingValue(key)
@@ -538,6 +908,12 @@ func (m model) handleConfigSubcommand(sub string) (tea.Model, tea.Cmd) {
 
 // handleListKey processes keys while in list modes (subscriptions or insights resources)
 func (m model) handleListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// While the filter prompt is active, the list owns Esc/Enter (cancel/apply filter)
+	if m.list.SettingFilter() {
+		var cmd tea.Cmd
+		m.list, cmd = m.list.Update(msg)
+		return m, cmd
+	}
 	switch msg.String() {
 	case keyEsc:
 		switch m.mode {
@@ -545,6 +921,12 @@ func (m model) handleListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.append("Closed subscriptions panel.")
 		case modeListInsightsResources:
 			m.append("Closed Application Insights resources panel.")
+		case modeListHistory:
+			m.append("Closed query history panel.")
+		case modeListSavedQueries:
+			m.append("Closed saved queries panel.")
+		case modeListTimespan:
+			m.append("Closed time range panel.")
 		}
 		m.mode = modeChat
 		return m, nil
@@ -570,8 +952,35 @@ func (m model) handleListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				}
 				m.mode = modeChat
 			}
+		case modeListHistory:
+			if sel, ok := m.list.SelectedItem().(historyItem); ok {
+				logging.Info("History entry selected", "query_len", fmt.Sprintf("%d", len(sel.e.Query)))
+				m.append("Loaded query from history into the editor.")
+				return m.enterEditor(sel.e.Query, false)
+			}
+		case modeListSavedQueries:
+			if sel, ok := m.list.SelectedItem().(savedQueryItem); ok {
+				m.mode = modeChat
+				return m.startTemplateRun(sel.q.Name, sel.q.Query, sel.q.Params, nil)
+			}
+		case modeListTimespan:
+			if sel, ok := m.list.SelectedItem().(timespanItem); ok {
+				m.mode = modeChat
+				return m.handleTimeCommand(sel.value)
+			}
 		}
 		return m, nil
+	case "e":
+		if m.mode == modeListSavedQueries {
+			if sel, ok := m.list.SelectedItem().(savedQueryItem); ok {
+				m.lastTemplate = sel.q.Query
+				m.append(fmt.Sprintf("Loaded saved query '%s' into the editor.", sel.q.Name))
+				mAny, cmd := m.enterEditor(sel.q.Query, false)
+				mm := mAny.(model)
+				mm.editorParams = sel.q.Params
+				return mm, cmd
+			}
+		}
 	}
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
@@ -617,25 +1026,78 @@ func (m model) handleAuthError(msg authErrorMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleKQLRetry shows an automatic retry of the running query and keeps listening for more.
+func (m model) handleKQLRetry(msg kqlRetryMsg) (tea.Model, tea.Cmd) {
+	if !m.runningKQL {
+		// late event after the result arrived; nothing left to report
+		return m, waitForRetryEvent(msg.events)
+	}
+	ev := msg.event
+	m.retryStatus = fmt.Sprintf("(retry %d/%d)", ev.Attempt, ev.MaxAttempts)
+	m.append(describeRetry(ev))
+	if m.followTail || m.vp.AtBottom() {
+		m.vp.GotoBottom()
+	}
+	return m, waitForRetryEvent(msg.events)
+}
+
+// handleKQLCanceled reports a user-canceled query and hands its text back for editing:
+// the editor buffer is left untouched; in chat the query is restored to the input
+// (multi-line queries open in the editor).
+func (m model) handleKQLCanceled(res kqlResultMsg) (tea.Model, tea.Cmd) {
+	m.append(fmt.Sprintf("Query canceled after %.3fs.", res.duration.Seconds()))
+	if m.mode != modeChat || strings.TrimSpace(m.ta.Value()) != "" {
+		return m, nil
+	}
+	text := util.FirstNonEmpty(m.lastTemplate, res.query)
+	if strings.Contains(text, "\n") {
+		m.append("Query restored to the editor.")
+		return m.enterEditor(text, false)
+	}
+	m.ta.SetValue("kql: " + text)
+	m.ta.CursorEnd()
+	return m, nil
+}
+
 // handleKQLResult processes the outcome of a KQL execution
 func (m model) handleKQLResult(res kqlResultMsg) (tea.Model, tea.Cmd) {
+	if res.kind == kqlRunTail && (!m.tail.active || res.tailGen != m.tail.gen) {
+		// A poll of a stopped tail; stopTail already reset the running state
+		return m, nil
+	}
 	m.runningKQL = false
+	m.retryStatus = ""
+	m.cancelKQL = nil
+	m.canceling = false
+	switch res.kind {
+	case kqlRunPage:
+		return m.handleKQLPage(res)
+	case kqlRunTail:
+		return m.handleKQLTail(res)
+	}
+	m.recordHistory(res)
+	if res.canceled {
+		return m.handleKQLCanceled(res)
+	}
 	if res.err != nil {
 		logging.Error("KQL result error", "error", res.err.Error())
-		m.append(res.err.Error())
+		m.showKQLError(res)
 		m.haveResults = false
+		m.paging = pagingState{}
 		return m, nil
 	}
 	// Zero tables case
-	if len(res.columns) == 0 && len(res.rows) == 0 {
-		m.append(fmt.Sprintf("Query complete in %.3fs · 0 rows", res.duration.Seconds()))
+	tables := tablesFromResult(res)
+	if len(tables) == 0 || !anyTableHasData(tables) {
+		m.append(fmt.Sprintf("Query complete in %.3fs · 0 rows", res.duration.Seconds()) + fetchLimitSummary(res.fetchLimit))
 		m.append("No results.")
 		m.haveResults = false
+		m.lastTables = nil
+		m.paging = pagingState{}
 		return m, nil
 	}
-	// Build snapshot
-	// Compute canonical headers using ranking (Step 10) with fallback to alphabetical
-	m.lastDisplayHeaders = computeRankedHeaders(res.columns, res.rows, m.cfg)
+	// Keep every table; each gets its own ranked headers (Step 10) when activated
+	m.setResultTables(tables, primaryTableIndex(tables))
 	// Log header diagnostics for troubleshooting very wide schemas
 	customCount := 0
 	if len(m.lastDisplayHeaders) >= 2 {
@@ -651,11 +1113,23 @@ func (m model) handleKQLResult(res kqlResultMsg) (tea.Model, tea.Cmd) {
 		"count", fmt.Sprintf("%d", len(m.lastDisplayHeaders)),
 		"custom_count", fmt.Sprintf("%d", customCount),
 		"example_keys", sample,
+		"tables", fmt.Sprintf("%d", len(m.lastTables)),
 	)
-	summary := fmt.Sprintf("Query complete in %.3fs · %d rows · table: %s", res.duration.Seconds(), len(res.rows), util.FirstNonEmpty(res.tableName, "PrimaryResult"))
+	summary := fmt.Sprintf("Query complete in %.3fs · %d rows · table: %s", res.duration.Seconds(), len(m.lastRows), util.FirstNonEmpty(m.lastTable, "PrimaryResult"))
+	if !res.timespan.IsZero() {
+		summary += " · time: " + res.timespan.Describe()
+	}
+	if res.retries > 0 {
+		summary += fmt.Sprintf(" · attempts: %d", res.retries+1)
+	}
+	summary += fetchLimitSummary(res.fetchLimit)
 	m.append(summary)
-	snapshot := m.renderSnapshot(res.columns, res.rows)
-	if snapshot != "" {
+	if len(m.lastTables) > 1 {
+		m.append(m.tablesSummary())
+		for _, line := range m.renderTableSnapshots() {
+			m.append(line)
+		}
+	} else if snapshot := m.renderSnapshot(m.lastColumns, m.lastRows); snapshot != "" {
 		m.append(snapshot)
 	}
 	if m.mode == modeKQLEditor {
@@ -663,12 +1137,11 @@ func (m model) handleKQLResult(res kqlResultMsg) (tea.Model, tea.Cmd) {
 	} else {
 		m.append("Press F6 to open interactively.")
 	}
+	m.rememberCompletionNames(res.appID)
 	// Store for interactive
-	m.lastColumns = res.columns
-	m.lastRows = res.rows
-	m.lastTable = res.tableName
 	m.lastDuration = res.duration
 	m.haveResults = true
+	m.resetPaging(res)
 	// Scroll to bottom for visibility
 	if m.followTail || m.vp.AtBottom() {
 		m.vp.GotoBottom()
@@ -676,6 +1149,25 @@ func (m model) handleKQLResult(res kqlResultMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// fetchLimitSummary tells whether the client appended the fetch size as "| take N",
+// naming the capped statements when that is not just the first query statement;
+// empty when no fetch size is configured or the query was not parsed.
+func fetchLimitSummary(fl appinsights.FetchLimitDecision) string {
+	switch {
+	case fl.Applied && (len(fl.Statements) > 1 || len(fl.Statements) == 1 && fl.Statements[0] != 1):
+		nums := make([]string, len(fl.Statements))
+		for i, n := range fl.Statements {
+			nums[i] = strconv.Itoa(n)
+		}
+		return fmt.Sprintf(" · fetch limit: take %d added to query %s", fl.Limit, strings.Join(nums, ", "))
+	case fl.Applied:
+		return fmt.Sprintf(" · fetch limit: take %d added", fl.Limit)
+	case fl.Reason == "user_explicit":
+		return " · fetch limit: not added (query limits its rows)"
+	}
+	return ""
+}
+
 // renderSnapshot builds a Bubbles table string with dynamic columns, limited rows
 // nolint:gocyclo // Snapshot layout has a few branches to keep output readable; tested by UI suite.
 func (m *model) renderSnapshot(columns []appinsights.Column, rows [][]interface{}) string {
@@ -744,11 +1236,15 @@ func (m *model) renderSnapshot(columns []appinsights.Column, rows [][]interface{
 	return tm.View()
 }
 
-// initInteractiveTable builds a focused table model with all rows and columns
+// initInteractiveTable builds a focused table model with all rows and the
+// columns of the current horizontal scroll position.
 // nolint:gocyclo // Column sizing and ordering branches kept explicit for readability.
 func (m *model) initInteractiveTable() {
 	columns := m.lastColumns
 	rows := m.lastRows
+	m.tblOrder = nil
+	m.tblVisible = nil
+	m.tblFind.matches = nil
 	if len(columns) == 0 || len(rows) == 0 {
 		m.tbl = table.New()
 		return
@@ -760,35 +1256,62 @@ func (m *model) initInteractiveTable() {
 	}
 	_, data := buildDisplayMatrixFromHeaders(headers, columns, rows)
 	width := m.vp.Width
-	layout := computeColumnLayout(headers, width, 14)
-	if len(layout.visible) == 0 {
+	if len(headers) == 0 {
 		m.tbl = table.New()
 		return
 	}
+	m.tblCol = clamp(m.tblCol, 0, len(headers)-1)
+	layout := m.scrollToColumnCursor(contentColumnWidths(headers, data), m.frozenColumns(headers), width)
+	m.tblVisible = layout.visible
+	order := m.filterRows(headers, data)
+	sortCol := m.sortColumnIndex(headers)
+	if sortCol >= 0 {
+		if order == nil {
+			order = allRows(len(data))
+		}
+		order = sortedRowOrder(data, order, sortCol, columnSortKind(headers[sortCol], columns, data, sortCol), m.tblSort.desc)
+	}
+	m.tblOrder, m.tblOrderOf = order, len(rows)
 	cols := make([]table.Column, 0, len(layout.visible)+1)
-	for _, h := range layout.visible {
-		cols = append(cols, table.Column{Title: h, Width: layout.colWidth})
+	for k, hi := range layout.visible {
+		h := headers[hi]
+		if hi == sortCol {
+			h = sortMark(m.tblSort.desc) + " " + h
+		}
+		if hi == layout.offset && layout.hiddenLeft > 0 {
+			h = scrolledLeftMark + h
+		}
+		if hi == m.tblCol {
+			h = columnCursorMark + h
+		}
+		cols = append(cols, table.Column{Title: h, Width: layout.widths[k]})
 	}
-	if layout.truncated && layout.hiddenCount > 0 {
-		logging.Info("Interactive columns truncated",
+	if layout.hiddenLeft+layout.hiddenRight > 0 {
+		logging.Debug("Interactive columns scrolled",
 			"width", fmt.Sprintf("%d", width),
-			"colCount", fmt.Sprintf("%d", len(layout.headers)),
+			"colCount", fmt.Sprintf("%d", len(headers)),
 			"visible", fmt.Sprintf("%d", len(layout.visible)),
-			"dataCols", fmt.Sprintf("%d", layout.dataCols),
-			"hidden", fmt.Sprintf("%d", layout.hiddenCount),
+			"frozen", fmt.Sprintf("%d", layout.frozen),
+			"hiddenLeft", fmt.Sprintf("%d", layout.hiddenLeft),
+			"hiddenRight", fmt.Sprintf("%d", layout.hiddenRight),
 		)
-		cols = append(cols, table.Column{Title: fmt.Sprintf("(+%d)", layout.hiddenCount), Width: layout.colWidth})
 	}
-	trows := make([]table.Row, 0, len(data))
-	for _, r := range data {
-		tr := make([]string, 0, len(cols))
-		for j := 0; j < layout.dataCols && j < len(r); j++ {
-			tr = append(tr, r[j])
-		}
-		for len(tr) < layout.dataCols {
-			tr = append(tr, "")
+	if layout.hiddenRight > 0 {
+		title := fmt.Sprintf("(+%d)", layout.hiddenRight)
+		cols = append(cols, table.Column{Title: title, Width: len(title)})
+	}
+	shown := m.shownRows()
+	trows := make([]table.Row, 0, len(shown))
+	for i, idx := range shown {
+		r := data[idx]
+		tr := make([]string, len(layout.visible), len(cols))
+		for k, hi := range layout.visible {
+			if hi < len(r) {
+				tr[k] = r[hi]
+			}
 		}
-		if layout.truncated && layout.hiddenCount > 0 {
+		m.markSearchMatches(i, tr, layout.visible, headers, r, rows[idx])
+		if layout.hiddenRight > 0 {
 			tr = append(tr, "…")
 		}
 		trows = append(trows, tr)
@@ -816,32 +1339,6 @@ func buildDetailsSafe(columns []appinsights.Column, row []interface{}) (string,
 	return telemetry.BuildDetails(columns, row)
 }
 
-// renderDetails builds the content string for the details viewport.
-func renderDetails(table string, rowIdx int, timestamp, message string, fields []telemetry.DetailField) string {
-	b := &strings.Builder{}
-	// Header
-	fmt.Fprintf(b, "Details — %s · row %d\n", util.FirstNonEmpty(table, "PrimaryResult"), rowIdx)
-	// Timestamp
-	if strings.TrimSpace(timestamp) != "" {
-		fmt.Fprintf(b, "timestamp: %s\n", timestamp)
-	}
-	// Message
-	if strings.TrimSpace(message) != "" {
-		fmt.Fprintf(b, "message: %s\n", message)
-	}
-	// customDimensions section
-	fmt.Fprintf(b, "customDimensions:\n")
-	if len(fields) == 0 {
-		fmt.Fprintf(b, "  <none>\n")
-		return b.String()
-	}
-	for _, f := range fields {
-		// indent keys for readability
-		fmt.Fprintf(b, "  %s: %s\n", f.Key, f.Value)
-	}
-	return b.String()
-}
-
 // buildDisplayMatrix constructs headers and row strings for display using only
 // timestamp, message, and flattened customDimensions keys. The set of custom keys
 // is discovered from up to sampleLimit rows for a stable header set.
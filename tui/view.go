@@ -2,8 +2,12 @@ package tui
 
 import (
 	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
 )
 
+var statusStyle = lipgloss.NewStyle().Faint(true)
+
 // View renders the layout: top viewport and bottom textarea, centered.
 func (m model) View() string {
 	if m.quitting {
@@ -11,18 +15,86 @@ func (m model) View() string {
 	}
 	var top string
 	switch m.mode {
-	case modeListSubscriptions, modeListInsightsResources:
+	case modeListSubscriptions, modeListInsightsResources, modeListHistory, modeListSavedQueries, modeListTimespan, modeListColumns:
 		top = m.vpStyle.Render(m.list.View())
 	case modeTableResults:
 		top = m.vpStyle.Render(m.tbl.View())
 	case modeDetails:
 		top = m.vpStyle.Render(m.detailsVP.View())
 	case modeKQLEditor:
-		// Show scrollback in top area while editing
-		top = m.vpStyle.Render(m.vp.View())
+		// Show scrollback in top area while editing; the completion popup covers its bottom
+		body := m.vp.View()
+		if m.completion.active {
+			body = overlayBottom(body, m.completionPopup(m.vp.Width))
+		}
+		top = m.vpStyle.Render(body)
 	default:
 		top = m.vpStyle.Render(m.vp.View())
 	}
 	bottom := m.ta.View()
-	return m.containerStyle.Render(fmt.Sprintf("%s\n%s", top, bottom))
+	if m.mode == modeKQLEditor {
+		bottom = m.editorView()
+	} else if m.mode == modeTableResults && m.tblFind.typing != 0 {
+		// keep the input's height so the table does not jump while typing
+		bottom = lipgloss.NewStyle().Height(m.ta.Height()).Render(m.tblFind.prompt.View())
+	}
+	return m.containerStyle.Render(fmt.Sprintf("%s\n%s\n%s", top, m.statusLine(), bottom))
+}
+
+// statusLine renders the one-line indicator between the top panel and the input.
+func (m model) statusLine() string {
+	status := "time: " + m.timespan.Describe()
+	if m.mode == modeTableResults || m.mode == modeDetails {
+		if ts := m.tableStatus(); ts != "" {
+			status += " · " + ts
+		}
+	}
+	if m.mode == modeKQLEditor && m.editorErr != nil {
+		status += fmt.Sprintf(" · error at line %d, col %d", m.editorErr.line, m.editorErr.col)
+	}
+	if m.mode == modeTableResults && m.tblCol < len(m.lastDisplayHeaders) && len(m.lastDisplayHeaders) > 0 {
+		status += " · col: " + m.lastDisplayHeaders[m.tblCol]
+		if n := len(m.lastDisplayHeaders); len(m.tblVisible) < n {
+			status += fmt.Sprintf(" (%d of %d)", m.tblCol+1, n)
+		}
+	}
+	if m.mode == modeDetails {
+		if rs := m.detailsRowStatus(); rs != "" {
+			status += " · " + rs
+		}
+		if p := m.detailsPath(); p != "" {
+			status += " · " + p
+		}
+	}
+	if m.mode == modeListColumns {
+		status += " · " + columnPickerHint
+	}
+	if m.mode == modeTableResults {
+		if fs := m.findStatus(); fs != "" {
+			status += " · " + fs
+		}
+	}
+	if m.tail.active {
+		status += " · " + m.tailStatus()
+	}
+	if m.canceling {
+		status += " · canceling…"
+	} else if m.runningKQL && !m.tail.inflight {
+		// tail polls are shown by tailStatus
+		if m.paging.pending != nil {
+			status += " · loading more…"
+		} else {
+			status += " · running…"
+		}
+		if m.retryStatus != "" {
+			status += " " + m.retryStatus
+		}
+	}
+	if m.statusNote != "" {
+		status += " · " + m.statusNote
+	}
+	if w := m.ta.Width(); w > 0 {
+		status = truncateRunes(status, w)
+	}
+	return statusStyle.Render(status)
 }